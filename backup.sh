@@ -33,6 +33,13 @@ if [ $? -eq 0 ]; then
     echo "✅ Backup created successfully!"
     echo "   File: ${BACKUP_DIR}/${BACKUP_FILE}"
     echo "   Size: $(du -h "${BACKUP_DIR}/${BACKUP_FILE}" | cut -f1)"
+
+    # Uploaded photos live next to the database
+    IMAGES_BACKUP="${BACKUP_DIR}/${BACKUP_FILE%.db}_images"
+    if docker exec "$CONTAINER_NAME" test -d /app/data/images; then
+        docker cp "${CONTAINER_NAME}:/app/data/images" "$IMAGES_BACKUP"
+        echo "   Photos: ${IMAGES_BACKUP} ($(ls "$IMAGES_BACKUP" | wc -l | tr -d ' ') files)"
+    fi
     
     # Show backup contents summary
     echo ""
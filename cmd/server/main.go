@@ -1,50 +1,120 @@
 package main
 
 import (
+	"context"
 	"crypto/rand"
 	"fmt"
 	"log"
 	"math/big"
 	"net"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
+	"github.com/paran01d/lddb/internal/covers"
 	"github.com/paran01d/lddb/internal/database"
 	"github.com/paran01d/lddb/internal/handlers"
-	"github.com/paran01d/lddb/internal/models"
+	"github.com/paran01d/lddb/internal/images"
+	"github.com/paran01d/lddb/internal/migrations"
+	"github.com/paran01d/lddb/internal/refresh"
+	"github.com/paran01d/lddb/internal/scraper"
 )
 
 // Global access token (generated on startup)
 var accessToken string
 
 func main() {
+	dbPath := "data/collection.db"
+	if path := os.Getenv("LDDB_DB_PATH"); path != "" {
+		dbPath = path
+	}
+
+	// "server migrate status|up|down" manages the schema without serving
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{})
+		if err != nil {
+			log.Fatal("Failed to connect to database:", err)
+		}
+		if err := runMigrate(db, dbPath, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	// Generate mobile-friendly access token
 	accessToken = generateMobileToken()
 	log.Printf("🔑 Access Token: %s", accessToken)
 	log.Printf("   Enter this token when prompted to access the application")
 	
 	// Initialize database in data directory
-	db, err := gorm.Open(sqlite.Open("data/collection.db"), &gorm.Config{})
+	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{})
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
-	// Auto-migrate the schema
-	err = db.AutoMigrate(&models.LaserDisc{})
+	// Bring the schema up to date, backing up the database first
+	migrator, err := migrations.New(db)
 	if err != nil {
+		log.Fatal("Failed to load migrations:", err)
+	}
+	if _, err := migrateDatabase(db, dbPath, migrator); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
 	// Initialize database service
 	dbService := database.NewService(db)
 
+	// Search with FTS5 when SQLite was built with it (-tags sqlite_fts5)
+	if enabled, err := dbService.EnableFullTextSearch(); err != nil {
+		log.Fatal("Failed to set up full-text search:", err)
+	} else if !enabled {
+		log.Println("SQLite was built without FTS5, searching without the full-text index")
+	}
+
+	// Bring UPCs saved before barcode normalization into GTIN-14 form
+	if updated, err := dbService.NormalizeUPCs(); err != nil {
+		log.Fatal("Failed to normalize UPCs:", err)
+	} else if updated > 0 {
+		log.Printf("Normalized %d UPCs to GTIN-14", updated)
+	}
+
 	// Initialize handlers
-	collectionHandler := handlers.NewCollectionHandler(dbService)
-	lookupHandler := handlers.NewLookupHandler(dbService)
+	lookupHandler := handlers.NewLookupHandler(dbService, lookupConfigFromEnv())
+
+	// Mirror cover art locally in the background, sharing the scraper's rate limit
+	coversDir := "data/covers"
+	if dir := os.Getenv("LDDB_COVERS_DIR"); dir != "" {
+		coversDir = dir
+	}
+	coverStore := covers.NewStore(coversDir, lookupHandler.HTTPClient())
+	coverMirror := covers.NewMirror(coverStore, dbService)
+	coverMirror.Start(context.Background())
+
+	// Uploaded photos live next to the database so backups include them
+	imagesDir := "data/images"
+	if dir := os.Getenv("LDDB_IMAGES_DIR"); dir != "" {
+		imagesDir = dir
+	}
+	imageStore := images.NewStore(imagesDir, int64(envInt("LDDB_IMAGE_MAX_MB", images.DefaultMaxBytes>>20))<<20)
+
+	collectionHandler := handlers.NewCollectionHandler(dbService, coverMirror, imageStore)
+	coverHandler := handlers.NewCoverHandler(dbService, coverStore, imageStore)
+	imageHandler := handlers.NewImageHandler(dbService, imageStore)
+
+	// Refresh jobs re-scrape saved LaserDiscs, mirroring any new covers
+	if interrupted, err := dbService.FailInterruptedRefreshJobs(); err != nil {
+		log.Printf("Warning: Could not clean up refresh jobs: %v", err)
+	} else if interrupted > 0 {
+		log.Printf("Marked %d interrupted refresh jobs as failed", interrupted)
+	}
+	refreshRunner := refresh.NewRunner(dbService, lookupHandler.Scraper(), coverMirror.Enqueue)
+	refreshHandler := handlers.NewRefreshHandler(dbService, refreshRunner)
 
 	// Initialize Gin router
 	router := gin.Default()
@@ -115,11 +185,60 @@ func main() {
 		api.PUT("/collection/:id", collectionHandler.UpdateLaserDisc)
 		api.DELETE("/collection/:id", collectionHandler.DeleteLaserDisc)
 		api.POST("/collection/:id/watched", collectionHandler.ToggleWatched)
+		api.GET("/collection/:id/viewings", collectionHandler.GetViewingTimeline)
+		api.POST("/collection/:id/viewings", collectionHandler.AddViewing)
+		api.PUT("/collection/:id/viewings/:viewingId", collectionHandler.UpdateViewing)
+		api.DELETE("/collection/:id/viewings/:viewingId", collectionHandler.DeleteViewing)
+		api.GET("/collection/:id/copies", collectionHandler.ListCopies)
+		api.POST("/collection/:id/copies", collectionHandler.AddCopy)
+		api.PUT("/collection/:id/copies/:copyId", collectionHandler.UpdateCopy)
+		api.DELETE("/collection/:id/copies/:copyId", collectionHandler.DeleteCopy)
+		api.GET("/collection/:id/copies/:copyId/inspections", collectionHandler.ListInspections)
+		api.POST("/collection/:id/copies/:copyId/inspections", collectionHandler.AddInspection)
+		api.DELETE("/collection/:id/copies/:copyId/inspections/:inspectionId", collectionHandler.DeleteInspection)
+		api.GET("/inspections/worst", collectionHandler.GetWorstConditions)
+		api.GET("/inspections/rot", collectionHandler.GetRotProgressions)
+		api.GET("/collection/:id/cover", coverHandler.GetCover)
+		api.GET("/collection/:id/images", imageHandler.ListImages)
+		api.POST("/collection/:id/images", imageHandler.UploadImages)
+		api.PUT("/collection/:id/images", imageHandler.ReorderImages)
+		api.GET("/collection/:id/images/:imageId/file", imageHandler.GetImageFile)
+		api.PUT("/collection/:id/images/:imageId/primary", imageHandler.SetPrimaryImage)
+		api.DELETE("/collection/:id/images/:imageId", imageHandler.DeleteImage)
+		api.GET("/suggest", collectionHandler.SuggestValues)
+		api.GET("/export/:format", collectionHandler.ExportCollection)
+
+		// Tag and custom field endpoints
+		api.GET("/tags", collectionHandler.ListTags)
+		api.POST("/tags", collectionHandler.AddTag)
+		api.PUT("/tags/:tagId", collectionHandler.UpdateTag)
+		api.DELETE("/tags/:tagId", collectionHandler.DeleteTag)
+		api.GET("/custom-fields", collectionHandler.ListCustomFields)
+		api.POST("/custom-fields", collectionHandler.AddCustomField)
+		api.PUT("/custom-fields/:fieldId", collectionHandler.UpdateCustomField)
+		api.DELETE("/custom-fields/:fieldId", collectionHandler.DeleteCustomField)
 
 		// Lookup and random endpoints
 		api.GET("/lookup/:upc", lookupHandler.LookupByUPC)
 		api.GET("/lookup/reference/:reference", lookupHandler.LookupByReference)
+		api.GET("/lookup/lddb/:id", lookupHandler.LookupByLDDBID)
+		api.GET("/lookup/url", lookupHandler.LookupByURL)
+		api.GET("/lookup/search", lookupHandler.SearchByTitle)
 		api.GET("/random-unwatched", collectionHandler.GetRandomUnwatched)
+		api.GET("/random", collectionHandler.GetRandom)
+
+		// Metadata refresh endpoints
+		api.POST("/refresh/jobs", refreshHandler.StartRefresh)
+		api.GET("/refresh/jobs", refreshHandler.GetRefreshJobs)
+		api.GET("/refresh/jobs/:id", refreshHandler.GetRefreshJob)
+		api.DELETE("/refresh/jobs/:id", refreshHandler.CancelRefreshJob)
+		api.GET("/refresh/suggestions", refreshHandler.GetSuggestions)
+		api.POST("/refresh/suggestions/:id/accept", refreshHandler.AcceptSuggestion)
+		api.POST("/refresh/suggestions/:id/reject", refreshHandler.RejectSuggestion)
+
+		// Admin endpoints
+		api.DELETE("/admin/lookup-cache", lookupHandler.PurgeLookupCache)
+		api.POST("/admin/merge", collectionHandler.MergeValues)
 	}
 
 	// Find an available port starting from 8080
@@ -128,6 +247,66 @@ func main() {
 	log.Fatal(router.Run(fmt.Sprintf(":%d", port)))
 }
 
+// lookupConfigFromEnv builds the lookup configuration, letting environment
+// variables override the defaults
+func lookupConfigFromEnv() handlers.LookupConfig {
+	config := handlers.DefaultLookupConfig()
+	config.CacheTTL = envDuration("LDDB_LOOKUP_CACHE_TTL", config.CacheTTL)
+	config.NegativeCacheTTL = envDuration("LDDB_LOOKUP_NEGATIVE_CACHE_TTL", config.NegativeCacheTTL)
+
+	if userAgent := os.Getenv("LDDB_SCRAPER_USER_AGENT"); userAgent != "" {
+		config.ScraperOptions = append(config.ScraperOptions, scraper.WithUserAgent(userAgent))
+	}
+	if os.Getenv("LDDB_SCRAPER_RATE_LIMIT") != "" {
+		config.ScraperOptions = append(config.ScraperOptions, scraper.WithRateLimit(envDuration("LDDB_SCRAPER_RATE_LIMIT", time.Second)))
+	}
+	if os.Getenv("LDDB_SCRAPER_MAX_RETRIES") != "" || os.Getenv("LDDB_SCRAPER_RETRY_BACKOFF") != "" {
+		config.ScraperOptions = append(config.ScraperOptions, scraper.WithRetries(envInt("LDDB_SCRAPER_MAX_RETRIES", 3), envDuration("LDDB_SCRAPER_RETRY_BACKOFF", 500*time.Millisecond)))
+	}
+	if respect, err := strconv.ParseBool(os.Getenv("LDDB_SCRAPER_RESPECT_ROBOTS")); err == nil {
+		config.ScraperOptions = append(config.ScraperOptions, scraper.WithRobotsTxt(respect))
+	}
+
+	if names := os.Getenv("LDDB_METADATA_PROVIDERS"); names != "" {
+		config.Providers = strings.Split(names, ",")
+	}
+	if path := os.Getenv("LDDB_DATASET_PATH"); path != "" {
+		config.DatasetPath = path
+	}
+	if path := os.Getenv("LDDB_MAPPING_PATH"); path != "" {
+		config.MappingPath = path
+	}
+	return config
+}
+
+// envDuration reads a duration such as "12h" from the environment
+func envDuration(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Warning: Ignoring invalid %s=%q: %v", name, value, err)
+		return fallback
+	}
+	return duration
+}
+
+// envInt reads an integer from the environment
+func envInt(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Warning: Ignoring invalid %s=%q: %v", name, value, err)
+		return fallback
+	}
+	return n
+}
+
 // findAvailablePort finds an available port starting from the given port number
 func findAvailablePort(startPort int) int {
 	for port := startPort; port < startPort+100; port++ {
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/migrations"
+)
+
+// migrateUsage describes the migrate subcommand
+const migrateUsage = `usage: server migrate <command>
+
+commands:
+  status  list migrations and whether each has been applied
+  up      apply every pending migration
+  down    roll back the most recently applied migration
+
+The database is backed up next to itself before it's changed.`
+
+// runMigrate handles the migrate subcommand against the database at dbPath
+func runMigrate(db *gorm.DB, dbPath string, args []string) error {
+	if len(args) != 1 {
+		return errors.New(migrateUsage)
+	}
+
+	migrator, err := migrations.New(db)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = status.AppliedAt.Local().Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", status.Version, status.Name, applied)
+		}
+		return w.Flush()
+
+	case "up":
+		applied, err := migrateDatabase(db, dbPath, migrator)
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("Database is up to date")
+		}
+		return nil
+
+	case "down":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		if !anyApplied(statuses) {
+			return migrations.ErrNothingToRollBack
+		}
+		if err := backupDatabase(db, dbPath); err != nil {
+			return err
+		}
+		migration, err := migrator.Down()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rolled back %04d_%s\n", migration.Version, migration.Name)
+		return nil
+
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+// anyApplied reports whether any of the migrations has been applied
+func anyApplied(statuses []migrations.Status) bool {
+	for _, status := range statuses {
+		if status.AppliedAt != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// migrateDatabase applies pending migrations, backing the database up first
+func migrateDatabase(db *gorm.DB, dbPath string, migrator *migrations.Migrator) ([]migrations.Migration, error) {
+	pending, err := migrator.Pending()
+	if err != nil || len(pending) == 0 {
+		return nil, err
+	}
+	if err := backupDatabase(db, dbPath); err != nil {
+		return nil, err
+	}
+
+	applied, err := migrator.Up()
+	for _, migration := range applied {
+		log.Printf("Applied migration %04d_%s", migration.Version, migration.Name)
+	}
+	return applied, err
+}
+
+// backupDatabase copies the database before a migration changes it. A new
+// database, with nothing in it yet, isn't backed up.
+func backupDatabase(db *gorm.DB, dbPath string) error {
+	var tables int64
+	if err := db.Raw("SELECT count(*) FROM sqlite_master WHERE type = 'table'").Scan(&tables).Error; err != nil {
+		return err
+	}
+	if tables == 0 {
+		return nil
+	}
+
+	// Number backups taken within the same second
+	base := migrations.BackupPath(dbPath, time.Now())
+	path := base
+	for i := 2; fileExists(path); i++ {
+		path = fmt.Sprintf("%s.%d", base, i)
+	}
+	if err := migrations.Backup(db, path); err != nil {
+		return fmt.Errorf("backing up database before migrating: %w", err)
+	}
+	log.Printf("Backed up database to %s", path)
+	return nil
+}
+
+// fileExists reports whether something exists at path
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -60,6 +60,15 @@ if [ $? -eq 0 ]; then
     echo "✅ Backup uploaded successfully to Google Drive!"
     echo "   Google Drive File ID: $UPLOAD_RESULT"
     echo "   Folder: $GDRIVE_FOLDER"
+
+    # Upload the photos backed up alongside the database as one archive
+    IMAGES_BACKUP="${LATEST_BACKUP%.db}_images"
+    if [ -d "$IMAGES_BACKUP" ]; then
+        IMAGES_ARCHIVE="${IMAGES_BACKUP}.tar.gz"
+        tar -czf "$IMAGES_ARCHIVE" -C "$IMAGES_BACKUP" .
+        IMAGES_RESULT=$(gdrive files upload "$IMAGES_ARCHIVE" --parent "$FOLDER_ID" --print-only-id)
+        echo "   Photos File ID: $IMAGES_RESULT ($(du -h "$IMAGES_ARCHIVE" | cut -f1))"
+    fi
     
     # List recent backups in Google Drive
     echo ""
@@ -74,5 +83,4 @@ fi
 echo ""
 echo "💡 To restore from Google Drive:"
 echo "   1. List backups: gdrive files list --query \"parents in '$FOLDER_ID'\""
-echo "   2. Download: gdrive files download <FILE_ID> --path ./backups/"
-echo "   3. Restore: ./restore.sh ./backups/downloaded_backup.db"
\ No newline at end of file
+echo "   2. Restore: ./gdrive-restore.sh <FILE_ID> [PHOTOS_FILE_ID]"
\ No newline at end of file
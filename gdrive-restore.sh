@@ -36,15 +36,17 @@ gdrive files list --parent "$FOLDER_ID"
 echo ""
 
 if [ $# -eq 0 ]; then
-    echo "Usage: $0 <google_drive_file_id>"
+    echo "Usage: $0 <google_drive_file_id> [photos_file_id]"
     echo ""
     echo "Example:"
-    echo "   1. Copy the File ID from the list above"
-    echo "   2. Run: $0 1abc...xyz"
+    echo "   1. Copy the File ID of a .db backup from the list above, and of its"
+    echo "      _images.tar.gz photos archive if it has one"
+    echo "   2. Run: $0 1abc...xyz 1def...uvw"
     exit 1
 fi
 
 FILE_ID="$1"
+IMAGES_FILE_ID="$2"
 
 # Create backup directory
 mkdir -p "$BACKUP_DIR"
@@ -76,6 +78,17 @@ if [ $? -eq 0 ] && [ -f "$DOWNLOAD_FILE" ]; then
     echo "   Watched: $WATCHED_COUNT"
     echo "   Unwatched: $UNWATCHED_COUNT"
     echo ""
+
+    # Unpack the photos where restore.sh looks for them
+    if [ -n "$IMAGES_FILE_ID" ]; then
+        echo "📥 Downloading photos from Google Drive..."
+        IMAGES_BACKUP="${DOWNLOAD_FILE%.db}_images"
+        gdrive files download "$IMAGES_FILE_ID" --destination "$BACKUP_DIR" --overwrite
+        mkdir -p "$IMAGES_BACKUP"
+        tar -xzf "${IMAGES_BACKUP}.tar.gz" -C "$IMAGES_BACKUP"
+        echo "   Photos: $IMAGES_BACKUP ($(ls "$IMAGES_BACKUP" | wc -l | tr -d ' ') files)"
+        echo ""
+    fi
     
     # Use existing restore script
     echo "🔄 Proceeding with local restore..."
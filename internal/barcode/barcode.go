@@ -0,0 +1,140 @@
+// Package barcode validates retail barcodes printed on LaserDisc jackets and
+// converts them to a canonical GTIN-14, so the same disc is always stored
+// and looked up under the same code however it was typed or scanned.
+package barcode
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Barcode types
+const (
+	TypeUPCA   = "UPC-A"
+	TypeUPCE   = "UPC-E"
+	TypeEAN8   = "EAN-8"
+	TypeEAN13  = "EAN-13"
+	TypeJAN    = "JAN" // EAN-13 issued in Japan, prefix 45 or 49
+	TypeGTIN14 = "GTIN-14"
+)
+
+// ErrInvalid is wrapped by every error returned for a malformed barcode
+var ErrInvalid = errors.New("invalid barcode")
+
+// Barcode is a validated barcode
+type Barcode struct {
+	Type  string // as entered, e.g. UPC-E
+	GTIN  string // canonical 14 digit form
+	Input string // digits as entered
+}
+
+// Parse validates a UPC-A, UPC-E, EAN-8, EAN-13, JAN or GTIN-14 barcode.
+// Spaces and hyphens are ignored; anything else that isn't a digit is
+// rejected. An 8 digit code that is valid as UPC-E is taken to be one, and
+// any other must be a valid EAN-8.
+func Parse(code string) (Barcode, error) {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(code))
+	if digits == "" {
+		return Barcode{}, fmt.Errorf("%w: barcode is empty", ErrInvalid)
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return Barcode{}, fmt.Errorf("%w: %q contains characters other than digits", ErrInvalid, code)
+		}
+	}
+
+	b := Barcode{Input: digits}
+	full := digits
+	switch len(digits) {
+	case 8:
+		b.Type = TypeEAN8
+		if expanded, err := ExpandUPCE(digits); err == nil {
+			if want := CheckDigit(expanded[:11]); expanded[11] == want {
+				b.Type, full = TypeUPCE, expanded
+			} else if CheckDigit(digits[:7]) != digits[7] {
+				return Barcode{}, fmt.Errorf("%w: UPC-E check digit should be %c, or EAN-8 %c, not %c", ErrInvalid, want, CheckDigit(digits[:7]), digits[7])
+			}
+		}
+	case 12:
+		b.Type = TypeUPCA
+	case 13:
+		b.Type = TypeEAN13
+		if strings.HasPrefix(digits, "45") || strings.HasPrefix(digits, "49") {
+			b.Type = TypeJAN
+		}
+	case 14:
+		b.Type = TypeGTIN14
+	default:
+		return Barcode{}, fmt.Errorf("%w: %d digits is not a UPC-A (12), UPC-E or EAN-8 (8), EAN-13/JAN (13) or GTIN-14", ErrInvalid, len(digits))
+	}
+
+	if want := CheckDigit(full[:len(full)-1]); full[len(full)-1] != want {
+		return Barcode{}, fmt.Errorf("%w: %s check digit should be %c, not %c", ErrInvalid, b.Type, want, full[len(full)-1])
+	}
+
+	b.GTIN = strings.Repeat("0", 14-len(full)) + full
+	return b, nil
+}
+
+// Normalize validates a barcode and returns its canonical GTIN-14
+func Normalize(code string) (string, error) {
+	b, err := Parse(code)
+	if err != nil {
+		return "", err
+	}
+	return b.GTIN, nil
+}
+
+// Short returns the shortest common form of a GTIN-14: 12 digit UPC-A when
+// it has two leading zeros, 13 digit EAN-13 with one, the GTIN-14 otherwise.
+// This is the form printed on jackets and used by lddb.com.
+func Short(gtin string) string {
+	switch {
+	case len(gtin) == 14 && strings.HasPrefix(gtin, "00"):
+		return gtin[2:]
+	case len(gtin) == 14 && strings.HasPrefix(gtin, "0"):
+		return gtin[1:]
+	}
+	return gtin
+}
+
+// CheckDigit computes the GS1 mod 10 check digit for a barcode without its check digit
+func CheckDigit(digits string) byte {
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		n := int(digits[len(digits)-1-i] - '0')
+		// Weights alternate 3, 1, 3, ... starting next to the check digit
+		if i%2 == 0 {
+			n *= 3
+		}
+		sum += n
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// ExpandUPCE expands an 8 digit UPC-E (number system, 6 digits, check digit)
+// to the UPC-A it was compressed from. The check digit is carried over
+// unchanged; Parse validates it against the expansion.
+func ExpandUPCE(upce string) (string, error) {
+	if len(upce) != 8 {
+		return "", fmt.Errorf("%w: UPC-E must be 8 digits", ErrInvalid)
+	}
+	if upce[0] != '0' && upce[0] != '1' {
+		return "", fmt.Errorf("%w: UPC-E number system must be 0 or 1", ErrInvalid)
+	}
+
+	system, d, check := upce[:1], upce[1:7], upce[7:]
+	var body string
+	switch d[5] {
+	case '0', '1', '2':
+		body = d[0:2] + string(d[5]) + "0000" + d[2:5]
+	case '3':
+		body = d[0:3] + "00000" + d[3:5]
+	case '4':
+		body = d[0:4] + "00000" + d[4:5]
+	default:
+		body = d[0:5] + "0000" + d[5:6]
+	}
+	return system + body + check, nil
+}
@@ -0,0 +1,103 @@
+package barcode
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input    string
+		wantType string
+		wantGTIN string
+	}{
+		{"012345678905", TypeUPCA, "00012345678905"},
+		{"0 12345 67890 5", TypeUPCA, "00012345678905"},
+		{"0-12345-67890-5", TypeUPCA, "00012345678905"},
+		{"0012345678905", TypeEAN13, "00012345678905"},
+		{"00012345678905", TypeGTIN14, "00012345678905"},
+		{"04252614", TypeUPCE, "00042100005264"},
+		{"01234565", TypeUPCE, "00012345000065"},
+		{"96385074", TypeEAN8, "00000096385074"},
+		{"24252614", TypeEAN8, "00000024252614"},
+		{"4988104006479", TypeJAN, "04988104006479"},
+		{"4512345678906", TypeJAN, "04512345678906"},
+		{"5012345678900", TypeEAN13, "05012345678900"},
+		{"085391163824", TypeUPCA, "00085391163824"},
+	}
+
+	for _, tt := range tests {
+		b, err := Parse(tt.input)
+		require.NoError(t, err, "Unexpected error for input: %s", tt.input)
+		assert.Equal(t, tt.wantType, b.Type, "Wrong type for input: %s", tt.input)
+		assert.Equal(t, tt.wantGTIN, b.GTIN, "Wrong GTIN for input: %s", tt.input)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		input   string
+		message string
+	}{
+		{"", "empty"},
+		{"   ", "empty"},
+		{"1234567890", "10 digits"},
+		{"012345678904", "UPC-A check digit should be 5, not 4"},
+		{"4988104006478", "JAN check digit should be 9, not 8"},
+		{"04252615", "UPC-E check digit should be 4, or EAN-8 0, not 5"},
+		{"96385075", "EAN-8 check digit should be 4, not 5"},
+		{"01234567890X", "characters other than digits"},
+		{"abc", "characters other than digits"},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.input)
+		require.Error(t, err, "Expected error for input: %q", tt.input)
+		assert.True(t, errors.Is(err, ErrInvalid), "Error should wrap ErrInvalid for input: %q", tt.input)
+		assert.Contains(t, err.Error(), tt.message)
+	}
+}
+
+func TestNormalize_SameDiscSameCode(t *testing.T) {
+	// The same UPC typed as UPC-A, EAN-13 and GTIN-14 must not become separate records
+	forms := []string{"012345678905", "0012345678905", "00012345678905", " 0 12345 67890 5 "}
+	for _, form := range forms {
+		gtin, err := Normalize(form)
+		require.NoError(t, err)
+		assert.Equal(t, "00012345678905", gtin, "Wrong GTIN for input: %s", form)
+	}
+}
+
+func TestShort(t *testing.T) {
+	assert.Equal(t, "012345678905", Short("00012345678905"))
+	assert.Equal(t, "4988104006479", Short("04988104006479"))
+	assert.Equal(t, "10012345678902", Short("10012345678902"))
+	assert.Equal(t, "12345", Short("12345"))
+}
+
+func TestCheckDigit(t *testing.T) {
+	assert.Equal(t, byte('5'), CheckDigit("01234567890"))
+	assert.Equal(t, byte('9'), CheckDigit("498810400647"))
+	assert.Equal(t, byte('0'), CheckDigit("000000000000"))
+}
+
+func TestExpandUPCE(t *testing.T) {
+	tests := map[string]string{
+		"01234505": "012000003455", // last digit 0-2: manufacturer XX{d}00, product 00XXX
+		"01234535": "012300000455", // last digit 3
+		"01234545": "012340000055", // last digit 4
+		"01234565": "012345000065", // last digit 5-9
+		"11234565": "112345000065",
+	}
+	for upce, upca := range tests {
+		expanded, err := ExpandUPCE(upce)
+		require.NoError(t, err)
+		assert.Equal(t, upca, expanded, "Wrong expansion for %s", upce)
+	}
+
+	_, err := ExpandUPCE("0123456")
+	assert.ErrorIs(t, err, ErrInvalid)
+}
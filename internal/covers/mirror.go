@@ -0,0 +1,121 @@
+package covers
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// queueSize is how many LaserDiscs can wait to have their cover mirrored
+const queueSize = 1000
+
+// Repository is the database access the mirror needs
+type Repository interface {
+	GetLaserDiscByID(id uint) (*models.LaserDisc, error)
+	LaserDiscsWithUnmirroredCovers() ([]uint, error)
+	SetMirroredCover(id uint, coverURL, file, thumb string) error
+}
+
+// Mirror downloads covers in the background, one LaserDisc at a time, so
+// adding a disc never waits on lddb.com
+type Mirror struct {
+	store *Store
+	repo  Repository
+	queue chan uint
+}
+
+// NewMirror creates a mirror saving covers to store
+func NewMirror(store *Store, repo Repository) *Mirror {
+	return &Mirror{
+		store: store,
+		repo:  repo,
+		queue: make(chan uint, queueSize),
+	}
+}
+
+// Store returns the store the mirror saves covers to
+func (m *Mirror) Store() *Store {
+	return m.store
+}
+
+// Start runs the mirror until ctx is done, after queueing every LaserDisc
+// whose cover hasn't been mirrored yet
+func (m *Mirror) Start(ctx context.Context) {
+	go m.run(ctx)
+	go func() {
+		queued, err := m.Backfill(ctx)
+		if err != nil {
+			log.Printf("Warning: Could not backfill covers: %v", err)
+		} else if queued > 0 {
+			log.Printf("Queued %d covers to mirror", queued)
+		}
+	}()
+}
+
+// Enqueue asks for a LaserDisc's cover to be mirrored. If the queue is full
+// the request is dropped and picked up by the next backfill instead.
+func (m *Mirror) Enqueue(id uint) {
+	select {
+	case m.queue <- id:
+	default:
+		log.Printf("Warning: Cover mirror queue is full, skipping LaserDisc %d", id)
+	}
+}
+
+// Backfill queues every LaserDisc whose cover URL hasn't been mirrored,
+// waiting for room in the queue as needed. It returns how many were queued.
+func (m *Mirror) Backfill(ctx context.Context) (int, error) {
+	ids, err := m.repo.LaserDiscsWithUnmirroredCovers()
+	if err != nil {
+		return 0, err
+	}
+
+	for i, id := range ids {
+		select {
+		case m.queue <- id:
+		case <-ctx.Done():
+			return i, ctx.Err()
+		}
+	}
+	return len(ids), nil
+}
+
+// run mirrors queued LaserDiscs until ctx is done
+func (m *Mirror) run(ctx context.Context) {
+	for {
+		select {
+		case id := <-m.queue:
+			if err := m.MirrorLaserDisc(id); err != nil {
+				log.Printf("Warning: Could not mirror cover for LaserDisc %d: %v", id, err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// MirrorLaserDisc mirrors one LaserDisc's cover now, unless its current
+// cover URL is already mirrored
+func (m *Mirror) MirrorLaserDisc(id uint) error {
+	laserdisc, err := m.repo.GetLaserDiscByID(id)
+	if err != nil {
+		return err
+	}
+	if laserdisc.CoverImageURL == laserdisc.CoverSource {
+		return nil
+	}
+
+	cover, err := m.store.Mirror(laserdisc.CoverImageURL)
+	if errors.Is(err, ErrNoCover) {
+		// Nothing to download, so forget any previously mirrored cover
+		return m.repo.SetMirroredCover(id, laserdisc.CoverImageURL, "", "")
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Mirrored cover for LaserDisc %d as %s", id, cover.File)
+	return m.repo.SetMirroredCover(id, laserdisc.CoverImageURL, cover.File, cover.Thumb)
+}
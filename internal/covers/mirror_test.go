@@ -0,0 +1,128 @@
+package covers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// fakeRepository keeps LaserDiscs in memory
+type fakeRepository struct {
+	mu         sync.Mutex
+	laserdiscs map[uint]*models.LaserDisc
+}
+
+func newFakeRepository(laserdiscs ...models.LaserDisc) *fakeRepository {
+	repo := &fakeRepository{laserdiscs: make(map[uint]*models.LaserDisc)}
+	for i := range laserdiscs {
+		repo.laserdiscs[laserdiscs[i].ID] = &laserdiscs[i]
+	}
+	return repo
+}
+
+func (r *fakeRepository) GetLaserDiscByID(id uint) (*models.LaserDisc, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ld, ok := r.laserdiscs[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	result := *ld
+	return &result, nil
+}
+
+func (r *fakeRepository) LaserDiscsWithUnmirroredCovers() ([]uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []uint
+	for id, ld := range r.laserdiscs {
+		if ld.CoverImageURL != ld.CoverSource {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (r *fakeRepository) SetMirroredCover(id uint, coverURL, file, thumb string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ld := r.laserdiscs[id]
+	ld.CoverSource, ld.CoverFile, ld.CoverThumb = coverURL, file, thumb
+	return nil
+}
+
+func TestMirror_MirrorLaserDisc(t *testing.T) {
+	server, requests := newImageServer(t, map[string][]byte{
+		"/cover/ld/1-100/thumb/42.jpg": testImage(t, 100, 150, "jpeg"),
+		"/cover/ld/1-100/42.jpg":       testImage(t, 400, 600, "jpeg"),
+	})
+	coverURL := server.URL + "/cover/ld/1-100/thumb/42.jpg"
+	repo := newFakeRepository(models.LaserDisc{ID: 1, CoverImageURL: coverURL})
+	mirror := NewMirror(NewStore(t.TempDir(), server.Client()), repo)
+
+	require.NoError(t, mirror.MirrorLaserDisc(1))
+	ld, _ := repo.GetLaserDiscByID(1)
+	assert.Equal(t, coverURL, ld.CoverSource)
+	assert.NotEmpty(t, ld.CoverFile)
+	assert.NotEmpty(t, ld.CoverThumb)
+	assert.Equal(t, int32(2), requests.Load())
+
+	// An already mirrored cover isn't downloaded again
+	require.NoError(t, mirror.MirrorLaserDisc(1))
+	assert.Equal(t, int32(2), requests.Load())
+
+	// Clearing the cover URL forgets the mirrored files
+	repo.laserdiscs[1].CoverImageURL = ""
+	require.NoError(t, mirror.MirrorLaserDisc(1))
+	ld, _ = repo.GetLaserDiscByID(1)
+	assert.Empty(t, ld.CoverFile)
+	assert.Empty(t, ld.CoverSource)
+
+	assert.Error(t, mirror.MirrorLaserDisc(99))
+}
+
+func TestMirror_BackfillsInBackground(t *testing.T) {
+	server, _ := newImageServer(t, map[string][]byte{
+		"/a.jpg": testImage(t, 50, 50, "jpeg"),
+		"/b.jpg": testImage(t, 60, 60, "jpeg"),
+	})
+	repo := newFakeRepository(
+		models.LaserDisc{ID: 1, CoverImageURL: server.URL + "/a.jpg"},
+		models.LaserDisc{ID: 2, CoverImageURL: server.URL + "/b.jpg"},
+		models.LaserDisc{ID: 3},
+	)
+	mirror := NewMirror(NewStore(t.TempDir(), server.Client()), repo)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mirror.Start(ctx)
+
+	assert.Eventually(t, func() bool {
+		ids, _ := repo.LaserDiscsWithUnmirroredCovers()
+		return len(ids) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	a, _ := repo.GetLaserDiscByID(1)
+	b, _ := repo.GetLaserDiscByID(2)
+	assert.NotEmpty(t, a.CoverFile)
+	assert.NotEmpty(t, b.CoverFile)
+	assert.NotEqual(t, a.CoverFile, b.CoverFile)
+
+	// Newly added discs are mirrored once enqueued
+	repo.mu.Lock()
+	repo.laserdiscs[4] = &models.LaserDisc{ID: 4, CoverImageURL: server.URL + "/a.jpg"}
+	repo.mu.Unlock()
+	mirror.Enqueue(4)
+
+	assert.Eventually(t, func() bool {
+		ld, _ := repo.GetLaserDiscByID(4)
+		return ld.CoverFile == a.CoverFile
+	}, 5*time.Second, 10*time.Millisecond)
+}
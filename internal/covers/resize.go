@@ -0,0 +1,55 @@
+package covers
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// resize scales an image down to the given width, keeping its aspect ratio.
+// Each output pixel averages the block of source pixels it covers, which is
+// plenty for shrinking cover scans.
+func resize(src image.Image, width int) image.Image {
+	bounds := src.Bounds()
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	// Work on RGBA pixels directly rather than through the slow At method
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := span(y, height, bounds.Dy())
+		for x := 0; x < width; x++ {
+			x0, x1 := span(x, width, bounds.Dx())
+
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					i := rgba.PixOffset(sx, sy)
+					r += int(rgba.Pix[i])
+					g += int(rgba.Pix[i+1])
+					b += int(rgba.Pix[i+2])
+					a += int(rgba.Pix[i+3])
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{uint8(r / n), uint8(g / n), uint8(b / n), uint8(a / n)})
+		}
+	}
+	return dst
+}
+
+// span returns the source pixel range [start, end) covered by output pixel i
+// when srcLen pixels are shrunk to dstLen
+func span(i, dstLen, srcLen int) (int, int) {
+	start := i * srcLen / dstLen
+	end := (i + 1) * srcLen / dstLen
+	if end <= start {
+		end = start + 1
+	}
+	return start, end
+}
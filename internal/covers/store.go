@@ -0,0 +1,214 @@
+// Package covers mirrors LaserDisc cover art into local storage so covers
+// keep working when lddb.com is slow or a hot-linked URL breaks.
+package covers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	_ "image/gif" // registered for image.Decode
+	_ "image/png"
+)
+
+// maxImageBytes caps the size of a downloaded cover
+const maxImageBytes = 10 << 20
+
+// Sizes maps the resized variants that can be requested to their width in pixels
+var Sizes = map[string]int{
+	"small":  150,
+	"medium": 300,
+	"large":  600,
+}
+
+// ErrNoCover is returned for cover URLs that don't point at a real cover,
+// such as LDDB's loading placeholder
+var ErrNoCover = errors.New("no cover image")
+
+// fileNamePattern matches the names the store gives mirrored images
+var fileNamePattern = regexp.MustCompile(`^[0-9a-f]{64}\.(jpg|png|gif)$`)
+
+// Store keeps cover images on disk, named by the SHA-256 of their content so
+// the same image is only ever stored once
+type Store struct {
+	dir    string
+	client *http.Client
+}
+
+// Cover names the mirrored files for one cover
+type Cover struct {
+	File  string // full size image
+	Thumb string // LDDB's thumbnail, or the full image when there is none
+}
+
+// NewStore creates a store saving images under dir and downloading them with client
+func NewStore(dir string, client *http.Client) *Store {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Store{dir: dir, client: client}
+}
+
+// Mirror downloads the cover at coverURL and saves it with its resized
+// variants. LDDB cover URLs point at the thumbnail, so the full size image
+// is fetched from the same path without /thumb/. Either one is enough.
+func (s *Store) Mirror(coverURL string) (Cover, error) {
+	if coverURL == "" || strings.Contains(coverURL, "loading.gif") {
+		return Cover{}, ErrNoCover
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return Cover{}, err
+	}
+
+	fullURL, thumbURL := coverURL, ""
+	if strings.Contains(coverURL, "/thumb/") {
+		fullURL, thumbURL = strings.Replace(coverURL, "/thumb/", "/", 1), coverURL
+	}
+
+	var cover Cover
+	var fullErr, thumbErr error
+	cover.File, fullErr = s.save(fullURL, true)
+	if thumbURL != "" {
+		cover.Thumb, thumbErr = s.save(thumbURL, cover.File == "")
+	}
+
+	switch {
+	case cover.File == "" && cover.Thumb == "":
+		if fullErr == nil {
+			fullErr = thumbErr
+		}
+		return Cover{}, fullErr
+	case cover.File == "":
+		cover.File = cover.Thumb
+	case cover.Thumb == "":
+		cover.Thumb = cover.File
+	}
+	return cover, nil
+}
+
+// Path returns the file to serve for a mirrored image at the given size:
+// "full" (or empty) for the image itself, or one of Sizes. Sizes that
+// weren't generated because the image is smaller fall back to the image.
+func (s *Store) Path(file, size string) (string, error) {
+	if !fileNamePattern.MatchString(file) {
+		return "", fmt.Errorf("invalid cover file name %q", file)
+	}
+
+	if size != "" && size != "full" {
+		width, ok := Sizes[size]
+		if !ok {
+			return "", fmt.Errorf("unknown cover size %q", size)
+		}
+		variant := filepath.Join(s.dir, variantName(file, width))
+		if _, err := os.Stat(variant); err == nil {
+			return variant, nil
+		}
+	}
+	return filepath.Join(s.dir, file), nil
+}
+
+// save downloads an image, stores it under its content hash and returns
+// the file name. With variants set the resized variants are written too.
+func (s *Store) save(imageURL string, variants bool) (string, error) {
+	data, err := s.download(imageURL)
+	if err != nil {
+		return "", err
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("%s is not an image: %w", imageURL, err)
+	}
+	ext := format
+	if ext == "jpeg" {
+		ext = "jpg"
+	}
+
+	sum := sha256.Sum256(data)
+	file := hex.EncodeToString(sum[:]) + "." + ext
+	if err := s.writeFile(file, data); err != nil {
+		return "", err
+	}
+
+	if variants {
+		for _, width := range Sizes {
+			if img.Bounds().Dx() <= width {
+				continue
+			}
+			var buf bytes.Buffer
+			if err := jpeg.Encode(&buf, resize(img, width), &jpeg.Options{Quality: 85}); err != nil {
+				return "", err
+			}
+			if err := s.writeFile(variantName(file, width), buf.Bytes()); err != nil {
+				return "", err
+			}
+		}
+	}
+	return file, nil
+}
+
+// download fetches an image, refusing anything that isn't a successful
+// image response or is unreasonably large
+func (s *Store) download(imageURL string) ([]byte, error) {
+	resp, err := s.client.Get(imageURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", imageURL, resp.Status)
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" && !strings.HasPrefix(contentType, "image/") {
+		return nil, fmt.Errorf("%s is %s, not an image", imageURL, contentType)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxImageBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImageBytes {
+		return nil, fmt.Errorf("%s is larger than %d bytes", imageURL, maxImageBytes)
+	}
+	return data, nil
+}
+
+// writeFile stores data under name unless it's already there. Content is
+// addressed by hash, so an existing file already holds the same bytes.
+func (s *Store) writeFile(name string, data []byte) error {
+	path := filepath.Join(s.dir, name)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	// Write to a temporary file first so a crash never leaves half an image
+	tmp, err := os.CreateTemp(s.dir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// variantName names the resized variant of an image, e.g. <hash>-300.jpg
+func variantName(file string, width int) string {
+	return fmt.Sprintf("%s-%d.jpg", strings.TrimSuffix(file, filepath.Ext(file)), width)
+}
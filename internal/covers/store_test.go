@@ -0,0 +1,201 @@
+package covers
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testImage encodes a solid colour image of the given size
+func testImage(t *testing.T, width, height int, format string) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{200, 30, 30, 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	if format == "png" {
+		require.NoError(t, png.Encode(&buf, img))
+	} else {
+		require.NoError(t, jpeg.Encode(&buf, img, nil))
+	}
+	return buf.Bytes()
+}
+
+// newImageServer serves the given bodies by path, counting requests
+func newImageServer(t *testing.T, images map[string][]byte) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		data, ok := images[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", http.DetectContentType(data))
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestStore_MirrorFullAndThumb(t *testing.T) {
+	full := testImage(t, 800, 800, "jpeg")
+	thumb := testImage(t, 100, 100, "jpeg")
+	server, _ := newImageServer(t, map[string][]byte{
+		"/cover/ld/31701-31800/31738.jpg":       full,
+		"/cover/ld/31701-31800/thumb/31738.jpg": thumb,
+	})
+
+	dir := t.TempDir()
+	store := NewStore(dir, server.Client())
+
+	cover, err := store.Mirror(server.URL + "/cover/ld/31701-31800/thumb/31738.jpg")
+	require.NoError(t, err)
+	assert.Regexp(t, `^[0-9a-f]{64}\.jpg$`, cover.File)
+	assert.Regexp(t, `^[0-9a-f]{64}\.jpg$`, cover.Thumb)
+	assert.NotEqual(t, cover.File, cover.Thumb)
+
+	saved, err := os.ReadFile(filepath.Join(dir, cover.File))
+	require.NoError(t, err)
+	assert.Equal(t, full, saved)
+
+	// Every size is smaller than the full image, so each has a variant
+	for size, width := range Sizes {
+		path, err := store.Path(cover.File, size)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, variantName(cover.File, width)), path)
+
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		config, err := jpeg.DecodeConfig(f)
+		f.Close()
+		require.NoError(t, err)
+		assert.Equal(t, width, config.Width)
+		assert.Equal(t, width, config.Height)
+	}
+
+	path, err := store.Path(cover.File, "full")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, cover.File), path)
+}
+
+func TestStore_MirrorThumbOnly(t *testing.T) {
+	thumb := testImage(t, 200, 300, "png")
+	server, _ := newImageServer(t, map[string][]byte{
+		"/cover/ld/1-100/thumb/42.jpg": thumb,
+	})
+
+	dir := t.TempDir()
+	store := NewStore(dir, server.Client())
+
+	// The full size image is missing, so the thumbnail stands in for it
+	cover, err := store.Mirror(server.URL + "/cover/ld/1-100/thumb/42.jpg")
+	require.NoError(t, err)
+	assert.Regexp(t, `\.png$`, cover.File)
+	assert.Equal(t, cover.File, cover.Thumb)
+
+	// Only sizes narrower than the image get a variant
+	path, err := store.Path(cover.File, "small")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, variantName(cover.File, 150)), path)
+
+	path, err = store.Path(cover.File, "large")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, cover.File), path)
+}
+
+func TestStore_MirrorDeduplicatesByContent(t *testing.T) {
+	img := testImage(t, 100, 100, "jpeg")
+	server, _ := newImageServer(t, map[string][]byte{
+		"/a.jpg": img,
+		"/b.jpg": img,
+	})
+
+	dir := t.TempDir()
+	store := NewStore(dir, server.Client())
+
+	a, err := store.Mirror(server.URL + "/a.jpg")
+	require.NoError(t, err)
+	b, err := store.Mirror(server.URL + "/b.jpg")
+	require.NoError(t, err)
+	assert.Equal(t, a.File, b.File)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestStore_MirrorErrors(t *testing.T) {
+	server, requests := newImageServer(t, map[string][]byte{
+		"/page.html": []byte("<html>not a cover</html>"),
+	})
+	store := NewStore(t.TempDir(), server.Client())
+
+	_, err := store.Mirror("")
+	assert.ErrorIs(t, err, ErrNoCover)
+	_, err = store.Mirror("https://www.lddb.com/images/visual/loading.gif")
+	assert.ErrorIs(t, err, ErrNoCover)
+	assert.Equal(t, int32(0), requests.Load())
+
+	_, err = store.Mirror(server.URL + "/missing.jpg")
+	assert.Error(t, err)
+
+	_, err = store.Mirror(server.URL + "/page.html")
+	assert.Error(t, err)
+}
+
+func TestStore_Path(t *testing.T) {
+	store := NewStore(t.TempDir(), nil)
+	file := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef.jpg"
+
+	_, err := store.Path(file, "huge")
+	assert.Error(t, err)
+
+	// Names that didn't come from the store are refused
+	_, err = store.Path("../collection.db", "full")
+	assert.Error(t, err)
+	_, err = store.Path("", "full")
+	assert.Error(t, err)
+}
+
+func TestResize(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	// Left half black, right half white
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			if x >= 2 {
+				src.Set(x, y, color.White)
+			} else {
+				src.Set(x, y, color.Black)
+			}
+		}
+	}
+
+	dst := resize(src, 2)
+	assert.Equal(t, image.Rect(0, 0, 2, 1), dst.Bounds())
+	assert.Equal(t, color.RGBAModel.Convert(color.Black), dst.At(0, 0))
+	assert.Equal(t, color.RGBAModel.Convert(color.White), dst.At(1, 0))
+
+	// Each pixel averages the block it covers
+	dst = resize(src, 1)
+	r, g, b, _ := dst.At(0, 0).RGBA()
+	assert.InDelta(t, 0x7f7f, r, 0x200)
+	assert.Equal(t, r, g)
+	assert.Equal(t, r, b)
+}
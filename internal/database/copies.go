@@ -0,0 +1,291 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// ErrDuplicateUPC is returned when adding a LaserDisc whose UPC is already in
+// the collection. Another copy of it is added to the existing LaserDisc.
+var ErrDuplicateUPC = errors.New("laserdisc with this UPC already exists")
+
+// ErrInvalidCopy is returned for a copy with an unknown grade or rot status,
+// or a malformed purchase
+var ErrInvalidCopy = errors.New("invalid copy")
+
+// currencyCode matches an ISO 4217 currency code
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// GetCopies returns the copies of a LaserDisc, oldest first
+func (s *Service) GetCopies(laserdiscID uint) ([]models.Copy, error) {
+	copies := []models.Copy{}
+	result := s.db.Where("laser_disc_id = ?", laserdiscID).Order("id").Find(&copies)
+	return copies, result.Error
+}
+
+// GetCopy retrieves one of a LaserDisc's copies
+func (s *Service) GetCopy(laserdiscID, copyID uint) (*models.Copy, error) {
+	var copy models.Copy
+	result := s.db.Where("laser_disc_id = ?", laserdiscID).First(&copy, copyID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &copy, nil
+}
+
+// AddCopy adds another copy of a LaserDisc
+func (s *Service) AddCopy(laserdiscID uint, req *models.CopyRequest) (*models.Copy, error) {
+	var laserdisc models.LaserDisc
+	if err := s.db.First(&laserdisc, laserdiscID).Error; err != nil {
+		return nil, err
+	}
+
+	copy, err := newCopy(&laserdisc, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(copy).Error; err != nil {
+		return nil, err
+	}
+	return copy, nil
+}
+
+// UpdateCopy updates the condition or purchase details of a copy
+func (s *Service) UpdateCopy(laserdiscID, copyID uint, req *models.CopyRequest) (*models.Copy, error) {
+	copy, err := s.GetCopy(laserdiscID, copyID)
+	if err != nil {
+		return nil, err
+	}
+	var laserdisc models.LaserDisc
+	if err := s.db.First(&laserdisc, laserdiscID).Error; err != nil {
+		return nil, err
+	}
+
+	applyCopyRequest(copy, req)
+	if err := validateCopy(copy, laserdisc.Sides); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(copy).Error; err != nil {
+		return nil, err
+	}
+	return copy, nil
+}
+
+// DeleteCopy deletes one of a LaserDisc's copies and its inspections. Its
+// viewings are kept, without the copy. The LaserDisc stays, with its
+// metadata, even once it has no copies left.
+func (s *Service) DeleteCopy(laserdiscID, copyID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("laser_disc_id = ?", laserdiscID).Delete(&models.Copy{}, copyID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Where("copy_id = ?", copyID).Delete(&models.Inspection{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Viewing{}).Where("copy_id = ?", copyID).Update("copy_id", 0).Error
+	})
+}
+
+// AttachCopies fills in the Copies of each LaserDisc
+func (s *Service) AttachCopies(laserdiscs []models.LaserDisc) error {
+	ids := make([]uint, len(laserdiscs))
+	for i := range laserdiscs {
+		ids[i] = laserdiscs[i].ID
+	}
+
+	byID, err := s.copiesFor(ids)
+	if err != nil {
+		return err
+	}
+	for i := range laserdiscs {
+		laserdiscs[i].Copies = byID[laserdiscs[i].ID]
+	}
+	return nil
+}
+
+// attachCopies fills in the Copies of one LaserDisc
+func (s *Service) attachCopies(laserdisc *models.LaserDisc) error {
+	byID, err := s.copiesFor([]uint{laserdisc.ID})
+	if err != nil {
+		return err
+	}
+	laserdisc.Copies = byID[laserdisc.ID]
+	return nil
+}
+
+// copiesFor loads the copies of the given LaserDiscs, keyed by LaserDisc ID.
+// Each ID has a list, empty when it has no copies.
+func (s *Service) copiesFor(ids []uint) (map[uint][]models.Copy, error) {
+	byID := make(map[uint][]models.Copy, len(ids))
+	for _, id := range ids {
+		byID[id] = []models.Copy{}
+	}
+
+	// In batches, to stay under SQLite's variable limit
+	for start := 0; start < len(ids); start += 500 {
+		end := min(start+500, len(ids))
+		var copies []models.Copy
+		if err := s.db.Where("laser_disc_id IN ?", ids[start:end]).Order("id").Find(&copies).Error; err != nil {
+			return nil, err
+		}
+		for _, copy := range copies {
+			byID[copy.LaserDiscID] = append(byID[copy.LaserDiscID], copy)
+		}
+	}
+	return byID, nil
+}
+
+// newCopy builds a valid copy of a LaserDisc from a request, which may be nil
+func newCopy(laserdisc *models.LaserDisc, req *models.CopyRequest) (*models.Copy, error) {
+	copy := &models.Copy{LaserDiscID: laserdisc.ID, RotStatus: models.RotUnknown}
+	if req != nil {
+		applyCopyRequest(copy, req)
+	}
+	if err := validateCopy(copy, laserdisc.Sides); err != nil {
+		return nil, err
+	}
+	return copy, nil
+}
+
+// applyCopyRequest sets the fields a request gives, tidying grades and
+// currency codes typed in lowercase
+func applyCopyRequest(copy *models.Copy, req *models.CopyRequest) {
+	if req.Sealed != nil {
+		copy.Sealed = *req.Sealed
+	}
+	if req.SleeveGrade != nil {
+		copy.SleeveGrade = strings.ToUpper(strings.TrimSpace(*req.SleeveGrade))
+	}
+	if req.SideGrades != nil {
+		grades := models.SplitSideGrades(strings.ToUpper(strings.TrimSpace(*req.SideGrades)))
+		copy.SideGrades = models.JoinSideGrades(grades)
+	}
+	if req.RotStatus != nil {
+		copy.RotStatus = strings.ToLower(strings.TrimSpace(*req.RotStatus))
+	}
+	if req.PurchaseDate != nil {
+		copy.PurchaseDate = strings.TrimSpace(*req.PurchaseDate)
+	}
+	if req.PurchasePrice != nil {
+		copy.PurchasePrice = *req.PurchasePrice
+	}
+	if req.Currency != nil {
+		copy.Currency = strings.ToUpper(strings.TrimSpace(*req.Currency))
+	}
+	if req.Seller != nil {
+		copy.Seller = *req.Seller
+	}
+	if req.Notes != nil {
+		copy.Notes = *req.Notes
+	}
+}
+
+// validateCopy checks a copy's grades, rot status and purchase. A LaserDisc
+// with a known number of sides can't have more side grades than that.
+func validateCopy(copy *models.Copy, sides int) error {
+	if copy.SleeveGrade != "" && !models.ValidGrade(copy.SleeveGrade) {
+		return fmt.Errorf("%w: sleeve grade %q, use one of %s", ErrInvalidCopy, copy.SleeveGrade, strings.Join(models.Grades, ", "))
+	}
+
+	grades := models.SplitSideGrades(copy.SideGrades)
+	for i, grade := range grades {
+		if grade != "" && !models.ValidGrade(grade) {
+			return fmt.Errorf("%w: side %d grade %q, use one of %s", ErrInvalidCopy, i+1, grade, strings.Join(models.Grades, ", "))
+		}
+	}
+	if sides > 0 && len(grades) > sides {
+		return fmt.Errorf("%w: %d side grades for a LaserDisc with %d sides", ErrInvalidCopy, len(grades), sides)
+	}
+
+	if copy.RotStatus == "" {
+		copy.RotStatus = models.RotUnknown
+	}
+	if !models.ValidRotStatus(copy.RotStatus) {
+		return fmt.Errorf("%w: rot status %q, use one of %s", ErrInvalidCopy, copy.RotStatus, strings.Join(models.RotStatuses, ", "))
+	}
+
+	if copy.PurchaseDate != "" {
+		if _, err := time.Parse("2006-01-02", copy.PurchaseDate); err != nil {
+			return fmt.Errorf("%w: purchase date %q, use YYYY-MM-DD", ErrInvalidCopy, copy.PurchaseDate)
+		}
+	}
+	if copy.PurchasePrice < 0 {
+		return fmt.Errorf("%w: negative purchase price", ErrInvalidCopy)
+	}
+	if copy.Currency != "" && !currencyCode.MatchString(copy.Currency) {
+		return fmt.Errorf("%w: currency %q, use a code like USD", ErrInvalidCopy, copy.Currency)
+	}
+	if copy.PurchasePrice > 0 && copy.Currency == "" {
+		return fmt.Errorf("%w: purchase price without a currency", ErrInvalidCopy)
+	}
+	return nil
+}
+
+// copyStats counts copies by rot status and sleeve grade, and totals what
+// was spent on them in each currency
+func (s *Service) copyStats(stats map[string]interface{}) error {
+	var total int64
+	if err := s.db.Model(&models.Copy{}).Count(&total).Error; err != nil {
+		return err
+	}
+	stats["copies"] = total
+
+	counts := func(column string) (map[string]int64, error) {
+		var rows []models.ValueCount
+		err := s.db.Model(&models.Copy{}).
+			Select(column + " AS value, count(*) AS count").
+			Group(column).
+			Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		byValue := make(map[string]int64, len(rows))
+		for _, row := range rows {
+			byValue[row.Value] = row.Count
+		}
+		return byValue, nil
+	}
+
+	rot, err := counts("rot_status")
+	if err != nil {
+		return err
+	}
+	stats["laser_rot"] = rot
+
+	// Ungraded sleeves count as "ungraded"
+	sleeves, err := counts("COALESCE(NULLIF(sleeve_grade, ''), 'ungraded')")
+	if err != nil {
+		return err
+	}
+	stats["sleeve_grades"] = sleeves
+
+	var spent []struct {
+		Currency string
+		Total    float64
+	}
+	err = s.db.Model(&models.Copy{}).
+		Select("currency, ROUND(SUM(purchase_price), 2) AS total").
+		Where("purchase_price > 0 AND currency != ''").
+		Group("currency").
+		Scan(&spent).Error
+	if err != nil {
+		return err
+	}
+	byCurrency := make(map[string]float64, len(spent))
+	for _, row := range spent {
+		byCurrency[row.Currency] = row.Total
+	}
+	stats["spent"] = byCurrency
+	return nil
+}
@@ -0,0 +1,157 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+func stringPtr(s string) *string  { return &s }
+func floatPtr(f float64) *float64 { return &f }
+
+func TestService_CreateLaserDisc_FirstCopy(t *testing.T) {
+	service := setupTestDB(t)
+
+	// Without copy details the LaserDisc still has one copy
+	laserdisc, err := service.CreateLaserDisc(createTestLaserDisc())
+	require.NoError(t, err)
+	require.Len(t, laserdisc.Copies, 1)
+	assert.Equal(t, models.RotUnknown, laserdisc.Copies[0].RotStatus)
+
+	sealed := true
+	req := createTestLaserDisc()
+	req.UPC = "111111111117"
+	req.Copy = &models.CopyRequest{
+		Sealed:        &sealed,
+		SleeveGrade:   stringPtr("nm"),
+		PurchaseDate:  stringPtr("2024-05-01"),
+		PurchasePrice: floatPtr(40),
+		Currency:      stringPtr("usd"),
+		Seller:        stringPtr("Record fair"),
+	}
+	laserdisc, err = service.CreateLaserDisc(req)
+	require.NoError(t, err)
+	require.Len(t, laserdisc.Copies, 1)
+	copy := laserdisc.Copies[0]
+	assert.True(t, copy.Sealed)
+	assert.Equal(t, "NM", copy.SleeveGrade)
+	assert.Equal(t, "USD", copy.Currency)
+	assert.Equal(t, 40.0, copy.PurchasePrice)
+
+	// An invalid copy adds nothing
+	req = createTestLaserDisc()
+	req.UPC = "222222222224"
+	req.Copy = &models.CopyRequest{SleeveGrade: stringPtr("EX")}
+	_, err = service.CreateLaserDisc(req)
+	assert.ErrorIs(t, err, ErrInvalidCopy)
+	_, err = service.GetLaserDiscByUPC("222222222224")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestService_Copies(t *testing.T) {
+	service := setupTestDB(t)
+	laserdisc, err := service.CreateLaserDisc(createTestLaserDisc())
+	require.NoError(t, err)
+	first := laserdisc.Copies[0]
+
+	// A second copy of the same release
+	second, err := service.AddCopy(laserdisc.ID, &models.CopyRequest{
+		SideGrades: stringPtr("vg+/vg"),
+		RotStatus:  stringPtr("Suspected"),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "VG+/VG", second.SideGrades)
+	assert.Equal(t, models.RotSuspected, second.RotStatus)
+
+	copies, err := service.GetCopies(laserdisc.ID)
+	require.NoError(t, err)
+	require.Len(t, copies, 2)
+	assert.Equal(t, first.ID, copies[0].ID)
+
+	retrieved, err := service.GetLaserDiscByID(laserdisc.ID)
+	require.NoError(t, err)
+	assert.Len(t, retrieved.Copies, 2)
+
+	_, err = service.AddCopy(999999, &models.CopyRequest{})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// Updates change only the fields given
+	updated, err := service.UpdateCopy(laserdisc.ID, second.ID, &models.CopyRequest{RotStatus: stringPtr(models.RotMinor)})
+	require.NoError(t, err)
+	assert.Equal(t, models.RotMinor, updated.RotStatus)
+	assert.Equal(t, "VG+/VG", updated.SideGrades)
+
+	_, err = service.UpdateCopy(laserdisc.ID, second.ID, &models.CopyRequest{SideGrades: stringPtr("NM/NM/NM")})
+	assert.ErrorIs(t, err, ErrInvalidCopy, "more grades than sides")
+	_, err = service.UpdateCopy(laserdisc.ID+1, second.ID, &models.CopyRequest{})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "copies belong to one LaserDisc")
+
+	require.NoError(t, service.DeleteCopy(laserdisc.ID, second.ID))
+	assert.ErrorIs(t, service.DeleteCopy(laserdisc.ID, second.ID), gorm.ErrRecordNotFound)
+
+	// Deleting the LaserDisc deletes its copies
+	require.NoError(t, service.DeleteLaserDisc(laserdisc.ID))
+	copies, err = service.GetCopies(laserdisc.ID)
+	require.NoError(t, err)
+	assert.Empty(t, copies)
+}
+
+func TestValidateCopy(t *testing.T) {
+	valid := []models.Copy{
+		{},
+		{SleeveGrade: "VG+", SideGrades: "NM/", RotStatus: models.RotNone},
+		{PurchaseDate: "2023-12-31", PurchasePrice: 12.5, Currency: "GBP"},
+	}
+	for _, copy := range valid {
+		assert.NoError(t, validateCopy(&copy, 2), "%+v", copy)
+	}
+
+	invalid := []models.Copy{
+		{SleeveGrade: "EX"},
+		{SideGrades: "NM/X"},
+		{SideGrades: "NM/NM/NM"},
+		{RotStatus: "terrible"},
+		{PurchaseDate: "31/12/2023"},
+		{PurchasePrice: -1, Currency: "USD"},
+		{PurchasePrice: 10},
+		{Currency: "dollars"},
+	}
+	for _, copy := range invalid {
+		assert.ErrorIs(t, validateCopy(&copy, 2), ErrInvalidCopy, "%+v", copy)
+	}
+
+	// Any number of sides is fine when the LaserDisc's isn't known
+	assert.NoError(t, validateCopy(&models.Copy{SideGrades: "NM/NM/NM"}, 0))
+}
+
+func TestService_GetStats_Copies(t *testing.T) {
+	service := setupTestDB(t)
+	laserdisc, err := service.CreateLaserDisc(createTestLaserDisc())
+	require.NoError(t, err)
+
+	_, err = service.AddCopy(laserdisc.ID, &models.CopyRequest{
+		SleeveGrade:   stringPtr("NM"),
+		RotStatus:     stringPtr(models.RotNone),
+		PurchasePrice: floatPtr(19.99),
+		Currency:      stringPtr("USD"),
+	})
+	require.NoError(t, err)
+	_, err = service.AddCopy(laserdisc.ID, &models.CopyRequest{
+		SleeveGrade:   stringPtr("NM"),
+		PurchasePrice: floatPtr(5.01),
+		Currency:      stringPtr("USD"),
+	})
+	require.NoError(t, err)
+
+	stats, err := service.GetStats()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats["total"])
+	assert.Equal(t, int64(3), stats["copies"])
+	assert.Equal(t, map[string]int64{models.RotUnknown: 2, models.RotNone: 1}, stats["laser_rot"])
+	assert.Equal(t, map[string]int64{"NM": 2, "ungraded": 1}, stats["sleeve_grades"])
+	assert.Equal(t, map[string]float64{"USD": 25}, stats["spent"])
+}
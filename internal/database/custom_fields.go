@@ -0,0 +1,436 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// ErrInvalidCustomField is returned for a custom field without a name or of
+// an unknown type, or for a value that doesn't suit a field's type
+var ErrInvalidCustomField = errors.New("invalid custom field")
+
+// ErrDuplicateCustomField is returned when a custom field would have the same
+// name as another, ignoring case
+var ErrDuplicateCustomField = errors.New("a custom field with this name already exists")
+
+// maxCustomFieldName is the longest a custom field's name can be
+const maxCustomFieldName = 50
+
+// GetCustomFields returns every custom field, oldest first
+func (s *Service) GetCustomFields() ([]models.CustomField, error) {
+	fields := []models.CustomField{}
+	result := s.db.Order("id").Find(&fields)
+	return fields, result.Error
+}
+
+// CreateCustomField adds a custom field, which no LaserDisc has a value for
+// yet
+func (s *Service) CreateCustomField(req *models.CustomFieldRequest) (*models.CustomField, error) {
+	field := &models.CustomField{}
+	if req.Type != nil {
+		field.Type = strings.ToLower(strings.TrimSpace(*req.Type))
+	}
+	applyCustomFieldRequest(field, req)
+	if err := validateCustomField(field); err != nil {
+		return nil, err
+	}
+	if err := s.checkCustomFieldName(field); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(field).Error; err != nil {
+		return nil, err
+	}
+	return field, nil
+}
+
+// UpdateCustomField renames a custom field or changes an enum's options.
+// Options still in use can't be removed.
+func (s *Service) UpdateCustomField(id uint, req *models.CustomFieldRequest) (*models.CustomField, error) {
+	var field models.CustomField
+	if err := s.db.First(&field, id).Error; err != nil {
+		return nil, err
+	}
+	if req.Type != nil && !strings.EqualFold(strings.TrimSpace(*req.Type), field.Type) {
+		return nil, fmt.Errorf("%w: the type of %s can't be changed", ErrInvalidCustomField, field.Name)
+	}
+
+	applyCustomFieldRequest(&field, req)
+	if err := validateCustomField(&field); err != nil {
+		return nil, err
+	}
+	if err := s.checkCustomFieldName(&field); err != nil {
+		return nil, err
+	}
+
+	if field.Type == models.FieldTypeEnum {
+		var used []string
+		err := s.db.Model(&models.CustomFieldValue{}).
+			Where("field_id = ? AND value NOT IN ?", field.ID, field.Options).
+			Distinct().
+			Order("value").
+			Pluck("value", &used).Error
+		if err != nil {
+			return nil, err
+		}
+		if len(used) > 0 {
+			return nil, fmt.Errorf("%w: %s is still used by LaserDiscs", ErrInvalidCustomField, strings.Join(used, ", "))
+		}
+	}
+
+	if err := s.db.Save(&field).Error; err != nil {
+		return nil, err
+	}
+	return &field, nil
+}
+
+// DeleteCustomField deletes a custom field and every LaserDisc's value for it
+func (s *Service) DeleteCustomField(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.CustomField{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("field_id = ?", id).Delete(&models.CustomFieldValue{}).Error
+	})
+}
+
+// applyCustomFieldRequest copies the name and options given in a request
+// onto a custom field
+func applyCustomFieldRequest(field *models.CustomField, req *models.CustomFieldRequest) {
+	if req.Name != nil {
+		field.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Options != nil {
+		field.Options = make([]string, len(*req.Options))
+		for i, option := range *req.Options {
+			field.Options[i] = strings.TrimSpace(option)
+		}
+	}
+}
+
+// validateCustomField checks a custom field's name, type and options
+func validateCustomField(field *models.CustomField) error {
+	if field.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidCustomField)
+	}
+	if len([]rune(field.Name)) > maxCustomFieldName {
+		return fmt.Errorf("%w: name is longer than %d characters", ErrInvalidCustomField, maxCustomFieldName)
+	}
+	// Names are filtered on as custom[name]
+	if strings.ContainsAny(field.Name, "[]") {
+		return fmt.Errorf("%w: name can't contain [ or ]", ErrInvalidCustomField)
+	}
+	if !models.ValidFieldType(field.Type) {
+		return fmt.Errorf("%w: type %q, use one of %s", ErrInvalidCustomField, field.Type, strings.Join(models.FieldTypes, ", "))
+	}
+
+	if field.Type != models.FieldTypeEnum {
+		if len(field.Options) > 0 {
+			return fmt.Errorf("%w: only enum fields have options", ErrInvalidCustomField)
+		}
+		return nil
+	}
+	if len(field.Options) == 0 {
+		return fmt.Errorf("%w: an enum needs options", ErrInvalidCustomField)
+	}
+	seen := make(map[string]bool, len(field.Options))
+	for _, option := range field.Options {
+		if option == "" {
+			return fmt.Errorf("%w: empty option", ErrInvalidCustomField)
+		}
+		if seen[strings.ToLower(option)] {
+			return fmt.Errorf("%w: option %q is listed twice", ErrInvalidCustomField, option)
+		}
+		seen[strings.ToLower(option)] = true
+	}
+	return nil
+}
+
+// checkCustomFieldName returns ErrDuplicateCustomField when another custom
+// field has the same name
+func (s *Service) checkCustomFieldName(field *models.CustomField) error {
+	var count int64
+	if err := s.db.Model(&models.CustomField{}).Where("name = ? AND id != ?", field.Name, field.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %s", ErrDuplicateCustomField, field.Name)
+	}
+	return nil
+}
+
+// storedFieldValue returns the text a value given for a custom field is
+// stored as, or empty when it clears the field. Values can be given as
+// strings, or numbers as JSON numbers.
+func storedFieldValue(field *models.CustomField, value interface{}) (string, error) {
+	var text string
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		text = strings.TrimSpace(v)
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return "", fmt.Errorf("%w: %s must be a %s", ErrInvalidCustomField, field.Name, field.Type)
+	}
+	if text == "" {
+		return "", nil
+	}
+
+	switch field.Type {
+	case models.FieldTypeNumber:
+		number, err := strconv.ParseFloat(text, 64)
+		if err != nil || math.IsInf(number, 0) || math.IsNaN(number) {
+			return "", fmt.Errorf("%w: %s must be a number, not %q", ErrInvalidCustomField, field.Name, text)
+		}
+		return strconv.FormatFloat(number, 'f', -1, 64), nil
+	case models.FieldTypeDate:
+		if _, err := time.Parse("2006-01-02", text); err != nil {
+			return "", fmt.Errorf("%w: %s must be a date like 2024-03-01, not %q", ErrInvalidCustomField, field.Name, text)
+		}
+	case models.FieldTypeEnum:
+		for _, option := range field.Options {
+			if strings.EqualFold(option, text) {
+				return option, nil
+			}
+		}
+		return "", fmt.Errorf("%w: %s must be one of %s, not %q", ErrInvalidCustomField, field.Name, strings.Join(field.Options, ", "), text)
+	}
+	return text, nil
+}
+
+// typedFieldValue returns a stored value the way it's shown: numbers as
+// float64 and everything else as it's stored
+func typedFieldValue(field *models.CustomField, stored string) interface{} {
+	if field.Type == models.FieldTypeNumber {
+		if number, err := strconv.ParseFloat(stored, 64); err == nil {
+			return number
+		}
+	}
+	return stored
+}
+
+// setCustomFieldValues sets a LaserDisc's values for the custom fields
+// named, ignoring case. Empty values and nulls clear a field.
+func setCustomFieldValues(tx *gorm.DB, laserdiscID uint, values map[string]interface{}) error {
+	for name, value := range values {
+		var field models.CustomField
+		err := tx.Where("name = ?", strings.TrimSpace(name)).First(&field).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: there's no custom field named %s", ErrInvalidCustomField, name)
+		}
+		if err != nil {
+			return err
+		}
+
+		stored, err := storedFieldValue(&field, value)
+		if err != nil {
+			return err
+		}
+		if stored == "" {
+			err = tx.Where("laser_disc_id = ? AND field_id = ?", laserdiscID, field.ID).Delete(&models.CustomFieldValue{}).Error
+		} else {
+			err = tx.Clauses(clause.OnConflict{UpdateAll: true}).
+				Create(&models.CustomFieldValue{LaserDiscID: laserdiscID, FieldID: field.ID, Value: stored}).Error
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AttachCustomFields fills in the CustomFields of each LaserDisc
+func (s *Service) AttachCustomFields(laserdiscs []models.LaserDisc) error {
+	ids := make([]uint, len(laserdiscs))
+	for i := range laserdiscs {
+		ids[i] = laserdiscs[i].ID
+	}
+
+	byID, err := s.customFieldsFor(ids)
+	if err != nil {
+		return err
+	}
+	for i := range laserdiscs {
+		laserdiscs[i].CustomFields = byID[laserdiscs[i].ID]
+	}
+	return nil
+}
+
+// attachCustomFields fills in the CustomFields of one LaserDisc
+func (s *Service) attachCustomFields(laserdisc *models.LaserDisc) error {
+	byID, err := s.customFieldsFor([]uint{laserdisc.ID})
+	if err != nil {
+		return err
+	}
+	laserdisc.CustomFields = byID[laserdisc.ID]
+	return nil
+}
+
+// customFieldsFor loads the custom field values of the given LaserDiscs,
+// keyed by LaserDisc ID and then field name. Each ID has a map, empty when
+// it has no values.
+func (s *Service) customFieldsFor(ids []uint) (map[uint]map[string]interface{}, error) {
+	byID := make(map[uint]map[string]interface{}, len(ids))
+	for _, id := range ids {
+		byID[id] = map[string]interface{}{}
+	}
+
+	fields, err := s.GetCustomFields()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return byID, nil
+	}
+	fieldsByID := make(map[uint]*models.CustomField, len(fields))
+	for i := range fields {
+		fieldsByID[fields[i].ID] = &fields[i]
+	}
+
+	// In batches, to stay under SQLite's variable limit
+	for start := 0; start < len(ids); start += 500 {
+		end := min(start+500, len(ids))
+		var values []models.CustomFieldValue
+		if err := s.db.Where("laser_disc_id IN ?", ids[start:end]).Find(&values).Error; err != nil {
+			return nil, err
+		}
+		for _, value := range values {
+			if field, ok := fieldsByID[value.FieldID]; ok {
+				byID[value.LaserDiscID][field.Name] = typedFieldValue(field, value.Value)
+			}
+		}
+	}
+	return byID, nil
+}
+
+// resolveCustomFields looks up the custom fields a query filters or sorts
+// by, and checks the values it filters by suit their types
+func (s *Service) resolveCustomFields(q *LaserDiscQuery) error {
+	sortName, sortsByField := strings.CutPrefix(q.Sort, CustomSortPrefix)
+	if len(q.Custom) == 0 && !sortsByField {
+		return nil
+	}
+
+	fields, err := s.GetCustomFields()
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]*models.CustomField, len(fields))
+	for i := range fields {
+		byName[strings.ToLower(fields[i].Name)] = &fields[i]
+	}
+
+	var problems []string
+	problem := func(err error) {
+		problems = append(problems, strings.TrimPrefix(err.Error(), ErrInvalidCustomField.Error()+": "))
+	}
+	for i := range q.Custom {
+		filter := &q.Custom[i]
+		field, ok := byName[strings.ToLower(strings.TrimSpace(filter.Field))]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("there's no custom field named %s", filter.Field))
+			continue
+		}
+		filter.field = field
+
+		if (filter.Min != "" || filter.Max != "") && field.Type != models.FieldTypeNumber && field.Type != models.FieldTypeDate {
+			problems = append(problems, fmt.Sprintf("%s is a %s field, only number and date fields have ranges", field.Name, field.Type))
+			continue
+		}
+		for _, value := range []*string{&filter.Value, &filter.Min, &filter.Max} {
+			stored, err := storedFieldValue(field, *value)
+			if err != nil {
+				problem(err)
+			}
+			*value = stored
+		}
+	}
+	if sortsByField {
+		if field, ok := byName[strings.ToLower(sortName)]; ok {
+			q.sortField = field
+		} else {
+			problems = append(problems, fmt.Sprintf("can't sort by %s, there's no custom field named %s", q.Sort, sortName))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidQuery, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// customValueSQL returns SQL for a custom_field_values row's value as it
+// compares: numerically for number fields
+func customValueSQL(field *models.CustomField) string {
+	if field.Type == models.FieldTypeNumber {
+		return "CAST(custom_field_values.value AS REAL)"
+	}
+	return "custom_field_values.value"
+}
+
+// customArg returns a filter's stored value as it's compared with
+// customValueSQL
+func customArg(field *models.CustomField, stored string) interface{} {
+	if field.Type == models.FieldTypeNumber {
+		number, _ := strconv.ParseFloat(stored, 64)
+		return number
+	}
+	return stored
+}
+
+// condition returns the SQL condition and its arguments for a resolved
+// filter, see resolveCustomFields
+func (f CustomFilter) condition() (string, []interface{}) {
+	conditions := []string{"custom_field_values.laser_disc_id = laserdiscs.id", "custom_field_values.field_id = ?"}
+	args := []interface{}{f.field.ID}
+	value := customValueSQL(f.field)
+	if f.Value != "" {
+		if f.field.Type == models.FieldTypeText {
+			conditions = append(conditions, value+" = ? COLLATE NOCASE")
+		} else {
+			conditions = append(conditions, value+" = ?")
+		}
+		args = append(args, customArg(f.field, f.Value))
+	}
+	if f.Min != "" {
+		conditions = append(conditions, value+" >= ?")
+		args = append(args, customArg(f.field, f.Min))
+	}
+	if f.Max != "" {
+		conditions = append(conditions, value+" <= ?")
+		args = append(args, customArg(f.field, f.Max))
+	}
+	return "EXISTS (SELECT 1 FROM custom_field_values WHERE " + strings.Join(conditions, " AND ") + ")", args
+}
+
+// customSortSQL returns SQL for a LaserDisc's value of a custom field, NULL
+// when it has none. Text sorts ignoring case, and enums in option order.
+func customSortSQL(field *models.CustomField) string {
+	value := customValueSQL(field)
+	if field.Type == models.FieldTypeEnum {
+		positions := make([]string, len(field.Options))
+		for i, option := range field.Options {
+			positions[i] = fmt.Sprintf("WHEN '%s' THEN %d", strings.ReplaceAll(option, "'", "''"), i)
+		}
+		value = fmt.Sprintf("CASE %s %s END", value, strings.Join(positions, " "))
+	}
+	sql := fmt.Sprintf("(SELECT %s FROM custom_field_values WHERE custom_field_values.laser_disc_id = laserdiscs.id AND custom_field_values.field_id = %d)", value, field.ID)
+	if field.Type == models.FieldTypeText {
+		sql += " COLLATE NOCASE"
+	}
+	return sql
+}
@@ -0,0 +1,163 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// createCustomField creates a custom field for a test
+func createCustomField(t *testing.T, service *Service, name, fieldType string, options ...string) *models.CustomField {
+	req := &models.CustomFieldRequest{Name: &name, Type: &fieldType}
+	if len(options) > 0 {
+		req.Options = &options
+	}
+	field, err := service.CreateCustomField(req)
+	require.NoError(t, err)
+	return field
+}
+
+func TestService_CustomFields(t *testing.T) {
+	service := setupTestDB(t)
+	region := createCustomField(t, service, "Region", models.FieldTypeEnum, "NTSC-U", " NTSC-J ", "PAL")
+	assert.Equal(t, []string{"NTSC-U", "NTSC-J", "PAL"}, region.Options)
+	createCustomField(t, service, "Discs", models.FieldTypeNumber)
+	createCustomField(t, service, "Purchased", models.FieldTypeDate)
+	shelf := createCustomField(t, service, "Shelf", models.FieldTypeText)
+
+	invalid := []models.CustomFieldRequest{
+		{Type: stringPtr(models.FieldTypeText)},
+		{Name: stringPtr("Rating"), Type: stringPtr("stars")},
+		{Name: stringPtr("Rating"), Type: stringPtr(models.FieldTypeEnum)},
+		{Name: stringPtr("Rating"), Type: stringPtr(models.FieldTypeNumber), Options: &[]string{"1"}},
+		{Name: stringPtr("Rating"), Type: stringPtr(models.FieldTypeEnum), Options: &[]string{"Good", "good"}},
+		{Name: stringPtr("Rating[1]"), Type: stringPtr(models.FieldTypeText)},
+	}
+	for _, req := range invalid {
+		_, err := service.CreateCustomField(&req)
+		assert.ErrorIs(t, err, ErrInvalidCustomField, "%+v", req)
+	}
+	_, err := service.CreateCustomField(&models.CustomFieldRequest{Name: stringPtr("region"), Type: stringPtr(models.FieldTypeText)})
+	assert.ErrorIs(t, err, ErrDuplicateCustomField)
+
+	// Values are checked against the field's type and stored in one form
+	req := createTestLaserDisc()
+	req.CustomFields = map[string]interface{}{
+		"region":    "ntsc-j",
+		"Discs":     "2.50",
+		"Purchased": "2024-03-01",
+		"Shelf":     " B3 ",
+	}
+	laserdisc, err := service.CreateLaserDisc(req)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"Region":    "NTSC-J",
+		"Discs":     2.5,
+		"Purchased": "2024-03-01",
+		"Shelf":     "B3",
+	}, laserdisc.CustomFields)
+
+	invalidValues := []map[string]interface{}{
+		{"Region": "SECAM"},
+		{"Discs": "two"},
+		{"Purchased": "March 2024"},
+		{"Shelf": true},
+		{"Owner": "Dad"},
+	}
+	for _, values := range invalidValues {
+		_, err := service.UpdateLaserDisc(laserdisc.ID, &models.UpdateLaserDiscRequest{CustomFields: values})
+		assert.ErrorIs(t, err, ErrInvalidCustomField, "%v", values)
+	}
+
+	// Only the values given change, and null or empty clears one
+	updated, err := service.UpdateLaserDisc(laserdisc.ID, &models.UpdateLaserDiscRequest{CustomFields: map[string]interface{}{
+		"Discs":     float64(3),
+		"Shelf":     nil,
+		"Purchased": "",
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"Region": "NTSC-J", "Discs": 3.0}, updated.CustomFields)
+
+	// A field's type can't change, nor can options in use be removed
+	_, err = service.UpdateCustomField(region.ID, &models.CustomFieldRequest{Type: stringPtr(models.FieldTypeText)})
+	assert.ErrorIs(t, err, ErrInvalidCustomField)
+	_, err = service.UpdateCustomField(region.ID, &models.CustomFieldRequest{Options: &[]string{"NTSC-U", "PAL"}})
+	assert.ErrorIs(t, err, ErrInvalidCustomField)
+	assert.ErrorContains(t, err, "NTSC-J")
+	renamed, err := service.UpdateCustomField(region.ID, &models.CustomFieldRequest{
+		Name:    stringPtr("Disc region"),
+		Options: &[]string{"NTSC-U", "NTSC-J", "PAL", "PAL-M"},
+	})
+	require.NoError(t, err)
+	assert.Len(t, renamed.Options, 4)
+	retrieved, err := service.GetLaserDiscByID(laserdisc.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "NTSC-J", retrieved.CustomFields["Disc region"])
+	_, err = service.UpdateCustomField(999999, &models.CustomFieldRequest{})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// Deleting a field deletes its values, and deleting a LaserDisc all of its
+	// values
+	require.NoError(t, service.DeleteCustomField(shelf.ID))
+	assert.ErrorIs(t, service.DeleteCustomField(shelf.ID), gorm.ErrRecordNotFound)
+	require.NoError(t, service.DeleteLaserDisc(laserdisc.ID))
+	var count int64
+	require.NoError(t, service.db.Model(&models.CustomFieldValue{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestService_QueryLaserDiscs_CustomFields(t *testing.T) {
+	service := setupTestDB(t)
+	laserdiscs := createRandomLaserDiscs(t, service, 4)
+	createCustomField(t, service, "Region", models.FieldTypeEnum, "NTSC-U", "NTSC-J", "PAL")
+	createCustomField(t, service, "Discs", models.FieldTypeNumber)
+	createCustomField(t, service, "Purchased", models.FieldTypeDate)
+	createCustomField(t, service, "Shelf", models.FieldTypeText)
+
+	set := func(i int, values map[string]interface{}) {
+		_, err := service.UpdateLaserDisc(laserdiscs[i].ID, &models.UpdateLaserDiscRequest{CustomFields: values})
+		require.NoError(t, err)
+	}
+	set(0, map[string]interface{}{"Region": "PAL", "Discs": 10.0, "Purchased": "2023-06-01", "Shelf": "b2"})
+	set(1, map[string]interface{}{"Region": "NTSC-U", "Discs": 9.0, "Purchased": "2024-01-15", "Shelf": "A1"})
+	set(2, map[string]interface{}{"Region": "NTSC-J", "Discs": 2.0})
+
+	query := func(q LaserDiscQuery) []string {
+		page, _, err := service.QueryLaserDiscs(q)
+		require.NoError(t, err)
+		return titles(page)
+	}
+
+	// Exact values ignore case, and ranges compare numbers as numbers
+	assert.Equal(t, []string{"Disc 2"}, query(LaserDiscQuery{Custom: []CustomFilter{{Field: "region", Value: "ntsc-j"}}}))
+	assert.Equal(t, []string{"Disc 0"}, query(LaserDiscQuery{Custom: []CustomFilter{{Field: "Shelf", Value: "B2"}}}))
+	assert.Equal(t, []string{"Disc 0", "Disc 1"}, query(LaserDiscQuery{Custom: []CustomFilter{{Field: "Discs", Min: "3"}}}))
+	assert.Equal(t, []string{"Disc 1"}, query(LaserDiscQuery{Custom: []CustomFilter{
+		{Field: "Discs", Max: "9.5"},
+		{Field: "Purchased", Min: "2024-01-01", Max: "2024-12-31"},
+	}}))
+	assert.Equal(t, []string{"Disc 0", "Disc 1"}, query(LaserDiscQuery{Custom: []CustomFilter{{Field: "Purchased"}}}), "has a value")
+
+	// Sorted numerically, in option order or ignoring case, missing values last
+	assert.Equal(t, []string{"Disc 2", "Disc 1", "Disc 0", "Disc 3"}, query(LaserDiscQuery{Sort: "custom:Discs"}))
+	assert.Equal(t, []string{"Disc 0", "Disc 1", "Disc 2", "Disc 3"}, query(LaserDiscQuery{Sort: "custom:discs", Desc: true}))
+	assert.Equal(t, []string{"Disc 1", "Disc 2", "Disc 0", "Disc 3"}, query(LaserDiscQuery{Sort: "custom:Region"}))
+	assert.Equal(t, []string{"Disc 1", "Disc 0", "Disc 2", "Disc 3"}, query(LaserDiscQuery{Sort: "custom:Shelf"}))
+
+	// Unknown fields and values that don't suit the type are listed
+	_, _, err := service.QueryLaserDiscs(LaserDiscQuery{
+		Custom: []CustomFilter{{Field: "Owner", Value: "Dad"}, {Field: "Region", Min: "NTSC-U"}, {Field: "Discs", Value: "many"}},
+		Sort:   "custom:Condition",
+	})
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+	assert.ErrorContains(t, err, "no custom field named Owner")
+	assert.ErrorContains(t, err, "only number and date fields have ranges")
+	assert.ErrorContains(t, err, "Discs must be a number")
+	assert.ErrorContains(t, err, "can't sort by custom:Condition")
+	_, _, err = service.QueryLaserDiscs(LaserDiscQuery{Sort: "custom:"})
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+}
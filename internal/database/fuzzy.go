@@ -0,0 +1,182 @@
+package database
+
+import (
+	"html"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/fuzzy"
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// maxFuzzyMatches is the most LaserDiscs a fuzzy search finds
+const maxFuzzyMatches = 500
+
+// scoredTitle is a LaserDisc's title and how well it matched
+type scoredTitle struct {
+	ID    uint
+	Title string
+	Year  int
+	Score float64
+}
+
+// scoreTitles scores the title of every LaserDisc db selects, returning
+// those scoring at least threshold, best first
+func scoreTitles(db *gorm.DB, score func(title string) float64, threshold float64) ([]scoredTitle, error) {
+	var rows []scoredTitle
+	if err := db.Select("laserdiscs.id, laserdiscs.title, laserdiscs.year").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	scored := rows[:0]
+	for _, row := range rows {
+		row.Score = score(row.Title)
+		if row.Score >= threshold {
+			scored = append(scored, row)
+		}
+	}
+	sort.SliceStable(scored, func(i, j int) bool {
+		if scored[i].Score != scored[j].Score {
+			return scored[i].Score > scored[j].Score
+		}
+		if scored[i].Title != scored[j].Title {
+			return scored[i].Title < scored[j].Title
+		}
+		return scored[i].ID < scored[j].ID
+	})
+	return scored, nil
+}
+
+// searchText returns the words of a search that could be part of a title,
+// leaving out its syntax and terms limited to other fields
+func searchText(search string) string {
+	var words []string
+	for _, term := range parseSearch(search) {
+		if term.field == "" || term.field == "title" {
+			words = append(words, term.text)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// fieldTerms returns the terms of a search limited to fields other than the
+// title, which a fuzzy search still matches exactly
+func fieldTerms(search string) []searchTerm {
+	var terms []searchTerm
+	for _, term := range parseSearch(search) {
+		if term.field != "" && term.field != "title" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// fuzzyQuery runs a query whose search matches titles loosely. The other
+// filters and the search's terms for other fields, e.g. director:cameron,
+// are applied in SQL, and the titles they leave are scored in Go.
+func (s *Service) fuzzyQuery(q LaserDiscQuery) ([]models.LaserDisc, int64, error) {
+	matcher := fuzzy.NewMatcher(searchText(q.Search))
+	scored, err := scoreTitles(s.filter(q, fieldTerms(q.Search)), matcher.Score, fuzzy.MatchThreshold)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(scored) > maxFuzzyMatches {
+		scored = scored[:maxFuzzyMatches]
+	}
+
+	total := int64(len(scored))
+	laserdiscs := []models.LaserDisc{}
+	if total == 0 || int64(q.Offset) >= total {
+		return laserdiscs, total, nil
+	}
+
+	ids := make([]uint, len(scored))
+	scores := make(map[uint]float64, len(scored))
+	for i, match := range scored {
+		ids[i] = match.ID
+		scores[match.ID] = match.Score
+	}
+
+	if q.Sort != "" {
+		// Sort and page the matches in SQL
+		page := s.db.Where("id IN ?", ids).Order(q.order(false)).Offset(q.Offset)
+		if q.Limit > 0 {
+			page = page.Limit(q.Limit)
+		}
+		if err := page.Find(&laserdiscs).Error; err != nil {
+			return nil, 0, err
+		}
+	} else {
+		// Page the matches best first
+		ids = ids[q.Offset:]
+		if q.Limit > 0 && len(ids) > q.Limit {
+			ids = ids[:q.Limit]
+		}
+		if err := s.db.Where("id IN ?", ids).Find(&laserdiscs).Error; err != nil {
+			return nil, 0, err
+		}
+		position := make(map[uint]int, len(ids))
+		for i, id := range ids {
+			position[id] = i
+		}
+		sort.Slice(laserdiscs, func(i, j int) bool {
+			return position[laserdiscs[i].ID] < position[laserdiscs[j].ID]
+		})
+	}
+
+	for i := range laserdiscs {
+		laserdiscs[i].Match = &models.SearchMatch{
+			Score: scores[laserdiscs[i].ID],
+			Title: html.EscapeString(laserdiscs[i].Title),
+		}
+	}
+	return laserdiscs, total, nil
+}
+
+// SuggestTitles returns up to limit titles in the collection that a search
+// may have meant, best first, for when it found nothing
+func (s *Service) SuggestTitles(search string, limit int) ([]string, error) {
+	text := searchText(search)
+	if text == "" {
+		return []string{}, nil
+	}
+	scored, err := scoreTitles(s.db.Model(&models.LaserDisc{}), fuzzy.NewMatcher(text).Score, fuzzy.SuggestThreshold)
+	if err != nil {
+		return nil, err
+	}
+
+	// The same title can be in the collection more than once, or filed
+	// differently, e.g. "The Terminator" and "Terminator, The"
+	suggestions := []string{}
+	seen := make(map[string]bool)
+	for _, match := range scored {
+		if len(suggestions) == limit {
+			break
+		}
+		key := fuzzy.Normalize(match.Title)
+		if !seen[key] {
+			seen[key] = true
+			suggestions = append(suggestions, match.Title)
+		}
+	}
+	return suggestions, nil
+}
+
+// SimilarTitles returns the LaserDiscs, other than excludeID, whose titles
+// are nearly the same as title, most alike first
+func (s *Service) SimilarTitles(title string, excludeID uint) ([]models.TitleMatch, error) {
+	scored, err := scoreTitles(s.db.Model(&models.LaserDisc{}).Where("id != ?", excludeID), func(other string) float64 {
+		return fuzzy.Similarity(title, other)
+	}, fuzzy.DuplicateThreshold)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]models.TitleMatch, len(scored))
+	for i, match := range scored {
+		matches[i] = models.TitleMatch{ID: match.ID, Title: match.Title, Year: match.Year, Score: match.Score}
+	}
+	return matches, nil
+}
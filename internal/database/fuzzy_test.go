@@ -0,0 +1,131 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// createFuzzyLaserDiscs adds LaserDiscs for the fuzzy search tests
+func createFuzzyLaserDiscs(t *testing.T, service *Service) {
+	laserdiscs := []models.LaserDisc{
+		{Title: "Blade Runner", Year: 1982, Watched: true},
+		{Title: "Terminator, The", Year: 1984, Director: "James Cameron"},
+		{Title: "Terminator 2: Judgment Day", Year: 1991, Director: "James Cameron"},
+		{Title: "Rocky II", Year: 1979},
+		{Title: "Blade Runner", Year: 1992, Notes: "Director's cut"},
+		{Title: "Jaws", Year: 1975},
+		{Title: "The Terminator", Year: 1984},
+	}
+	require.NoError(t, service.db.Create(&laserdiscs).Error)
+}
+
+func TestService_FuzzySearch(t *testing.T) {
+	service := setupTestDB(t)
+	createFuzzyLaserDiscs(t, service)
+
+	// Exact searches miss these
+	for _, search := range []string{"Bladerunner", "Termnator", "rocky 2"} {
+		_, total, err := service.QueryLaserDiscs(LaserDiscQuery{Search: search})
+		require.NoError(t, err)
+		assert.Zero(t, total, search)
+	}
+
+	laserdiscs, total, err := service.QueryLaserDiscs(LaserDiscQuery{Search: "Termnator", Fuzzy: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Equal(t, []string{"Terminator, The", "The Terminator", "Terminator 2: Judgment Day"}, titles(laserdiscs), "whole titles first")
+	require.NotNil(t, laserdiscs[0].Match)
+	assert.Greater(t, laserdiscs[1].Match.Score, laserdiscs[2].Match.Score)
+	assert.Equal(t, "Terminator, The", laserdiscs[0].Match.Title)
+
+	laserdiscs, _, err = service.QueryLaserDiscs(LaserDiscQuery{Search: "rocky 2", Fuzzy: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Rocky II"}, titles(laserdiscs))
+
+	// Terms for other fields still match exactly
+	laserdiscs, _, err = service.QueryLaserDiscs(LaserDiscQuery{Search: "Termnator director:cameron", Fuzzy: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Terminator, The", "Terminator 2: Judgment Day"}, titles(laserdiscs))
+	laserdiscs, _, err = service.QueryLaserDiscs(LaserDiscQuery{Search: "bladerunner notes:cut", Fuzzy: true})
+	require.NoError(t, err)
+	require.Len(t, laserdiscs, 1)
+	assert.Equal(t, 1992, laserdiscs[0].Year)
+
+	// Filters, sorting and paging still apply
+	no := false
+	laserdiscs, total, err = service.QueryLaserDiscs(LaserDiscQuery{Search: "bladerunner", Fuzzy: true, Watched: &no})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, laserdiscs, 1)
+	assert.Equal(t, 1992, laserdiscs[0].Year)
+
+	laserdiscs, total, err = service.QueryLaserDiscs(LaserDiscQuery{Search: "terminator", Fuzzy: true, Sort: "year", Desc: true, Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Equal(t, []string{"Terminator 2: Judgment Day"}, titles(laserdiscs))
+
+	laserdiscs, total, err = service.QueryLaserDiscs(LaserDiscQuery{Search: "terminator", Fuzzy: true, Limit: 2, Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Equal(t, []string{"The Terminator", "Terminator 2: Judgment Day"}, titles(laserdiscs))
+
+	laserdiscs, total, err = service.QueryLaserDiscs(LaserDiscQuery{Search: "casablanca", Fuzzy: true})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+	assert.NotNil(t, laserdiscs)
+}
+
+func TestService_SuggestTitles(t *testing.T) {
+	service := setupTestDB(t)
+	createFuzzyLaserDiscs(t, service)
+
+	suggestions, err := service.SuggestTitles("Bladerunner", 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Blade Runner"}, suggestions, "each title is suggested once")
+
+	suggestions, err = service.SuggestTitles(`title:"Termnator" director:cameron`, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Terminator, The", "Terminator 2: Judgment Day"}, suggestions, "however it's filed")
+
+	suggestions, err = service.SuggestTitles("casablanca", 5)
+	require.NoError(t, err)
+	assert.Empty(t, suggestions)
+	assert.NotNil(t, suggestions)
+
+	suggestions, err = service.SuggestTitles("director:cameron", 5)
+	require.NoError(t, err)
+	assert.Empty(t, suggestions)
+}
+
+func TestService_SimilarTitles(t *testing.T) {
+	service := setupTestDB(t)
+	createFuzzyLaserDiscs(t, service)
+
+	similar, err := service.SimilarTitles("Terminator", 0)
+	require.NoError(t, err)
+	require.Len(t, similar, 2)
+	assert.Equal(t, "Terminator, The", similar[0].Title)
+	assert.Equal(t, 1984, similar[0].Year)
+	assert.Equal(t, 1.0, similar[0].Score)
+
+	similar, err = service.SimilarTitles("Rocky 2", 0)
+	require.NoError(t, err)
+	require.Len(t, similar, 1)
+	assert.Equal(t, "Rocky II", similar[0].Title)
+
+	// A LaserDisc isn't a duplicate of itself
+	var rocky models.LaserDisc
+	require.NoError(t, service.db.Where("title = ?", "Rocky II").First(&rocky).Error)
+	similar, err = service.SimilarTitles("Rocky II", rocky.ID)
+	require.NoError(t, err)
+	assert.Empty(t, similar)
+
+	// Sequels aren't duplicates
+	similar, err = service.SimilarTitles("Rocky III", 0)
+	require.NoError(t, err)
+	assert.Empty(t, similar)
+}
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// ErrInvalidImageOrder is returned when a new image order doesn't list
+// exactly the LaserDisc's images
+var ErrInvalidImageOrder = errors.New("image order must list each of the LaserDisc's images once")
+
+// GetLaserDiscImages returns a LaserDisc's images in display order
+func (s *Service) GetLaserDiscImages(laserdiscID uint) ([]models.LaserDiscImage, error) {
+	var images []models.LaserDiscImage
+	result := s.db.Where("laser_disc_id = ?", laserdiscID).Order("position ASC, id ASC").Find(&images)
+	return images, result.Error
+}
+
+// GetLaserDiscImage retrieves one of a LaserDisc's images
+func (s *Service) GetLaserDiscImage(laserdiscID, imageID uint) (*models.LaserDiscImage, error) {
+	var image models.LaserDiscImage
+	result := s.db.Where("laser_disc_id = ?", laserdiscID).First(&image, imageID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &image, nil
+}
+
+// GetPrimaryLaserDiscImage returns the image chosen as a LaserDisc's cover
+func (s *Service) GetPrimaryLaserDiscImage(laserdiscID uint) (*models.LaserDiscImage, error) {
+	var image models.LaserDiscImage
+	result := s.db.Where("laser_disc_id = ? AND is_primary = ?", laserdiscID, true).First(&image)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &image, nil
+}
+
+// AddLaserDiscImage adds an image after the LaserDisc's existing ones. If
+// it's marked primary it replaces the current primary image.
+func (s *Service) AddLaserDiscImage(image *models.LaserDiscImage) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").First(&models.LaserDisc{}, image.LaserDiscID).Error; err != nil {
+			return err
+		}
+
+		var last struct{ Position *int }
+		if err := tx.Model(&models.LaserDiscImage{}).Select("MAX(position) AS position").
+			Where("laser_disc_id = ?", image.LaserDiscID).Scan(&last).Error; err != nil {
+			return err
+		}
+		image.Position = 0
+		if last.Position != nil {
+			image.Position = *last.Position + 1
+		}
+
+		if image.Primary {
+			if err := clearPrimaryImage(tx, image.LaserDiscID); err != nil {
+				return err
+			}
+		}
+		return tx.Create(image).Error
+	})
+}
+
+// ReorderLaserDiscImages puts a LaserDisc's images in the order of imageIDs,
+// which must list every one of its images exactly once
+func (s *Service) ReorderLaserDiscImages(laserdiscID uint, imageIDs []uint) ([]models.LaserDiscImage, error) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id").First(&models.LaserDisc{}, laserdiscID).Error; err != nil {
+			return err
+		}
+
+		var existing []uint
+		if err := tx.Model(&models.LaserDiscImage{}).Where("laser_disc_id = ?", laserdiscID).Pluck("id", &existing).Error; err != nil {
+			return err
+		}
+		if len(existing) != len(imageIDs) {
+			return ErrInvalidImageOrder
+		}
+		remaining := make(map[uint]bool, len(existing))
+		for _, id := range existing {
+			remaining[id] = true
+		}
+		for _, id := range imageIDs {
+			if !remaining[id] {
+				return ErrInvalidImageOrder
+			}
+			delete(remaining, id)
+		}
+
+		for position, id := range imageIDs {
+			if err := tx.Model(&models.LaserDiscImage{}).Where("id = ?", id).Update("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetLaserDiscImages(laserdiscID)
+}
+
+// SetPrimaryLaserDiscImage makes an image the LaserDisc's cover, replacing
+// whichever image was before
+func (s *Service) SetPrimaryLaserDiscImage(laserdiscID, imageID uint) (*models.LaserDiscImage, error) {
+	var image models.LaserDiscImage
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("laser_disc_id = ?", laserdiscID).First(&image, imageID).Error; err != nil {
+			return err
+		}
+		if err := clearPrimaryImage(tx, laserdiscID); err != nil {
+			return err
+		}
+		image.Primary = true
+		return tx.Model(&image).Update("is_primary", true).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// DeleteLaserDiscImage deletes one of a LaserDisc's images, returning it so
+// the caller can clean up its file
+func (s *Service) DeleteLaserDiscImage(laserdiscID, imageID uint) (*models.LaserDiscImage, error) {
+	image, err := s.GetLaserDiscImage(laserdiscID, imageID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Delete(image).Error; err != nil {
+		return nil, err
+	}
+	return image, nil
+}
+
+// ImageFileInUse reports whether any image still refers to file. Identical
+// uploads share a file, so it can only be removed once this is false.
+func (s *Service) ImageFileInUse(file string) (bool, error) {
+	var count int64
+	result := s.db.Model(&models.LaserDiscImage{}).Where("file = ?", file).Count(&count)
+	return count > 0, result.Error
+}
+
+// clearPrimaryImage unsets the primary flag on all of a LaserDisc's images
+func clearPrimaryImage(tx *gorm.DB, laserdiscID uint) error {
+	return tx.Model(&models.LaserDiscImage{}).
+		Where("laser_disc_id = ? AND is_primary = ?", laserdiscID, true).
+		Update("is_primary", false).Error
+}
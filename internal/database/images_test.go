@@ -0,0 +1,138 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// addTestImage adds an image with the given file to a LaserDisc
+func addTestImage(t *testing.T, service *Service, laserdiscID uint, file string, primary bool) *models.LaserDiscImage {
+	t.Helper()
+	image := &models.LaserDiscImage{LaserDiscID: laserdiscID, Kind: models.ImageKindFront, File: file, Primary: primary}
+	require.NoError(t, service.AddLaserDiscImage(image))
+	return image
+}
+
+func TestService_AddLaserDiscImage(t *testing.T) {
+	service := setupTestDB(t)
+	laserdisc, err := service.CreateLaserDisc(createTestLaserDisc())
+	require.NoError(t, err)
+
+	first := addTestImage(t, service, laserdisc.ID, "a.jpg", false)
+	second := addTestImage(t, service, laserdisc.ID, "b.jpg", false)
+	assert.Equal(t, 0, first.Position)
+	assert.Equal(t, 1, second.Position)
+
+	images, err := service.GetLaserDiscImages(laserdisc.ID)
+	require.NoError(t, err)
+	require.Len(t, images, 2)
+	assert.Equal(t, "a.jpg", images[0].File)
+	assert.Equal(t, "b.jpg", images[1].File)
+
+	// Images can't be added to a missing LaserDisc
+	err = service.AddLaserDiscImage(&models.LaserDiscImage{LaserDiscID: 999, File: "c.jpg"})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestService_SetPrimaryLaserDiscImage(t *testing.T) {
+	service := setupTestDB(t)
+	laserdisc, err := service.CreateLaserDisc(createTestLaserDisc())
+	require.NoError(t, err)
+
+	_, err = service.GetPrimaryLaserDiscImage(laserdisc.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	first := addTestImage(t, service, laserdisc.ID, "a.jpg", true)
+	second := addTestImage(t, service, laserdisc.ID, "b.jpg", false)
+
+	primary, err := service.GetPrimaryLaserDiscImage(laserdisc.ID)
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, primary.ID)
+
+	// Only one image is primary at a time
+	updated, err := service.SetPrimaryLaserDiscImage(laserdisc.ID, second.ID)
+	require.NoError(t, err)
+	assert.True(t, updated.Primary)
+	primary, err = service.GetPrimaryLaserDiscImage(laserdisc.ID)
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, primary.ID)
+
+	// Adding a primary image replaces the current one
+	third := addTestImage(t, service, laserdisc.ID, "c.jpg", true)
+	images, err := service.GetLaserDiscImages(laserdisc.ID)
+	require.NoError(t, err)
+	for _, image := range images {
+		assert.Equal(t, image.ID == third.ID, image.Primary, "image %d", image.ID)
+	}
+
+	// The image must belong to the LaserDisc
+	_, err = service.SetPrimaryLaserDiscImage(laserdisc.ID+1, first.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestService_ReorderLaserDiscImages(t *testing.T) {
+	service := setupTestDB(t)
+	laserdisc, err := service.CreateLaserDisc(createTestLaserDisc())
+	require.NoError(t, err)
+
+	a := addTestImage(t, service, laserdisc.ID, "a.jpg", false)
+	b := addTestImage(t, service, laserdisc.ID, "b.jpg", false)
+	c := addTestImage(t, service, laserdisc.ID, "c.jpg", false)
+
+	images, err := service.ReorderLaserDiscImages(laserdisc.ID, []uint{c.ID, a.ID, b.ID})
+	require.NoError(t, err)
+	require.Len(t, images, 3)
+	assert.Equal(t, []string{"c.jpg", "a.jpg", "b.jpg"}, []string{images[0].File, images[1].File, images[2].File})
+
+	// Every image must be listed exactly once
+	for _, ids := range [][]uint{{a.ID, b.ID}, {a.ID, b.ID, b.ID}, {a.ID, b.ID, 999}} {
+		_, err = service.ReorderLaserDiscImages(laserdisc.ID, ids)
+		assert.ErrorIs(t, err, ErrInvalidImageOrder, "%v", ids)
+	}
+
+	_, err = service.ReorderLaserDiscImages(999, nil)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestService_DeleteLaserDiscImage(t *testing.T) {
+	service := setupTestDB(t)
+	laserdisc, err := service.CreateLaserDisc(createTestLaserDisc())
+	require.NoError(t, err)
+
+	// Identical uploads share a file
+	a := addTestImage(t, service, laserdisc.ID, "same.jpg", false)
+	b := addTestImage(t, service, laserdisc.ID, "same.jpg", false)
+
+	deleted, err := service.DeleteLaserDiscImage(laserdisc.ID, a.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "same.jpg", deleted.File)
+	inUse, err := service.ImageFileInUse("same.jpg")
+	require.NoError(t, err)
+	assert.True(t, inUse)
+
+	_, err = service.DeleteLaserDiscImage(laserdisc.ID, a.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	_, err = service.DeleteLaserDiscImage(laserdisc.ID, b.ID)
+	require.NoError(t, err)
+	inUse, err = service.ImageFileInUse("same.jpg")
+	require.NoError(t, err)
+	assert.False(t, inUse)
+}
+
+func TestService_DeleteLaserDiscDeletesImages(t *testing.T) {
+	service := setupTestDB(t)
+	laserdisc, err := service.CreateLaserDisc(createTestLaserDisc())
+	require.NoError(t, err)
+	addTestImage(t, service, laserdisc.ID, "a.jpg", true)
+
+	require.NoError(t, service.DeleteLaserDisc(laserdisc.ID))
+	inUse, err := service.ImageFileInUse("a.jpg")
+	require.NoError(t, err)
+	assert.False(t, inUse)
+}
@@ -0,0 +1,236 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// ErrInvalidInspection is returned for an inspection of a side the copy
+// doesn't have, or with an unknown defect or out of range severity
+var ErrInvalidInspection = errors.New("invalid inspection")
+
+// timecode matches a position into a side, M:SS or H:MM:SS
+var timecode = regexp.MustCompile(`^(\d{1,2}:)?\d{1,2}:\d{2}$`)
+
+// GetInspections returns a copy's inspections, oldest first
+func (s *Service) GetInspections(laserdiscID, copyID uint) ([]models.Inspection, error) {
+	if _, err := s.GetCopy(laserdiscID, copyID); err != nil {
+		return nil, err
+	}
+
+	inspections := []models.Inspection{}
+	result := s.db.Where("copy_id = ?", copyID).Order("inspected_date, side, id").Find(&inspections)
+	return inspections, result.Error
+}
+
+// AddInspection logs an inspection of one side of a copy
+func (s *Service) AddInspection(laserdiscID, copyID uint, req *models.CreateInspectionRequest) (*models.Inspection, error) {
+	if _, err := s.GetCopy(laserdiscID, copyID); err != nil {
+		return nil, err
+	}
+	var laserdisc models.LaserDisc
+	if err := s.db.First(&laserdisc, laserdiscID).Error; err != nil {
+		return nil, err
+	}
+
+	inspection := &models.Inspection{
+		CopyID:        copyID,
+		LaserDiscID:   laserdiscID,
+		Side:          req.Side,
+		InspectedDate: strings.TrimSpace(req.InspectedDate),
+		Player:        strings.TrimSpace(req.Player),
+		Defect:        strings.ToLower(strings.TrimSpace(req.Defect)),
+		Timecode:      strings.TrimSpace(req.Timecode),
+		Chapter:       req.Chapter,
+		Severity:      req.Severity,
+		Notes:         req.Notes,
+	}
+	if inspection.InspectedDate == "" {
+		inspection.InspectedDate = time.Now().Format("2006-01-02")
+	}
+	if err := validateInspection(inspection, laserdisc.Sides); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(inspection).Error; err != nil {
+		return nil, err
+	}
+	return inspection, nil
+}
+
+// DeleteInspection deletes one of a copy's inspections
+func (s *Service) DeleteInspection(laserdiscID, copyID, inspectionID uint) error {
+	result := s.db.Where("laser_disc_id = ? AND copy_id = ?", laserdiscID, copyID).Delete(&models.Inspection{}, inspectionID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// validateInspection checks an inspection's side, date, defect, position and
+// severity. A LaserDisc with a known number of sides can't have another.
+func validateInspection(inspection *models.Inspection, sides int) error {
+	if inspection.Side < 1 || (sides > 0 && inspection.Side > sides) {
+		return fmt.Errorf("%w: side %d of a LaserDisc with %d sides", ErrInvalidInspection, inspection.Side, sides)
+	}
+	if _, err := time.Parse("2006-01-02", inspection.InspectedDate); err != nil {
+		return fmt.Errorf("%w: inspected date %q, use YYYY-MM-DD", ErrInvalidInspection, inspection.InspectedDate)
+	}
+	if !models.ValidDefect(inspection.Defect) {
+		return fmt.Errorf("%w: defect %q, use one of %s", ErrInvalidInspection, inspection.Defect, strings.Join(models.Defects, ", "))
+	}
+	if inspection.Timecode != "" && !timecode.MatchString(inspection.Timecode) {
+		return fmt.Errorf("%w: timecode %q, use H:MM:SS", ErrInvalidInspection, inspection.Timecode)
+	}
+	if inspection.Chapter < 0 {
+		return fmt.Errorf("%w: negative chapter", ErrInvalidInspection)
+	}
+
+	if inspection.Defect == models.DefectNone {
+		if inspection.Severity != 0 {
+			return fmt.Errorf("%w: a clean inspection has no severity", ErrInvalidInspection)
+		}
+		return nil
+	}
+	if inspection.Severity < models.MinSeverity || inspection.Severity > models.MaxSeverity {
+		return fmt.Errorf("%w: severity %d, use %d to %d", ErrInvalidInspection, inspection.Severity, models.MinSeverity, models.MaxSeverity)
+	}
+	return nil
+}
+
+// latestSideConditions is the condition of each inspected side at its latest
+// inspection: the worst severity and the defects found that day. Sides
+// that last played cleanly are left out.
+const latestSideConditions = `
+SELECT i.laser_disc_id, i.copy_id, i.side, i.inspected_date,
+	MAX(i.severity) AS severity, GROUP_CONCAT(DISTINCT i.defect) AS defects
+FROM inspections i
+JOIN (
+	SELECT copy_id, side, MAX(inspected_date) AS inspected_date
+	FROM inspections GROUP BY copy_id, side
+) latest ON latest.copy_id = i.copy_id AND latest.side = i.side AND latest.inspected_date = i.inspected_date
+WHERE i.defect != 'none'
+GROUP BY i.copy_id, i.side`
+
+// WorstConditions returns up to limit copies with the worst defects found at
+// their latest inspections, worst first. Each copy is listed once, with its
+// worst side.
+func (s *Service) WorstConditions(limit int) ([]models.SideCondition, error) {
+	var rows []struct {
+		LaserDiscID   uint
+		Title         string
+		CopyID        uint
+		Side          int
+		InspectedDate string
+		Severity      int
+		Defects       string
+	}
+	err := s.db.Raw(`
+WITH sides AS (`+latestSideConditions+`
+), ranked AS (
+	SELECT sides.*, ROW_NUMBER() OVER (PARTITION BY copy_id ORDER BY severity DESC, side) AS n
+	FROM sides
+)
+SELECT ranked.*, laserdiscs.title
+FROM ranked JOIN laserdiscs ON laserdiscs.id = ranked.laser_disc_id
+WHERE n = 1
+ORDER BY severity DESC, inspected_date DESC, copy_id
+LIMIT ?`, limit).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := make([]models.SideCondition, len(rows))
+	for i, row := range rows {
+		conditions[i] = models.SideCondition{
+			LaserDiscID:   row.LaserDiscID,
+			Title:         row.Title,
+			CopyID:        row.CopyID,
+			Side:          row.Side,
+			InspectedDate: row.InspectedDate,
+			Severity:      row.Severity,
+			Defects:       strings.Split(row.Defects, ","),
+		}
+	}
+	return conditions, nil
+}
+
+// RotProgressions follows the rot on every side where some was found, with
+// a reading for each day the side was inspected, including clean ones.
+// Only sides with the given trend are returned, unless it's empty.
+func (s *Service) RotProgressions(trend string) ([]models.RotProgression, error) {
+	var rows []struct {
+		LaserDiscID   uint
+		Title         string
+		CopyID        uint
+		Side          int
+		InspectedDate string
+		Severity      int
+	}
+	err := s.db.Raw(`
+SELECT i.laser_disc_id, laserdiscs.title, i.copy_id, i.side, i.inspected_date,
+	MAX(CASE WHEN i.defect = 'rot' THEN i.severity ELSE 0 END) AS severity
+FROM inspections i JOIN laserdiscs ON laserdiscs.id = i.laser_disc_id
+WHERE EXISTS (
+	SELECT 1 FROM inspections rot
+	WHERE rot.copy_id = i.copy_id AND rot.side = i.side AND rot.defect = 'rot'
+)
+GROUP BY i.copy_id, i.side, i.inspected_date
+ORDER BY laserdiscs.title, i.copy_id, i.side, i.inspected_date`).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	progressions := []models.RotProgression{}
+	for _, row := range rows {
+		last := len(progressions) - 1
+		if last < 0 || progressions[last].CopyID != row.CopyID || progressions[last].Side != row.Side {
+			progressions = append(progressions, models.RotProgression{
+				LaserDiscID: row.LaserDiscID,
+				Title:       row.Title,
+				CopyID:      row.CopyID,
+				Side:        row.Side,
+			})
+			last++
+		}
+		progressions[last].Readings = append(progressions[last].Readings, models.RotReading{
+			InspectedDate: row.InspectedDate,
+			Severity:      row.Severity,
+		})
+	}
+
+	filtered := progressions[:0]
+	for _, progression := range progressions {
+		progression.Trend = rotTrend(progression.Readings)
+		if trend == "" || progression.Trend == trend {
+			filtered = append(filtered, progression)
+		}
+	}
+	return filtered, nil
+}
+
+// rotTrend compares the latest rot reading of a side with its first
+func rotTrend(readings []models.RotReading) string {
+	if len(readings) < 2 {
+		return models.RotTrendUnknown
+	}
+	first, latest := readings[0].Severity, readings[len(readings)-1].Severity
+	switch {
+	case latest > first:
+		return models.RotTrendWorsening
+	case latest < first:
+		return models.RotTrendImproving
+	default:
+		return models.RotTrendStable
+	}
+}
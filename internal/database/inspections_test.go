@@ -0,0 +1,156 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// inspect logs an inspection for a test, failing it if that's refused
+func inspect(t *testing.T, service *Service, copy models.Copy, side int, date, defect string, severity int) models.Inspection {
+	inspection, err := service.AddInspection(copy.LaserDiscID, copy.ID, &models.CreateInspectionRequest{
+		Side:          side,
+		InspectedDate: date,
+		Defect:        defect,
+		Severity:      severity,
+	})
+	require.NoError(t, err)
+	return *inspection
+}
+
+func TestService_Inspections(t *testing.T) {
+	service := setupTestDB(t)
+	laserdisc, err := service.CreateLaserDisc(createTestLaserDisc())
+	require.NoError(t, err)
+	copy := laserdisc.Copies[0]
+
+	inspection, err := service.AddInspection(laserdisc.ID, copy.ID, &models.CreateInspectionRequest{
+		Side:     2,
+		Player:   " Pioneer CLD-D704 ",
+		Defect:   "Skip",
+		Timecode: "0:42:10",
+		Chapter:  14,
+		Severity: 3,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.DefectSkip, inspection.Defect)
+	assert.Equal(t, "Pioneer CLD-D704", inspection.Player)
+	assert.Len(t, inspection.InspectedDate, len("2006-01-02"), "dated today")
+	inspect(t, service, copy, 1, "2020-01-01", models.DefectNone, 0)
+
+	inspections, err := service.GetInspections(laserdisc.ID, copy.ID)
+	require.NoError(t, err)
+	require.Len(t, inspections, 2)
+	assert.Equal(t, "2020-01-01", inspections[0].InspectedDate, "oldest first")
+
+	invalid := []models.CreateInspectionRequest{
+		{Side: 3, Defect: models.DefectRot, Severity: 1},
+		{Side: 1, Defect: "scratch", Severity: 1},
+		{Side: 1, Defect: models.DefectRot},
+		{Side: 1, Defect: models.DefectRot, Severity: 6},
+		{Side: 1, Defect: models.DefectNone, Severity: 2},
+		{Side: 1, Defect: models.DefectHum, Severity: 1, InspectedDate: "yesterday"},
+		{Side: 1, Defect: models.DefectHum, Severity: 1, Timecode: "42"},
+	}
+	for _, req := range invalid {
+		_, err := service.AddInspection(laserdisc.ID, copy.ID, &req)
+		assert.ErrorIs(t, err, ErrInvalidInspection, "%+v", req)
+	}
+
+	_, err = service.GetInspections(laserdisc.ID+1, copy.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	require.NoError(t, service.DeleteInspection(laserdisc.ID, copy.ID, inspection.ID))
+	assert.ErrorIs(t, service.DeleteInspection(laserdisc.ID, copy.ID, inspection.ID), gorm.ErrRecordNotFound)
+
+	// Deleting a copy deletes its inspections
+	require.NoError(t, service.DeleteCopy(laserdisc.ID, copy.ID))
+	var count int64
+	require.NoError(t, service.db.Model(&models.Inspection{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestService_WorstConditions(t *testing.T) {
+	service := setupTestDB(t)
+	alien, err := service.CreateLaserDisc(createTestLaserDisc())
+	require.NoError(t, err)
+	req := createTestLaserDisc()
+	req.UPC = "111111111117"
+	req.Title = "Jaws"
+	jaws, err := service.CreateLaserDisc(req)
+	require.NoError(t, err)
+	player, err := service.AddCopy(jaws.ID, &models.CopyRequest{})
+	require.NoError(t, err)
+
+	// Alien's side 1 had bad rot, but was cleaned and now plays well. Its
+	// side 2 hums and skips.
+	inspect(t, service, alien.Copies[0], 1, "2023-01-01", models.DefectRot, 5)
+	inspect(t, service, alien.Copies[0], 1, "2024-01-01", models.DefectNone, 0)
+	inspect(t, service, alien.Copies[0], 2, "2024-01-01", models.DefectHum, 1)
+	inspect(t, service, alien.Copies[0], 2, "2024-01-01", models.DefectSkip, 2)
+	// One copy of Jaws is worse on both sides, the other was clean
+	inspect(t, service, jaws.Copies[0], 1, "2024-02-01", models.DefectCrosstalk, 3)
+	inspect(t, service, jaws.Copies[0], 2, "2024-02-01", models.DefectRot, 4)
+	inspect(t, service, *player, 1, "2024-02-01", models.DefectNone, 0)
+
+	conditions, err := service.WorstConditions(10)
+	require.NoError(t, err)
+	require.Len(t, conditions, 2, "each copy with defects once")
+
+	assert.Equal(t, "Jaws", conditions[0].Title)
+	assert.Equal(t, jaws.Copies[0].ID, conditions[0].CopyID)
+	assert.Equal(t, 2, conditions[0].Side)
+	assert.Equal(t, 4, conditions[0].Severity)
+	assert.Equal(t, []string{models.DefectRot}, conditions[0].Defects)
+
+	assert.Equal(t, alien.Copies[0].ID, conditions[1].CopyID)
+	assert.Equal(t, 2, conditions[1].Side)
+	assert.Equal(t, 2, conditions[1].Severity)
+	assert.ElementsMatch(t, []string{models.DefectHum, models.DefectSkip}, conditions[1].Defects)
+
+	conditions, err = service.WorstConditions(1)
+	require.NoError(t, err)
+	assert.Len(t, conditions, 1)
+}
+
+func TestService_RotProgressions(t *testing.T) {
+	service := setupTestDB(t)
+	laserdisc, err := service.CreateLaserDisc(createTestLaserDisc())
+	require.NoError(t, err)
+	copy := laserdisc.Copies[0]
+
+	// Side 1 was clean, then developed rot that spread
+	inspect(t, service, copy, 1, "2022-06-01", models.DefectNone, 0)
+	inspect(t, service, copy, 1, "2023-06-01", models.DefectRot, 1)
+	inspect(t, service, copy, 1, "2024-06-01", models.DefectRot, 3)
+	inspect(t, service, copy, 1, "2024-06-01", models.DefectSkip, 5)
+	// Side 2 has had the same speckles twice
+	inspect(t, service, copy, 2, "2023-06-01", models.DefectRot, 2)
+	inspect(t, service, copy, 2, "2024-06-01", models.DefectRot, 2)
+
+	progressions, err := service.RotProgressions("")
+	require.NoError(t, err)
+	require.Len(t, progressions, 2)
+
+	assert.Equal(t, 1, progressions[0].Side)
+	assert.Equal(t, models.RotTrendWorsening, progressions[0].Trend)
+	assert.Equal(t, []models.RotReading{
+		{InspectedDate: "2022-06-01", Severity: 0},
+		{InspectedDate: "2023-06-01", Severity: 1},
+		{InspectedDate: "2024-06-01", Severity: 3},
+	}, progressions[0].Readings, "other defects don't count")
+	assert.Equal(t, models.RotTrendStable, progressions[1].Trend)
+
+	progressions, err = service.RotProgressions(models.RotTrendWorsening)
+	require.NoError(t, err)
+	require.Len(t, progressions, 1)
+	assert.Equal(t, 1, progressions[0].Side)
+	assert.Equal(t, "Test Movie", progressions[0].Title)
+
+	assert.Equal(t, models.RotTrendUnknown, rotTrend([]models.RotReading{{Severity: 2}}))
+	assert.Equal(t, models.RotTrendImproving, rotTrend([]models.RotReading{{Severity: 2}, {Severity: 1}}))
+}
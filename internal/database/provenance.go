@@ -0,0 +1,177 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// ManualFields returns the scraped fields of a LaserDisc the user has edited by hand
+func (s *Service) ManualFields(laserdiscID uint) (map[string]bool, error) {
+	var fields []string
+	result := s.db.Model(&models.FieldProvenance{}).
+		Where("laser_disc_id = ? AND source = ?", laserdiscID, models.FieldSourceManual).
+		Pluck("field", &fields)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	manual := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		manual[field] = true
+	}
+	return manual, nil
+}
+
+// provenanceFor loads the field provenance of some LaserDiscs, keyed by
+// LaserDisc ID and then field
+func (s *Service) provenanceFor(ids []uint) (map[uint]map[string]models.FieldProvenance, error) {
+	byID := make(map[uint]map[string]models.FieldProvenance)
+	if len(ids) == 0 {
+		return byID, nil
+	}
+
+	var rows []models.FieldProvenance
+	if err := s.db.Where("laser_disc_id IN ?", ids).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if byID[row.LaserDiscID] == nil {
+			byID[row.LaserDiscID] = make(map[string]models.FieldProvenance)
+		}
+		byID[row.LaserDiscID][row.Field] = row
+	}
+	return byID, nil
+}
+
+// AttachProvenance fills in the Provenance of each LaserDisc
+func (s *Service) AttachProvenance(laserdiscs []models.LaserDisc) error {
+	ids := make([]uint, len(laserdiscs))
+	for i := range laserdiscs {
+		ids[i] = laserdiscs[i].ID
+	}
+
+	byID, err := s.provenanceFor(ids)
+	if err != nil {
+		return err
+	}
+	for i := range laserdiscs {
+		laserdiscs[i].Provenance = byID[laserdiscs[i].ID]
+	}
+	return nil
+}
+
+// attachProvenance fills in the Provenance of one LaserDisc
+func (s *Service) attachProvenance(laserdisc *models.LaserDisc) error {
+	byID, err := s.provenanceFor([]uint{laserdisc.ID})
+	if err != nil {
+		return err
+	}
+	laserdisc.Provenance = byID[laserdisc.ID]
+	return nil
+}
+
+// scrapedValues returns a LaserDisc's scraped fields, keyed by JSON name
+func scrapedValues(laserdisc *models.LaserDisc) (map[string]interface{}, error) {
+	encoded, err := json.Marshal(laserdisc)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(encoded, &values); err != nil {
+		return nil, err
+	}
+	for field := range values {
+		if !models.IsScrapedField(field) {
+			delete(values, field)
+		}
+	}
+	return values, nil
+}
+
+// createdFieldSources groups the filled in scraped fields of a new LaserDisc
+// by source. Fields missing from sources were entered by hand.
+func createdFieldSources(laserdisc *models.LaserDisc, sources map[string]string) (map[string][]string, error) {
+	values, err := scrapedValues(laserdisc)
+	if err != nil {
+		return nil, err
+	}
+
+	bySource := make(map[string][]string)
+	for field, value := range values {
+		if value == nil || value == "" || value == float64(0) {
+			continue
+		}
+		source := sources[field]
+		if source == "" {
+			source = models.FieldSourceManual
+		}
+		bySource[source] = append(bySource[source], field)
+	}
+	return bySource, nil
+}
+
+// changedScrapedFields lists the scraped fields an update actually changes.
+// Edit forms send every field, so unchanged values aren't counted as edits.
+func changedScrapedFields(before *models.LaserDisc, updates map[string]interface{}) ([]string, error) {
+	current, err := scrapedValues(before)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for field, value := range updates {
+		if models.IsScrapedField(field) && fmt.Sprint(current[field]) != fmt.Sprint(value) {
+			changed = append(changed, field)
+		}
+	}
+	return changed, nil
+}
+
+// setFieldSources records where the current values of some of a LaserDisc's fields came from
+func setFieldSources(tx *gorm.DB, laserdiscID uint, fields []string, source string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	rows := make([]models.FieldProvenance, len(fields))
+	for i, field := range fields {
+		rows[i] = models.FieldProvenance{LaserDiscID: laserdiscID, Field: field, Source: source, UpdatedAt: now}
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "laser_disc_id"}, {Name: "field"}},
+		DoUpdates: clause.AssignmentColumns([]string{"source", "updated_at"}),
+	}).Create(&rows).Error
+}
+
+// setScrapedFields writes JSON encoded values to a LaserDisc's fields, keyed
+// by JSON name, and saves just those fields
+func setScrapedFields(tx *gorm.DB, laserdisc *models.LaserDisc, values map[string]json.RawMessage) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	columns := []string{"updated_date"}
+	for field := range values {
+		if !models.IsScrapedField(field) {
+			return fmt.Errorf("%s is not a metadata field", field)
+		}
+		columns = append(columns, field)
+	}
+
+	// Decoding through JSON converts each value to its field's type
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(encoded, laserdisc); err != nil {
+		return err
+	}
+	return tx.Model(laserdisc).Select(columns).Updates(laserdisc).Error
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+func TestService_CreateLaserDiscRecordsSources(t *testing.T) {
+	service := setupTestDB(t)
+
+	req := createTestLaserDisc()
+	req.Runtime = 0
+	req.Sources = map[string]string{"title": "lddb", "year": "lddb", "genre": "dataset", "runtime": "lddb", "upc": "lddb"}
+	created, err := service.CreateLaserDisc(req)
+	require.NoError(t, err)
+
+	sources := make(map[string]string)
+	for field, provenance := range created.Provenance {
+		sources[field] = provenance.Source
+		assert.False(t, provenance.UpdatedAt.IsZero(), field)
+	}
+	assert.Equal(t, map[string]string{
+		"title":    "lddb",
+		"year":     "lddb",
+		"genre":    "dataset",
+		"director": models.FieldSourceManual, // typed in rather than looked up
+		"format":   models.FieldSourceManual,
+		"sides":    models.FieldSourceManual,
+
+		"cover_image_url": models.FieldSourceManual,
+	}, sources, "empty fields and non-metadata fields have no provenance")
+
+	laserdisc, err := service.GetLaserDiscByID(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created.Provenance, laserdisc.Provenance)
+}
+
+func TestService_ProvenanceFollowsEditsAndRefreshes(t *testing.T) {
+	service := setupTestDB(t)
+
+	req := createTestLaserDisc()
+	req.Sources = map[string]string{"title": "lddb", "genre": "lddb", "director": "lddb"}
+	created, err := service.CreateLaserDisc(req)
+	require.NoError(t, err)
+
+	genre := "Horror"
+	updated, err := service.UpdateLaserDisc(created.ID, &models.UpdateLaserDiscRequest{Genre: &genre})
+	require.NoError(t, err)
+	assert.Equal(t, models.FieldSourceManual, updated.Provenance["genre"].Source)
+	assert.Equal(t, "lddb", updated.Provenance["title"].Source)
+
+	require.NoError(t, service.ApplyMetadataChanges(created.ID, []models.MetadataChange{
+		{Field: "director", OldValue: json.RawMessage(`"Test Director"`), NewValue: json.RawMessage(`"Ridley Scott"`), Source: "dataset", Status: models.ChangeApplied},
+	}))
+
+	laserdiscs, err := service.GetAllLaserDiscs()
+	require.NoError(t, err)
+	require.NoError(t, service.AttachProvenance(laserdiscs))
+	require.Len(t, laserdiscs, 1)
+	assert.Equal(t, "dataset", laserdiscs[0].Provenance["director"].Source)
+	assert.Equal(t, models.FieldSourceManual, laserdiscs[0].Provenance["genre"].Source)
+}
@@ -0,0 +1,240 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// ErrInvalidQuery is returned for a LaserDiscQuery that can't be run
+var ErrInvalidQuery = errors.New("invalid query")
+
+// SortFields are the columns a LaserDiscQuery can sort by. A LaserDisc's
+// tags sort in order of their names.
+var SortFields = []string{"title", "year", "director", "runtime", "added_date", "updated_date", "tags"}
+
+// CustomSortPrefix sorts by a custom field, e.g. custom:Region. LaserDiscs
+// without a value come last.
+const CustomSortPrefix = "custom:"
+
+// tagsSortSQL is a LaserDisc's tag names in order, NULL when it has none
+const tagsSortSQL = `(SELECT group_concat(tags.name, ', ' ORDER BY tags.name)
+	FROM laserdisc_tags JOIN tags ON tags.id = laserdisc_tags.tag_id
+	WHERE laserdisc_tags.laser_disc_id = laserdiscs.id) COLLATE NOCASE`
+
+// LaserDiscQuery selects, orders and pages LaserDiscs in SQL. Zero values
+// don't filter, and ranges include their bounds.
+type LaserDiscQuery struct {
+	Search     string // see parseSearch
+	Fuzzy      bool   // match the search loosely against titles, see fuzzy.Score
+	Watched    *bool
+	Genre      string // ignoring case
+	Format     string // ignoring case, e.g. CLV
+	YearMin    int
+	YearMax    int
+	RuntimeMin int // minutes
+	RuntimeMax int
+	Sides      int
+	AddedFrom  *time.Time
+	AddedTo    *time.Time
+	HasNotes   *bool
+	Tags       []string // tagged with all of these, ignoring case
+	Custom     []CustomFilter
+	Sort       string // one of SortFields or CustomSortPrefix and a field, by relevance or title when empty
+	Desc       bool
+	Limit      int // no limit when zero
+	Offset     int
+
+	sortField *models.CustomField // looked up by resolveCustomFields
+}
+
+// CustomFilter selects LaserDiscs by a custom field. Value matches exactly,
+// ignoring case, and Min and Max bound number and date fields. Without any
+// of them it matches LaserDiscs that have a value.
+type CustomFilter struct {
+	Field string // the field's name, ignoring case
+	Value string
+	Min   string
+	Max   string
+
+	field *models.CustomField // looked up by resolveCustomFields
+}
+
+// Validate reports every problem with a query in one ErrInvalidQuery
+func (q LaserDiscQuery) Validate() error {
+	var problems []string
+	for _, value := range []struct {
+		name  string
+		value int
+	}{
+		{"year_min", q.YearMin}, {"year_max", q.YearMax},
+		{"runtime_min", q.RuntimeMin}, {"runtime_max", q.RuntimeMax},
+		{"sides", q.Sides}, {"limit", q.Limit}, {"offset", q.Offset},
+	} {
+		if value.value < 0 {
+			problems = append(problems, value.name+" can't be negative")
+		}
+	}
+	if q.YearMax != 0 && q.YearMin > q.YearMax {
+		problems = append(problems, "year_min is after year_max")
+	}
+	if q.RuntimeMax != 0 && q.RuntimeMin > q.RuntimeMax {
+		problems = append(problems, "runtime_min is longer than runtime_max")
+	}
+	if q.AddedFrom != nil && q.AddedTo != nil && q.AddedFrom.After(*q.AddedTo) {
+		problems = append(problems, "added_from is after added_to")
+	}
+	if q.Sort != "" && !validSort(q.Sort) {
+		problems = append(problems, fmt.Sprintf("can't sort by %s, use one of %s or %s<field>", q.Sort, strings.Join(SortFields, ", "), CustomSortPrefix))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidQuery, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validSort reports whether field is one of SortFields, or names a custom
+// field
+func validSort(field string) bool {
+	if name, ok := strings.CutPrefix(field, CustomSortPrefix); ok {
+		return name != ""
+	}
+	for _, f := range SortFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// sqliteTime formats a time the way SQLite's datetime() returns it, so
+// stored times are compared in UTC whatever offset they were saved with
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+// filter applies a query's conditions, shared by the page and its count. Its
+// custom fields must have been resolved, see resolveCustomFields.
+func (s *Service) filter(q LaserDiscQuery, terms []searchTerm) *gorm.DB {
+	db := s.search(s.db.Model(&models.LaserDisc{}), terms)
+	if q.Watched != nil {
+		db = db.Where("watched = ?", *q.Watched)
+	}
+	if q.Genre != "" {
+		db = db.Where("genre = ? COLLATE NOCASE", q.Genre)
+	}
+	if q.Format != "" {
+		db = db.Where("format = ? COLLATE NOCASE", q.Format)
+	}
+	if q.YearMin != 0 {
+		db = db.Where("year >= ?", q.YearMin)
+	}
+	if q.YearMax != 0 {
+		db = db.Where("year <= ?", q.YearMax)
+	}
+	if q.RuntimeMin != 0 {
+		db = db.Where("runtime >= ?", q.RuntimeMin)
+	}
+	if q.RuntimeMax != 0 {
+		db = db.Where("runtime <= ?", q.RuntimeMax)
+	}
+	if q.Sides != 0 {
+		db = db.Where("sides = ?", q.Sides)
+	}
+	if q.AddedFrom != nil {
+		db = db.Where("datetime(added_date) >= ?", sqliteTime(*q.AddedFrom))
+	}
+	if q.AddedTo != nil {
+		db = db.Where("datetime(added_date) <= ?", sqliteTime(*q.AddedTo))
+	}
+	if q.HasNotes != nil {
+		if *q.HasNotes {
+			db = db.Where("notes IS NOT NULL AND notes != ''")
+		} else {
+			db = db.Where("(notes IS NULL OR notes = '')")
+		}
+	}
+	for _, tag := range q.Tags {
+		db = db.Where(`EXISTS (SELECT 1 FROM laserdisc_tags JOIN tags ON tags.id = laserdisc_tags.tag_id
+			WHERE laserdisc_tags.laser_disc_id = laserdiscs.id AND tags.name = ?)`, strings.TrimSpace(tag))
+	}
+	for _, custom := range q.Custom {
+		condition, args := custom.condition()
+		db = db.Where(condition, args...)
+	}
+	return db
+}
+
+// order returns a query's ORDER BY clause. Ties are broken by ID so pages
+// don't overlap. Full-text matches are ranked best first unless sorted.
+func (q LaserDiscQuery) order(ranked bool) string {
+	if ranked && q.Sort == "" {
+		return "matches.match_rank, laserdiscs.id"
+	}
+	direction := "ASC"
+	if q.Desc {
+		direction = "DESC"
+	}
+
+	// Tags and custom fields can be missing, which comes last either way
+	missing := ""
+	switch {
+	case q.Sort == "tags":
+		missing = tagsSortSQL
+	case q.sortField != nil:
+		missing = customSortSQL(q.sortField)
+	}
+	if missing != "" {
+		return fmt.Sprintf("%[1]s IS NULL, %[1]s %[2]s, id %[2]s", missing, direction)
+	}
+
+	column := q.Sort
+	if column == "" {
+		column = "title"
+	}
+	return fmt.Sprintf("%s %s, id %s", column, direction, direction)
+}
+
+// QueryLaserDiscs returns a page of the LaserDiscs matching a query, and how
+// many match in total
+func (s *Service) QueryLaserDiscs(q LaserDiscQuery) ([]models.LaserDisc, int64, error) {
+	if err := q.Validate(); err != nil {
+		return nil, 0, err
+	}
+	if err := s.resolveCustomFields(&q); err != nil {
+		return nil, 0, err
+	}
+
+	if q.Fuzzy && searchText(q.Search) != "" {
+		return s.fuzzyQuery(q)
+	}
+
+	terms := parseSearch(q.Search)
+	var total int64
+	if err := s.filter(q, terms).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	laserdiscs := []models.LaserDisc{}
+	if total == 0 || int64(q.Offset) >= total {
+		return laserdiscs, total, nil
+	}
+
+	page := s.filter(q, terms).Order(q.order(s.fullText && len(terms) > 0)).Offset(q.Offset)
+	if q.Limit > 0 {
+		page = page.Limit(q.Limit)
+	}
+	if err := page.Find(&laserdiscs).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := s.attachMatches(laserdiscs, terms); err != nil {
+		return nil, 0, err
+	}
+	return laserdiscs, total, nil
+}
@@ -0,0 +1,237 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// seedLaserDiscs inserts n LaserDiscs with varied titles, years and genres.
+// Every third one is watched.
+func seedLaserDiscs(tb testing.TB, service *Service, n int) {
+	genres := []string{"Action", "Comedy", "Drama", "Horror", "Sci-Fi"}
+	laserdiscs := make([]models.LaserDisc, n)
+	for i := range laserdiscs {
+		laserdiscs[i] = models.LaserDisc{
+			UPC:      fmt.Sprintf("%014d", i+1),
+			Title:    fmt.Sprintf("Title %05d", (i*7919)%n),
+			Year:     1978 + i%25,
+			Director: fmt.Sprintf("Director %d", i%500),
+			Genre:    genres[i%len(genres)],
+			Watched:  i%3 == 0,
+		}
+	}
+	require.NoError(tb, service.db.CreateInBatches(laserdiscs, 500).Error)
+}
+
+func titles(laserdiscs []models.LaserDisc) []string {
+	result := make([]string, len(laserdiscs))
+	for i, laserdisc := range laserdiscs {
+		result[i] = laserdisc.Title
+	}
+	return result
+}
+
+func TestService_QueryLaserDiscs(t *testing.T) {
+	service := setupTestDB(t)
+	seedLaserDiscs(t, service, 10)
+
+	page, total, err := service.QueryLaserDiscs(LaserDiscQuery{Limit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), total)
+	assert.Equal(t, []string{"Title 00000", "Title 00001", "Title 00002"}, titles(page))
+
+	page, total, err = service.QueryLaserDiscs(LaserDiscQuery{Limit: 3, Offset: 9})
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), total)
+	assert.Equal(t, []string{"Title 00009"}, titles(page))
+
+	page, total, err = service.QueryLaserDiscs(LaserDiscQuery{Limit: 3, Offset: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), total, "the total is counted even past the last page")
+	assert.Empty(t, page)
+	assert.NotNil(t, page)
+
+	watched := true
+	page, total, err = service.QueryLaserDiscs(LaserDiscQuery{Watched: &watched, Sort: "year", Desc: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), total)
+	require.Len(t, page, 4)
+	for i, laserdisc := range page {
+		assert.True(t, laserdisc.Watched)
+		if i > 0 {
+			assert.LessOrEqual(t, laserdisc.Year, page[i-1].Year)
+		}
+	}
+
+	page, total, err = service.QueryLaserDiscs(LaserDiscQuery{Search: "horror"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	for _, laserdisc := range page {
+		assert.Equal(t, "Horror", laserdisc.Genre)
+	}
+}
+
+func TestService_QueryLaserDiscsStablePages(t *testing.T) {
+	service := setupTestDB(t)
+	seedLaserDiscs(t, service, 30)
+
+	// Many discs share a year, so paging relies on the ID tie-break
+	seen := make(map[uint]bool)
+	for offset := 0; offset < 30; offset += 7 {
+		page, _, err := service.QueryLaserDiscs(LaserDiscQuery{Sort: "year", Limit: 7, Offset: offset})
+		require.NoError(t, err)
+		for _, laserdisc := range page {
+			assert.False(t, seen[laserdisc.ID], "LaserDisc %d on two pages", laserdisc.ID)
+			seen[laserdisc.ID] = true
+		}
+	}
+	assert.Len(t, seen, 30)
+}
+
+func TestService_QueryLaserDiscsFilters(t *testing.T) {
+	service := setupTestDB(t)
+
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 12, 0, 0, 0, time.UTC) }
+	laserdiscs := []models.LaserDisc{
+		{Title: "Alien", Year: 1979, Genre: "Sci-Fi", Format: "CLV", Runtime: 117, Sides: 2, AddedDate: day(1), Watched: true},
+		{Title: "Aliens", Year: 1986, Genre: "Sci-Fi", Format: "CAV", Runtime: 137, Sides: 4, AddedDate: day(2), Notes: "Special edition"},
+		{Title: "Airplane!", Year: 1980, Genre: "Comedy", Format: "CLV", Runtime: 88, Sides: 1, AddedDate: day(3)},
+		{Title: "Brazil", Year: 1985, Genre: "sci-fi", Format: "clv", Runtime: 142, Sides: 3, AddedDate: day(4), Notes: "Criterion"},
+	}
+	require.NoError(t, service.db.Create(&laserdiscs).Error)
+
+	yes, no := true, false
+	from, to := day(2), day(3)
+	tests := []struct {
+		name  string
+		query LaserDiscQuery
+		want  []string
+	}{
+		{"genre ignores case", LaserDiscQuery{Genre: "SCI-FI"}, []string{"Alien", "Aliens", "Brazil"}},
+		{"format", LaserDiscQuery{Format: "CLV"}, []string{"Airplane!", "Alien", "Brazil"}},
+		{"year range", LaserDiscQuery{YearMin: 1980, YearMax: 1985}, []string{"Airplane!", "Brazil"}},
+		{"year from", LaserDiscQuery{YearMin: 1985}, []string{"Aliens", "Brazil"}},
+		{"runtime range", LaserDiscQuery{RuntimeMin: 100, RuntimeMax: 140}, []string{"Alien", "Aliens"}},
+		{"sides", LaserDiscQuery{Sides: 3}, []string{"Brazil"}},
+		{"added range", LaserDiscQuery{AddedFrom: &from, AddedTo: &to}, []string{"Airplane!", "Aliens"}},
+		{"has notes", LaserDiscQuery{HasNotes: &yes}, []string{"Aliens", "Brazil"}},
+		{"no notes", LaserDiscQuery{HasNotes: &no}, []string{"Airplane!", "Alien"}},
+		{"search within a filter", LaserDiscQuery{Search: "Alien", Watched: &no}, []string{"Aliens"}},
+		{"combined", LaserDiscQuery{Genre: "Sci-Fi", Format: "CLV", Watched: &no}, []string{"Brazil"}},
+		{"sort by runtime", LaserDiscQuery{Sort: "runtime", Desc: true}, []string{"Brazil", "Aliens", "Alien", "Airplane!"}},
+		{"sort by added", LaserDiscQuery{Sort: "added_date"}, []string{"Alien", "Aliens", "Airplane!", "Brazil"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, total, err := service.QueryLaserDiscs(tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, titles(page))
+			assert.Equal(t, int64(len(tt.want)), total)
+		})
+	}
+}
+
+func TestService_QueryLaserDiscsInvalid(t *testing.T) {
+	service := setupTestDB(t)
+
+	_, _, err := service.QueryLaserDiscs(LaserDiscQuery{Sort: "notes"})
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+	_, _, err = service.QueryLaserDiscs(LaserDiscQuery{Sort: "title; DROP TABLE laserdiscs"})
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+	_, _, err = service.QueryLaserDiscs(LaserDiscQuery{Offset: -1})
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+
+	// Every problem is reported at once
+	from, to := time.Now(), time.Now().Add(-time.Hour)
+	err = LaserDiscQuery{YearMin: 1990, YearMax: 1980, RuntimeMin: -5, AddedFrom: &from, AddedTo: &to}.Validate()
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+	assert.ErrorContains(t, err, "year_min is after year_max")
+	assert.ErrorContains(t, err, "runtime_min can't be negative")
+	assert.ErrorContains(t, err, "added_from is after added_to")
+
+	assert.NoError(t, LaserDiscQuery{YearMin: 1980, Sort: "updated_date"}.Validate())
+}
+
+// setupBenchmarkDB seeds a database the size of a very large collection
+func setupBenchmarkDB(b *testing.B) *Service {
+	service := setupTestDB(b)
+	service.db = service.db.Session(&gorm.Session{Logger: logger.Discard})
+	seedLaserDiscs(b, service, 50000)
+	return service
+}
+
+// pageOf slices a page out of a loaded collection, as GetCollection used to
+func pageOf(laserdiscs []models.LaserDisc, offset, limit int) []models.LaserDisc {
+	if offset >= len(laserdiscs) {
+		return nil
+	}
+	end := offset + limit
+	if end > len(laserdiscs) {
+		end = len(laserdiscs)
+	}
+	return laserdiscs[offset:end]
+}
+
+// BenchmarkCollectionPage compares loading the whole table and slicing a
+// page in Go with paging in SQL, at 50,000 LaserDiscs:
+//
+//	go test ./internal/database -run '^$' -bench CollectionPage -benchmem
+func BenchmarkCollectionPage(b *testing.B) {
+	service := setupBenchmarkDB(b)
+
+	b.Run("LoadAll", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			laserdiscs, err := service.GetAllLaserDiscs()
+			require.NoError(b, err)
+			require.Len(b, pageOf(laserdiscs, 25000, 50), 50)
+		}
+	})
+	b.Run("SQL", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			page, _, err := service.QueryLaserDiscs(LaserDiscQuery{Limit: 50, Offset: 25000})
+			require.NoError(b, err)
+			require.Len(b, page, 50)
+		}
+	})
+	b.Run("SearchLoadAll", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			laserdiscs, err := service.SearchLaserDiscs("Director 42")
+			require.NoError(b, err)
+			pageOf(laserdiscs, 0, 50)
+		}
+	})
+	b.Run("SearchSQL", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _, err := service.QueryLaserDiscs(LaserDiscQuery{Search: "Director 42", Limit: 50})
+			require.NoError(b, err)
+		}
+	})
+}
+
+// BenchmarkRandomUnwatched compares picking from every unwatched LaserDisc
+// loaded into memory with counting and skipping in SQL
+func BenchmarkRandomUnwatched(b *testing.B) {
+	service := setupBenchmarkDB(b)
+
+	b.Run("LoadAll", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var unwatched []models.LaserDisc
+			require.NoError(b, service.db.Where("watched = ?", false).Find(&unwatched).Error)
+			require.NotEmpty(b, unwatched)
+		}
+	})
+	b.Run("SQL", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, err := service.GetRandomUnwatched()
+			require.NoError(b, err)
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// Ways of weighting random picks
+const (
+	RandomUniform     = "uniform"      // every LaserDisc equally likely
+	RandomOldestAdded = "oldest_added" // favour those added longest ago
+	RandomTopGenres   = "top_genres"   // favour genres that were rated highly
+)
+
+// RandomModes lists the valid ways of weighting random picks
+var RandomModes = []string{RandomUniform, RandomOldestAdded, RandomTopGenres}
+
+// MaxRandomPicks is the most LaserDiscs one draw can pick
+const MaxRandomPicks = 10
+
+// MaxExcludeDays is the longest a pick can be left out of other draws. Picks
+// older than this are forgotten.
+const MaxExcludeDays = 365
+
+// ErrNoRandomPick is returned when no LaserDisc matches a RandomQuery
+var ErrNoRandomPick = errors.New("no laserdiscs to pick from")
+
+// maxRandomWeight is the weight of the most favoured LaserDiscs. The least
+// favoured have a weight of 1.
+const maxRandomWeight = 5
+
+// randomModulus is the prime the seeded random numbers are drawn modulo.
+// Products of two numbers below it still fit in SQLite's 64-bit integers.
+const randomModulus = 2147483647
+
+// RandomQuery picks LaserDiscs at random in SQL. The same seed picks the
+// same LaserDiscs while the collection doesn't change.
+type RandomQuery struct {
+	Filter          LaserDiscQuery // its sort and paging are ignored
+	NotWatchedYears int            // leave out those watched in the last this many years
+	ExcludeDays     int            // leave out those other draws picked in the last this many days
+	Mode            string         // one of RandomModes, uniform when empty
+	Count           int            // distinct LaserDiscs to pick, 1 when zero
+	Seed            int64
+	NoRecord        bool // don't remember the picks, so other draws don't leave them out
+}
+
+// Validate reports every problem with a query in one ErrInvalidQuery
+func (q RandomQuery) Validate() error {
+	var problems []string
+	if err := q.Filter.Validate(); err != nil {
+		problems = append(problems, strings.TrimPrefix(err.Error(), ErrInvalidQuery.Error()+": "))
+	}
+	if q.NotWatchedYears < 0 {
+		problems = append(problems, "not_watched_years can't be negative")
+	}
+	if q.ExcludeDays < 0 || q.ExcludeDays > MaxExcludeDays {
+		problems = append(problems, fmt.Sprintf("exclude_days must be 0 to %d", MaxExcludeDays))
+	}
+	if q.Count < 0 || q.Count > MaxRandomPicks {
+		problems = append(problems, fmt.Sprintf("count must be 1 to %d", MaxRandomPicks))
+	}
+	if q.Mode != "" && !validRandomMode(q.Mode) {
+		problems = append(problems, fmt.Sprintf("mode must be one of %s", strings.Join(RandomModes, ", ")))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidQuery, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// validRandomMode reports whether mode is one of RandomModes
+func validRandomMode(mode string) bool {
+	for _, m := range RandomModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// NewRandomSeed returns a seed for a RandomQuery that wasn't given one
+func NewRandomSeed() int64 {
+	return rand.Int63n(randomModulus)
+}
+
+// PickRandomLaserDiscs draws distinct LaserDiscs matching a query, the
+// likeliest picks first, and remembers them so later draws can leave them
+// out unless NoRecord is set. Picks too old for any draw to leave out are
+// forgotten.
+func (s *Service) PickRandomLaserDiscs(q RandomQuery) ([]models.LaserDisc, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.resolveCustomFields(&q.Filter); err != nil {
+		return nil, err
+	}
+	if q.Count == 0 {
+		q.Count = 1
+	}
+
+	// The weight and pick ID of each candidate, drawn from in an outer query
+	// so the weight is worked out once per LaserDisc
+	candidates := s.filter(q.Filter, parseSearch(q.Filter.Search))
+	if q.NotWatchedYears > 0 {
+		since := time.Now().AddDate(-q.NotWatchedYears, 0, 0)
+		candidates = candidates.Where("NOT EXISTS (SELECT 1 FROM viewings WHERE viewings.laser_disc_id = laserdiscs.id AND datetime(viewings.watched_at) >= ?)", sqliteTime(since))
+	}
+	if q.ExcludeDays > 0 {
+		since := time.Now().AddDate(0, 0, -q.ExcludeDays)
+		candidates = candidates.Where("laserdiscs.id NOT IN (SELECT laser_disc_id FROM random_picks WHERE datetime(picked_at) >= ? AND seed != ?)", sqliteTime(since), q.Seed)
+	}
+	weight := "1"
+	switch q.Mode {
+	case RandomOldestAdded:
+		weight = fmt.Sprintf("NTILE(%d) OVER (ORDER BY laserdiscs.added_date DESC, laserdiscs.id DESC)", maxRandomWeight)
+	case RandomTopGenres:
+		// Ratings out of 10 become weights out of 5. Genres that haven't
+		// been rated count as middling.
+		candidates = candidates.Joins(`LEFT JOIN (
+			SELECT rated.genre AS genre, AVG(viewings.rating) AS rating
+			FROM viewings JOIN laserdiscs rated ON rated.id = viewings.laser_disc_id
+			WHERE viewings.rating > 0 AND rated.genre != ''
+			GROUP BY rated.genre COLLATE NOCASE
+		) genre_ratings ON genre_ratings.genre = laserdiscs.genre COLLATE NOCASE`)
+		weight = fmt.Sprintf("COALESCE(MIN(%d, MAX(1, CAST(ROUND(genre_ratings.rating / 2.0) AS INTEGER))), 3)", maxRandomWeight)
+	}
+	candidates = candidates.Select("laserdiscs.id AS pick_id, " + weight + " AS pick_weight")
+
+	var ids []uint
+	err := s.db.Table("(?) AS candidates", candidates).
+		Order(weightedRandom(q.Seed, "pick_id", "pick_weight")+" DESC, pick_id").
+		Limit(q.Count).
+		Pluck("pick_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, ErrNoRandomPick
+	}
+
+	var found []models.LaserDisc
+	if err := s.db.Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.LaserDisc, len(found))
+	for _, laserdisc := range found {
+		byID[laserdisc.ID] = laserdisc
+	}
+	laserdiscs := make([]models.LaserDisc, 0, len(ids))
+	picks := make([]models.RandomPick, 0, len(ids))
+	now := time.Now()
+	for _, id := range ids {
+		laserdiscs = append(laserdiscs, byID[id])
+		picks = append(picks, models.RandomPick{LaserDiscID: id, Seed: q.Seed, PickedAt: now})
+	}
+	if q.NoRecord {
+		return laserdiscs, nil
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		forgotten := now.AddDate(0, 0, -MaxExcludeDays)
+		if err := tx.Where("datetime(picked_at) < ?", sqliteTime(forgotten)).Delete(&models.RandomPick{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&picks).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return laserdiscs, nil
+}
+
+// weightedRandom returns SQL for a row's sort key in a weighted draw: the
+// largest of weight seeded random numbers. A row with weight w then beats
+// one with weight 1 w times as often, as in Efraimidis and Spirakis'
+// weighted sampling, without needing SQLite's optional math functions.
+func weightedRandom(seed int64, id, weight string) string {
+	draws := make([]string, maxRandomWeight)
+	for stream := range draws {
+		draws[stream] = fmt.Sprintf("CASE WHEN %s > %d THEN %s ELSE 0 END", weight, stream, seededRandom(seed, id, stream))
+	}
+	return "MAX(" + strings.Join(draws, ", ") + ")"
+}
+
+// seededRandom returns SQL for a number between 0 and 1 that depends only on
+// the seed, the row's ID and the stream. Squaring mixes the bits, so
+// neighbouring IDs get unrelated numbers.
+func seededRandom(seed int64, id string, stream int) string {
+	offset := ((seed%randomModulus+randomModulus)%randomModulus*31 + int64(stream)*1000003) % randomModulus
+	h := fmt.Sprintf("((%s %% %d) * 48271 + %d) %% %d", id, randomModulus, offset, randomModulus)
+	for round := 1; round <= 2; round++ {
+		h = fmt.Sprintf("((%[1]s) * (%[1]s) %% %[2]d * 16807 + %[3]d) %% %[2]d", h, randomModulus, round*7919)
+	}
+	return fmt.Sprintf("((%s) + 0.5) / %d.0", h, randomModulus)
+}
@@ -0,0 +1,176 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// createRandomLaserDiscs adds count LaserDiscs, each added a day after the
+// one before
+func createRandomLaserDiscs(t *testing.T, service *Service, count int) []models.LaserDisc {
+	start := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	laserdiscs := make([]models.LaserDisc, count)
+	for i := range laserdiscs {
+		laserdiscs[i] = models.LaserDisc{
+			Title:     fmt.Sprintf("Disc %d", i),
+			Genre:     []string{"Horror", "Comedy"}[i%2],
+			Runtime:   90 + i*10,
+			AddedDate: start.AddDate(0, 0, i),
+		}
+	}
+	require.NoError(t, service.db.Create(&laserdiscs).Error)
+	return laserdiscs
+}
+
+// pickCounts counts how often each title is picked over many seeds
+func pickCounts(t *testing.T, service *Service, q RandomQuery, draws int) map[string]int {
+	counts := make(map[string]int)
+	for seed := 0; seed < draws; seed++ {
+		q.Seed = int64(seed)
+		picks, err := service.PickRandomLaserDiscs(q)
+		require.NoError(t, err)
+		for _, pick := range picks {
+			counts[pick.Title]++
+		}
+	}
+	return counts
+}
+
+func TestService_PickRandomLaserDiscs(t *testing.T) {
+	service := setupTestDB(t)
+	createRandomLaserDiscs(t, service, 10)
+
+	// The same seed draws the same picks, distinct and in the same order
+	q := RandomQuery{Count: 4, Seed: 42}
+	picks, err := service.PickRandomLaserDiscs(q)
+	require.NoError(t, err)
+	require.Len(t, picks, 4)
+	assert.Len(t, map[string]bool{picks[0].Title: true, picks[1].Title: true, picks[2].Title: true, picks[3].Title: true}, 4)
+	again, err := service.PickRandomLaserDiscs(q)
+	require.NoError(t, err)
+	assert.Equal(t, titles(picks), titles(again))
+
+	// Another seed usually draws others
+	q.Seed = 43
+	other, err := service.PickRandomLaserDiscs(q)
+	require.NoError(t, err)
+	assert.NotEqual(t, titles(picks), titles(other))
+
+	// Filters apply
+	q = RandomQuery{Filter: LaserDiscQuery{Genre: "comedy", RuntimeMax: 150}, Count: 10, Seed: 1}
+	picks, err = service.PickRandomLaserDiscs(q)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Disc 1", "Disc 3", "Disc 5"}, titles(picks))
+
+	q.Filter.Genre = "Western"
+	_, err = service.PickRandomLaserDiscs(q)
+	assert.ErrorIs(t, err, ErrNoRandomPick)
+
+	// Invalid queries list every problem
+	_, err = service.PickRandomLaserDiscs(RandomQuery{Count: 11, Mode: "favourites", Filter: LaserDiscQuery{YearMin: 2000, YearMax: 1990}})
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+	assert.ErrorContains(t, err, "count must be 1 to 10")
+	assert.ErrorContains(t, err, "mode must be one of")
+	assert.ErrorContains(t, err, "year_min is after year_max")
+}
+
+func TestService_PickRandomLaserDiscs_Exclusions(t *testing.T) {
+	service := setupTestDB(t)
+	laserdiscs := createRandomLaserDiscs(t, service, 4)
+
+	// Picks from other draws are left out for a while, but the same seed
+	// still draws the same
+	q := RandomQuery{ExcludeDays: 7, Count: 2, Seed: 5}
+	picks, err := service.PickRandomLaserDiscs(q)
+	require.NoError(t, err)
+	again, err := service.PickRandomLaserDiscs(q)
+	require.NoError(t, err)
+	assert.Equal(t, titles(picks), titles(again))
+
+	q.Seed = 6
+	others, err := service.PickRandomLaserDiscs(q)
+	require.NoError(t, err)
+	assert.Empty(t, intersect(titles(picks), titles(others)))
+
+	q.Seed = 7
+	_, err = service.PickRandomLaserDiscs(q)
+	assert.ErrorIs(t, err, ErrNoRandomPick, "everything was picked")
+	q.ExcludeDays = 0
+	_, err = service.PickRandomLaserDiscs(q)
+	assert.NoError(t, err)
+
+	// Picks older than any draw can leave out are forgotten
+	old := models.RandomPick{LaserDiscID: laserdiscs[0].ID, Seed: 1, PickedAt: time.Now().AddDate(0, 0, -MaxExcludeDays-1)}
+	require.NoError(t, service.db.Create(&old).Error)
+	_, err = service.PickRandomLaserDiscs(q)
+	require.NoError(t, err)
+	assert.ErrorIs(t, service.db.First(&models.RandomPick{}, old.ID).Error, gorm.ErrRecordNotFound)
+	q.ExcludeDays = MaxExcludeDays + 1
+	_, err = service.PickRandomLaserDiscs(q)
+	assert.ErrorIs(t, err, ErrInvalidQuery)
+
+	// Watched recently, watched long ago and on a day that isn't known
+	recent := time.Now().AddDate(0, -6, 0).Format(time.RFC3339)
+	longAgo := time.Now().AddDate(-3, 0, 0).Format(time.RFC3339)
+	_, err = service.AddViewing(laserdiscs[0].ID, &models.ViewingRequest{WatchedAt: &recent})
+	require.NoError(t, err)
+	_, err = service.AddViewing(laserdiscs[1].ID, &models.ViewingRequest{WatchedAt: &longAgo})
+	require.NoError(t, err)
+	_, err = service.AddViewing(laserdiscs[2].ID, &models.ViewingRequest{WatchedAt: stringPtr("")})
+	require.NoError(t, err)
+
+	watched := true
+	picks, err = service.PickRandomLaserDiscs(RandomQuery{
+		Filter:          LaserDiscQuery{Watched: &watched},
+		NotWatchedYears: 2,
+		Count:           10,
+		Seed:            1,
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Disc 1", "Disc 2"}, titles(picks), "watched, but not in 2 years")
+}
+
+func TestService_PickRandomLaserDiscs_Weighting(t *testing.T) {
+	service := setupTestDB(t)
+	laserdiscs := createRandomLaserDiscs(t, service, 5)
+
+	// Uniform picks are spread evenly
+	counts := pickCounts(t, service, RandomQuery{}, 1000)
+	for _, laserdisc := range laserdiscs {
+		assert.InDelta(t, 200, counts[laserdisc.Title], 60, laserdisc.Title)
+	}
+
+	// The oldest addition has five times the weight of the newest
+	counts = pickCounts(t, service, RandomQuery{Mode: RandomOldestAdded}, 1500)
+	assert.InDelta(t, 500, counts["Disc 0"], 90)
+	assert.InDelta(t, 100, counts["Disc 4"], 50)
+
+	// Comedies were rated 10 and horror 2, weighing 5 and 1
+	_, err := service.AddViewing(laserdiscs[1].ID, &models.ViewingRequest{Rating: intPtr(10)})
+	require.NoError(t, err)
+	_, err = service.AddViewing(laserdiscs[0].ID, &models.ViewingRequest{Rating: intPtr(2)})
+	require.NoError(t, err)
+	counts = pickCounts(t, service, RandomQuery{Mode: RandomTopGenres}, 1000)
+	comedies := counts["Disc 1"] + counts["Disc 3"]
+	assert.Greater(t, comedies, 700, "the two comedies have 10 of the 13 weight")
+}
+
+// intersect returns the strings in both a and b
+func intersect(a, b []string) []string {
+	var both []string
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				both = append(both, x)
+			}
+		}
+	}
+	return both
+}
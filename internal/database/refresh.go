@@ -0,0 +1,213 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// ErrChangeResolved is returned when accepting or rejecting a suggestion
+// that has already been dealt with
+var ErrChangeResolved = errors.New("change is not a pending suggestion")
+
+// RefreshCandidates returns the IDs of LaserDiscs a refresh job with the
+// given filter should re-check: those with an LDDB URL that match it
+func (s *Service) RefreshCandidates(filter models.RefreshFilter) ([]uint, error) {
+	query := s.db.Model(&models.LaserDisc{}).Where("COALESCE(lddb_url, '') <> ''")
+	if len(filter.IDs) > 0 {
+		query = query.Where("id IN ?", filter.IDs)
+	}
+	if len(filter.Missing) > 0 {
+		missing := s.db.Where("1 = 0")
+		for _, field := range filter.Missing {
+			if !models.IsScrapedField(field) {
+				return nil, fmt.Errorf("%s is not a metadata field", field)
+			}
+			// Works for text and number columns alike
+			missing = missing.Or(fmt.Sprintf("%[1]s IS NULL OR %[1]s = '' OR %[1]s = 0", field))
+		}
+		query = query.Where(missing)
+	}
+
+	var ids []uint
+	result := query.Order("id ASC").Pluck("id", &ids)
+	return ids, result.Error
+}
+
+// CreateRefreshJob saves a new refresh job
+func (s *Service) CreateRefreshJob(job *models.RefreshJob) error {
+	return s.db.Create(job).Error
+}
+
+// SaveRefreshJob saves a refresh job's progress
+func (s *Service) SaveRefreshJob(job *models.RefreshJob) error {
+	return s.db.Save(job).Error
+}
+
+// GetRefreshJob retrieves a refresh job by its ID
+func (s *Service) GetRefreshJob(id uint) (*models.RefreshJob, error) {
+	var job models.RefreshJob
+	result := s.db.First(&job, id)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &job, nil
+}
+
+// GetRefreshJobs returns the most recent refresh jobs, newest first
+func (s *Service) GetRefreshJobs(limit int) ([]models.RefreshJob, error) {
+	var jobs []models.RefreshJob
+	result := s.db.Order("id DESC").Limit(limit).Find(&jobs)
+	return jobs, result.Error
+}
+
+// FailInterruptedRefreshJobs marks jobs left running by a previous server
+// process as failed. It returns how many there were.
+func (s *Service) FailInterruptedRefreshJobs() (int64, error) {
+	result := s.db.Model(&models.RefreshJob{}).
+		Where("status = ?", models.RefreshJobRunning).
+		Updates(map[string]interface{}{
+			"status":      models.RefreshJobFailed,
+			"error":       "interrupted by a server restart",
+			"finished_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// GetMetadataChanges returns the changes found by a refresh job, optionally
+// only those with the given status
+func (s *Service) GetMetadataChanges(jobID uint, status string) ([]models.MetadataChange, error) {
+	query := s.db.Where("job_id = ?", jobID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var changes []models.MetadataChange
+	result := query.Order("laser_disc_id ASC, id ASC").Find(&changes)
+	return changes, result.Error
+}
+
+// GetPendingSuggestions returns suggestions waiting for review, for one
+// LaserDisc or, with an ID of zero, the whole collection
+func (s *Service) GetPendingSuggestions(laserdiscID uint) ([]models.MetadataChange, error) {
+	query := s.db.Where("status = ?", models.ChangePending)
+	if laserdiscID != 0 {
+		query = query.Where("laser_disc_id = ?", laserdiscID)
+	}
+
+	var changes []models.MetadataChange
+	result := query.Order("laser_disc_id ASC, id ASC").Find(&changes)
+	return changes, result.Error
+}
+
+// ApplyMetadataChanges records what a refresh found for one LaserDisc. Changes
+// marked applied are written to the LaserDisc, pending ones are queued for
+// review and replace any older suggestion for the same field.
+func (s *Service) ApplyMetadataChanges(laserdiscID uint, changes []models.MetadataChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var laserdisc models.LaserDisc
+		if err := tx.First(&laserdisc, laserdiscID).Error; err != nil {
+			return err
+		}
+
+		values := make(map[string]json.RawMessage)
+		sources := make(map[string][]string)
+		for i := range changes {
+			change := &changes[i]
+			change.LaserDiscID = laserdiscID
+
+			if err := tx.Model(&models.MetadataChange{}).
+				Where("laser_disc_id = ? AND field = ? AND status = ?", laserdiscID, change.Field, models.ChangePending).
+				Update("status", models.ChangeSuperseded).Error; err != nil {
+				return err
+			}
+			if change.Status == models.ChangeApplied {
+				values[change.Field] = change.NewValue
+				sources[change.Source] = append(sources[change.Source], change.Field)
+			}
+		}
+
+		if err := setScrapedFields(tx, &laserdisc, values); err != nil {
+			return err
+		}
+		for source, fields := range sources {
+			if err := setFieldSources(tx, laserdiscID, fields, source); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&changes).Error
+	})
+}
+
+// RejectedValues returns the JSON encoded values the user rejected when they
+// were suggested for a LaserDisc, keyed by field
+func (s *Service) RejectedValues(laserdiscID uint) (map[string][]json.RawMessage, error) {
+	var changes []models.MetadataChange
+	result := s.db.Select("field", "new_value").
+		Where("laser_disc_id = ? AND status = ?", laserdiscID, models.ChangeRejected).
+		Find(&changes)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	rejected := make(map[string][]json.RawMessage)
+	for _, change := range changes {
+		rejected[change.Field] = append(rejected[change.Field], change.NewValue)
+	}
+	return rejected, nil
+}
+
+// AcceptSuggestion writes a pending suggestion's value to its LaserDisc. The
+// field then counts as coming from the suggestion's source again.
+func (s *Service) AcceptSuggestion(id uint) (*models.MetadataChange, error) {
+	return s.resolveSuggestion(id, models.ChangeAccepted, func(tx *gorm.DB, change *models.MetadataChange) error {
+		var laserdisc models.LaserDisc
+		if err := tx.First(&laserdisc, change.LaserDiscID).Error; err != nil {
+			return err
+		}
+		if err := setScrapedFields(tx, &laserdisc, map[string]json.RawMessage{change.Field: change.NewValue}); err != nil {
+			return err
+		}
+		return setFieldSources(tx, change.LaserDiscID, []string{change.Field}, change.Source)
+	})
+}
+
+// RejectSuggestion dismisses a pending suggestion, leaving the LaserDisc as it is
+func (s *Service) RejectSuggestion(id uint) (*models.MetadataChange, error) {
+	return s.resolveSuggestion(id, models.ChangeRejected, nil)
+}
+
+// resolveSuggestion moves a pending suggestion to status, running apply first
+func (s *Service) resolveSuggestion(id uint, status string, apply func(tx *gorm.DB, change *models.MetadataChange) error) (*models.MetadataChange, error) {
+	var change models.MetadataChange
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&change, id).Error; err != nil {
+			return err
+		}
+		if change.Status != models.ChangePending {
+			return ErrChangeResolved
+		}
+		if apply != nil {
+			if err := apply(tx, &change); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		change.Status, change.ResolvedAt = status, &now
+		return tx.Model(&change).Updates(map[string]interface{}{"status": status, "resolved_at": now}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
@@ -0,0 +1,196 @@
+package database
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+func TestService_UpdateLaserDiscRecordsManualEdits(t *testing.T) {
+	service := setupTestDB(t)
+
+	// Looked up, so nothing starts out as a manual edit
+	req := createTestLaserDisc()
+	req.Sources = make(map[string]string)
+	for _, field := range models.ScrapedFields {
+		req.Sources[field] = "lddb"
+	}
+	created, err := service.CreateLaserDisc(req)
+	require.NoError(t, err)
+
+	// The edit form sends every field, but only changed metadata counts as edited
+	genre, director, notes, year := "Horror", created.Director, "new notes", created.Year
+	_, err = service.UpdateLaserDisc(created.ID, &models.UpdateLaserDiscRequest{
+		Genre:    &genre,
+		Director: &director,
+		Year:     &year,
+		Notes:    &notes,
+	})
+	require.NoError(t, err)
+
+	manual, err := service.ManualFields(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"genre": true}, manual)
+}
+
+func TestService_RefreshCandidates(t *testing.T) {
+	service := setupTestDB(t)
+
+	withURL := createTestLaserDisc()
+	withURL.LDDBUrl = "https://www.lddb.com/laserdisc/1/"
+	a, err := service.CreateLaserDisc(withURL)
+	require.NoError(t, err)
+
+	missingGenre := createTestLaserDisc()
+	missingGenre.UPC = "111111111117"
+	missingGenre.LDDBUrl = "https://www.lddb.com/laserdisc/2/"
+	missingGenre.Genre = ""
+	b, err := service.CreateLaserDisc(missingGenre)
+	require.NoError(t, err)
+
+	noURL := createTestLaserDisc()
+	noURL.UPC = "222222222224"
+	_, err = service.CreateLaserDisc(noURL)
+	require.NoError(t, err)
+
+	ids, err := service.RefreshCandidates(models.RefreshFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{a.ID, b.ID}, ids)
+
+	ids, err = service.RefreshCandidates(models.RefreshFilter{IDs: []uint{a.ID}})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{a.ID}, ids)
+
+	ids, err = service.RefreshCandidates(models.RefreshFilter{Missing: []string{"genre", "chapters"}})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{a.ID, b.ID}, ids, "neither has chapters")
+
+	ids, err = service.RefreshCandidates(models.RefreshFilter{Missing: []string{"genre"}})
+	require.NoError(t, err)
+	assert.Equal(t, []uint{b.ID}, ids)
+
+	_, err = service.RefreshCandidates(models.RefreshFilter{Missing: []string{"notes"}})
+	assert.Error(t, err)
+}
+
+func TestService_ApplyMetadataChanges(t *testing.T) {
+	service := setupTestDB(t)
+	created, err := service.CreateLaserDisc(createTestLaserDisc())
+	require.NoError(t, err)
+	job := &models.RefreshJob{Status: models.RefreshJobRunning}
+	require.NoError(t, service.CreateRefreshJob(job))
+
+	changes := []models.MetadataChange{
+		{JobID: job.ID, Field: "runtime", OldValue: json.RawMessage(`120`), NewValue: json.RawMessage(`117`), Source: "lddb", Status: models.ChangeApplied},
+		{JobID: job.ID, Field: "label", OldValue: json.RawMessage(`""`), NewValue: json.RawMessage(`"Criterion"`), Source: "lddb", Status: models.ChangeApplied},
+		{JobID: job.ID, Field: "genre", OldValue: json.RawMessage(`"Action"`), NewValue: json.RawMessage(`"Sci-Fi"`), Source: "lddb", Status: models.ChangePending},
+	}
+	require.NoError(t, service.ApplyMetadataChanges(created.ID, changes))
+
+	laserdisc, err := service.GetLaserDiscByID(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 117, laserdisc.Runtime)
+	assert.Equal(t, "Criterion", laserdisc.Label)
+	assert.Equal(t, "Action", laserdisc.Genre, "suggestions aren't applied")
+	assert.Equal(t, created.Title, laserdisc.Title)
+
+	recorded, err := service.GetMetadataChanges(job.ID, "")
+	require.NoError(t, err)
+	assert.Len(t, recorded, 3)
+	pending, err := service.GetPendingSuggestions(created.ID)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.JSONEq(t, `"Sci-Fi"`, string(pending[0].NewValue))
+
+	// A later refresh's suggestion for the same field replaces the old one
+	require.NoError(t, service.ApplyMetadataChanges(created.ID, []models.MetadataChange{
+		{JobID: job.ID, Field: "genre", OldValue: json.RawMessage(`"Action"`), NewValue: json.RawMessage(`"Science Fiction"`), Source: "lddb", Status: models.ChangePending},
+	}))
+	pending, err = service.GetPendingSuggestions(0)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.JSONEq(t, `"Science Fiction"`, string(pending[0].NewValue))
+
+	// Accepting writes the value and hands the field back to LDDB
+	genre := "Action"
+	_, err = service.UpdateLaserDisc(created.ID, &models.UpdateLaserDiscRequest{Genre: &genre})
+	require.NoError(t, err)
+	accepted, err := service.AcceptSuggestion(pending[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ChangeAccepted, accepted.Status)
+	assert.NotNil(t, accepted.ResolvedAt)
+
+	laserdisc, err = service.GetLaserDiscByID(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Science Fiction", laserdisc.Genre)
+	manual, err := service.ManualFields(created.ID)
+	require.NoError(t, err)
+	assert.False(t, manual["genre"])
+
+	_, err = service.AcceptSuggestion(pending[0].ID)
+	assert.ErrorIs(t, err, ErrChangeResolved)
+	_, err = service.RejectSuggestion(999)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestService_RejectSuggestion(t *testing.T) {
+	service := setupTestDB(t)
+	created, err := service.CreateLaserDisc(createTestLaserDisc())
+	require.NoError(t, err)
+
+	require.NoError(t, service.ApplyMetadataChanges(created.ID, []models.MetadataChange{
+		{JobID: 1, Field: "genre", OldValue: json.RawMessage(`"Action"`), NewValue: json.RawMessage(`"Sci-Fi"`), Source: "lddb", Status: models.ChangePending},
+	}))
+	pending, err := service.GetPendingSuggestions(created.ID)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+
+	rejected, err := service.RejectSuggestion(pending[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ChangeRejected, rejected.Status)
+
+	laserdisc, err := service.GetLaserDiscByID(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Action", laserdisc.Genre)
+
+	// Remembered so later refreshes don't suggest it again
+	values, err := service.RejectedValues(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]json.RawMessage{"genre": {json.RawMessage(`"Sci-Fi"`)}}, values)
+}
+
+func TestService_RefreshJobs(t *testing.T) {
+	service := setupTestDB(t)
+
+	first := &models.RefreshJob{Status: models.RefreshJobRunning, Filter: models.RefreshFilter{Missing: []string{"genre"}}}
+	require.NoError(t, service.CreateRefreshJob(first))
+	first.Processed, first.Errors = 3, []models.RefreshError{{LaserDiscID: 2, Error: "not found"}}
+	require.NoError(t, service.SaveRefreshJob(first))
+
+	saved, err := service.GetRefreshJob(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, saved.Processed)
+	assert.Equal(t, []string{"genre"}, saved.Filter.Missing)
+	assert.Equal(t, "not found", saved.Errors[0].Error)
+
+	second := &models.RefreshJob{Status: models.RefreshJobCompleted}
+	require.NoError(t, service.CreateRefreshJob(second))
+	jobs, err := service.GetRefreshJobs(10)
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	assert.Equal(t, second.ID, jobs[0].ID)
+
+	// A job left running by a previous process is marked failed on startup
+	interrupted, err := service.FailInterruptedRefreshJobs()
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), interrupted)
+	saved, err = service.GetRefreshJob(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.RefreshJobFailed, saved.Status)
+	assert.NotNil(t, saved.FinishedAt)
+}
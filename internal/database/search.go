@@ -0,0 +1,286 @@
+package database
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// searchField is a field a search term can be limited to with a prefix,
+// e.g. director:spielberg
+type searchField struct {
+	index   []string // full-text index columns
+	columns []string // laserdiscs columns, when searching without the index
+}
+
+// searchFields are the fields searches match, by prefix
+var searchFields = map[string]searchField{
+	"title":    {index: []string{"title", "title_alt"}, columns: []string{"title", articleFirst("title")}},
+	"director": {index: []string{"director"}, columns: []string{"director"}},
+	"genre":    {index: []string{"genre"}, columns: []string{"genre"}},
+	"notes":    {index: []string{"notes"}, columns: []string{"notes"}},
+	"catalog":  {index: []string{"catalog"}, columns: []string{"reference", "label", "upc"}},
+}
+
+// searchFieldOrder lists searchFields in a fixed order, for matching any field
+var searchFieldOrder = []string{"title", "director", "genre", "notes", "catalog"}
+
+// articleFirst returns SQL that moves a title's trailing article back to
+// the front, so "Ghost and the Darkness, The" is also found as "The Ghost
+// and the Darkness". Titles without one become empty.
+func articleFirst(title string) string {
+	return fmt.Sprintf(`CASE
+		WHEN %[1]s LIKE '%%, The' THEN 'The ' || substr(%[1]s, 1, length(%[1]s) - 5)
+		WHEN %[1]s LIKE '%%, An' THEN 'An ' || substr(%[1]s, 1, length(%[1]s) - 4)
+		WHEN %[1]s LIKE '%%, A' THEN 'A ' || substr(%[1]s, 1, length(%[1]s) - 3)
+		ELSE '' END`, title)
+}
+
+// searchTerm is one word or quoted phrase of a search
+type searchTerm struct {
+	field  string // one of searchFields, or empty for any of them
+	text   string
+	prefix bool // matches words starting with the term's last word
+}
+
+// isWordRune reports whether r is part of a word the index would keep
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// parseSearch splits a search into terms. Words match as prefixes, "quoted
+// phrases" match whole words in order unless followed by *, and either can
+// be limited to a field, e.g. director:spielberg or title:"close encounters".
+// Terms with no letters or digits are dropped.
+func parseSearch(search string) []searchTerm {
+	var terms []searchTerm
+	rest := strings.TrimSpace(search)
+	for rest != "" {
+		var term searchTerm
+		if i := strings.IndexByte(rest, ':'); i > 0 {
+			if _, ok := searchFields[strings.ToLower(rest[:i])]; ok {
+				term.field = strings.ToLower(rest[:i])
+				rest = rest[i+1:]
+			}
+		}
+
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				term.text, rest = rest[1:], ""
+			} else {
+				term.text, rest = rest[1:end+1], rest[end+2:]
+			}
+			if strings.HasPrefix(rest, "*") {
+				term.prefix, rest = true, rest[1:]
+			}
+		} else {
+			end := strings.IndexFunc(rest, unicode.IsSpace)
+			if end < 0 {
+				end = len(rest)
+			}
+			term.text, rest = strings.TrimRight(rest[:end], "*"), rest[end:]
+			term.prefix = true
+		}
+
+		if strings.IndexFunc(term.text, isWordRune) >= 0 {
+			terms = append(terms, term)
+		}
+		rest = strings.TrimLeftFunc(rest, unicode.IsSpace)
+	}
+	return terms
+}
+
+// match returns the term as an FTS5 query. The text is always quoted, so
+// nothing in it is taken as query syntax.
+func (t searchTerm) match() string {
+	query := `"` + strings.ReplaceAll(t.text, `"`, `""`) + `"`
+	if t.prefix {
+		query += "*"
+	}
+	if t.field != "" {
+		query = "{" + strings.Join(searchFields[t.field].index, " ") + "} : " + query
+	}
+	return query
+}
+
+// matchQuery returns an FTS5 query matching every term
+func matchQuery(terms []searchTerm) string {
+	queries := make([]string, len(terms))
+	for i, term := range terms {
+		queries[i] = term.match()
+	}
+	return strings.Join(queries, " AND ")
+}
+
+// likeEscaper escapes LIKE's wildcards, for ESCAPE '\'
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// like returns the term as a LIKE condition, for databases without the
+// full-text index. Every term matches anywhere in a word.
+func (t searchTerm) like() (string, []interface{}) {
+	fields := searchFieldOrder
+	if t.field != "" {
+		fields = []string{t.field}
+	}
+
+	pattern := "%" + likeEscaper.Replace(t.text) + "%"
+	var conditions []string
+	var args []interface{}
+	for _, field := range fields {
+		for _, column := range searchFields[field].columns {
+			conditions = append(conditions, column+` LIKE ? ESCAPE '\'`)
+			args = append(args, pattern)
+		}
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
+// searchRank is the SQL ranking full-text matches, best first. A match in
+// the title counts for most, then director, catalog, genre and notes.
+const searchRank = "bm25(laserdiscs_fts, 10.0, 10.0, 5.0, 2.0, 1.0, 3.0)"
+
+// search applies a query's search terms, with the full-text index if the
+// service has one
+func (s *Service) search(db *gorm.DB, terms []searchTerm) *gorm.DB {
+	if len(terms) == 0 {
+		return db
+	}
+	if s.fullText {
+		return db.Joins("JOIN (SELECT rowid AS match_id, "+searchRank+" AS match_rank FROM laserdiscs_fts WHERE laserdiscs_fts MATCH ?) AS matches ON matches.match_id = laserdiscs.id", matchQuery(terms))
+	}
+	for _, term := range terms {
+		condition, args := term.like()
+		db = db.Where(condition, args...)
+	}
+	return db
+}
+
+// Highlighted matches are marked with control characters, which are
+// replaced with <mark> elements once the rest of the text is escaped
+const (
+	matchStart = "\x02"
+	matchEnd   = "\x03"
+)
+
+// highlightHTML escapes text and turns the match markers into <mark> elements
+func highlightHTML(text string) string {
+	return strings.NewReplacer(matchStart, "<mark>", matchEnd, "</mark>").Replace(html.EscapeString(text))
+}
+
+// attachMatches sets how each LaserDisc matched a full-text search
+func (s *Service) attachMatches(laserdiscs []models.LaserDisc, terms []searchTerm) error {
+	if !s.fullText || len(terms) == 0 || len(laserdiscs) == 0 {
+		return nil
+	}
+
+	byID := make(map[uint]*models.SearchMatch, len(laserdiscs))
+	for start := 0; start < len(laserdiscs); start += 500 {
+		batch := laserdiscs[start:]
+		if len(batch) > 500 {
+			batch = batch[:500]
+		}
+		ids := make([]uint, len(batch))
+		for i, laserdisc := range batch {
+			ids[i] = laserdisc.ID
+		}
+
+		// +rowid keeps SQLite from running the MATCH again for every ID
+		var matches []struct {
+			ID      uint
+			Score   float64
+			Title   string
+			Snippet string
+		}
+		err := s.db.Raw("SELECT rowid AS id, -"+searchRank+" AS score, highlight(laserdiscs_fts, 0, ?, ?) AS title, snippet(laserdiscs_fts, -1, ?, ?, '…', 12) AS snippet FROM laserdiscs_fts WHERE laserdiscs_fts MATCH ? AND +rowid IN ?",
+			matchStart, matchEnd, matchStart, matchEnd, matchQuery(terms), ids).Scan(&matches).Error
+		if err != nil {
+			return err
+		}
+		for _, match := range matches {
+			byID[match.ID] = &models.SearchMatch{
+				Score:   match.Score,
+				Title:   highlightHTML(match.Title),
+				Snippet: highlightHTML(match.Snippet),
+			}
+		}
+	}
+	for i := range laserdiscs {
+		laserdiscs[i].Match = byID[laserdiscs[i].ID]
+	}
+	return nil
+}
+
+// searchIndexValues returns SQL for a laserdiscs row's values in the
+// full-text index, in the order of its columns
+func searchIndexValues(row string) string {
+	return fmt.Sprintf("%[1]s.id, %[1]s.title, %[2]s, %[1]s.director, %[1]s.genre, %[1]s.notes, replace(trim(coalesce(%[1]s.reference, '') || ' ' || coalesce(%[1]s.label, '') || ' ' || coalesce(%[1]s.upc, '')), '  ', ' ')",
+		row, articleFirst(row+".title"))
+}
+
+// searchIndexColumns are the full-text index's columns, after the rowid
+const searchIndexColumns = "rowid, title, title_alt, director, genre, notes, catalog"
+
+// EnableFullTextSearch sets up the FTS5 index searches use, with triggers
+// keeping it in step with the laserdiscs table, and fills it if it's out of
+// step. The index is only derived data, and FTS5 is only in SQLite builds
+// tagged sqlite_fts5, so it's set up here rather than by a migration. It
+// reports false, leaving searches to use LIKE, when FTS5 isn't available.
+func (s *Service) EnableFullTextSearch() (bool, error) {
+	var available bool
+	if err := s.db.Raw("SELECT sqlite_compileoption_used('ENABLE_FTS5')").Scan(&available).Error; err != nil {
+		return false, err
+	}
+	if !available {
+		return false, nil
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		statements := []string{
+			"CREATE VIRTUAL TABLE IF NOT EXISTS laserdiscs_fts USING fts5(title, title_alt, director, genre, notes, catalog, tokenize = 'unicode61 remove_diacritics 2', prefix = '2 3')",
+
+			// Recreated every time, in case the columns they copy have changed
+			"DROP TRIGGER IF EXISTS laserdiscs_fts_insert",
+			"DROP TRIGGER IF EXISTS laserdiscs_fts_update",
+			"DROP TRIGGER IF EXISTS laserdiscs_fts_delete",
+			"CREATE TRIGGER laserdiscs_fts_insert AFTER INSERT ON laserdiscs BEGIN " +
+				"INSERT INTO laserdiscs_fts (" + searchIndexColumns + ") VALUES (" + searchIndexValues("new") + "); END",
+			"CREATE TRIGGER laserdiscs_fts_update AFTER UPDATE OF title, director, genre, notes, reference, label, upc ON laserdiscs BEGIN " +
+				"DELETE FROM laserdiscs_fts WHERE rowid = old.id; " +
+				"INSERT INTO laserdiscs_fts (" + searchIndexColumns + ") VALUES (" + searchIndexValues("new") + "); END",
+			"CREATE TRIGGER laserdiscs_fts_delete AFTER DELETE ON laserdiscs BEGIN " +
+				"DELETE FROM laserdiscs_fts WHERE rowid = old.id; END",
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+
+		// Fill the index when it's new, or the table changed without the triggers
+		var indexed, total int64
+		if err := tx.Raw("SELECT count(*) FROM laserdiscs_fts").Scan(&indexed).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.LaserDisc{}).Count(&total).Error; err != nil {
+			return err
+		}
+		if indexed == total {
+			return nil
+		}
+		if err := tx.Exec("DELETE FROM laserdiscs_fts").Error; err != nil {
+			return err
+		}
+		return tx.Exec("INSERT INTO laserdiscs_fts (" + searchIndexColumns + ") SELECT " + searchIndexValues("laserdiscs") + " FROM laserdiscs").Error
+	})
+	if err != nil {
+		return false, fmt.Errorf("setting up full-text search: %w", err)
+	}
+	s.fullText = true
+	return true, nil
+}
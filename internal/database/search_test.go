@@ -0,0 +1,188 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+func TestParseSearch(t *testing.T) {
+	tests := []struct {
+		search string
+		want   []searchTerm
+	}{
+		{"", nil},
+		{"  alien  ", []searchTerm{{text: "alien", prefix: true}}},
+		{"ali* ridley", []searchTerm{{text: "ali", prefix: true}, {text: "ridley", prefix: true}}},
+		{`"close encounters" spielberg`, []searchTerm{{text: "close encounters"}, {text: "spielberg", prefix: true}}},
+		{`"close enc"*`, []searchTerm{{text: "close enc", prefix: true}}},
+		{"director:spielberg", []searchTerm{{field: "director", text: "spielberg", prefix: true}}},
+		{`Title:"the ghost"`, []searchTerm{{field: "title", text: "the ghost"}}},
+		{"Star Trek: The", []searchTerm{{text: "Star", prefix: true}, {text: "Trek:", prefix: true}, {text: "The", prefix: true}}},
+		{"cast:ford", []searchTerm{{text: "cast:ford", prefix: true}}},
+		{`- * "" director:`, nil},
+		{`"unterminated phrase`, []searchTerm{{text: "unterminated phrase"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.search, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseSearch(tt.search))
+		})
+	}
+}
+
+func TestSearchTerm_Match(t *testing.T) {
+	assert.Equal(t, `"alien"*`, searchTerm{text: "alien", prefix: true}.match())
+	assert.Equal(t, `{director} : "spielberg"*`, searchTerm{field: "director", text: "spielberg", prefix: true}.match())
+	assert.Equal(t, `{title title_alt} : "the ghost"`, searchTerm{field: "title", text: "the ghost"}.match())
+	assert.Equal(t, `"OR" AND "a""b"*`, matchQuery([]searchTerm{{text: "OR"}, {text: `a"b`, prefix: true}}))
+}
+
+func TestHighlightHTML(t *testing.T) {
+	assert.Equal(t, "<mark>Tom &amp; Jerry</mark> &lt;b&gt;", highlightHTML(matchStart+"Tom & Jerry"+matchEnd+" <b>"))
+}
+
+// createSearchLaserDiscs adds LaserDiscs for the search tests
+func createSearchLaserDiscs(t *testing.T, service *Service) {
+	laserdiscs := []models.LaserDisc{
+		{Title: "Ghost and the Darkness, The", Director: "Stephen Hopkins", Genre: "Adventure"},
+		{Title: "Close Encounters of the Third Kind", Director: "Steven Spielberg", Genre: "Sci-Fi", Reference: "VL5080", Label: "Criterion"},
+		{Title: "Jaws", Director: "Steven Spielberg", Genre: "Thriller"},
+		{Title: "Ghostbusters", Director: "Ivan Reitman", Genre: "Comedy", Notes: "Signed by the Spielberg fan club"},
+		{Title: "Amélie", Director: "Jean-Pierre Jeunet", Genre: "Comedy", Notes: "100% uncut"},
+	}
+	require.NoError(t, service.db.Create(&laserdiscs).Error)
+}
+
+// searchTitles returns the titles of the LaserDiscs found by a search
+func searchTitles(t *testing.T, service *Service, search string) []string {
+	laserdiscs, total, err := service.QueryLaserDiscs(LaserDiscQuery{Search: search, Sort: "title"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(laserdiscs)), total)
+	return titles(laserdiscs)
+}
+
+// testSearches checks the searches that behave the same with and without
+// the full-text index
+func testSearches(t *testing.T, service *Service) {
+	tests := []struct {
+		search string
+		want   []string
+	}{
+		{"jaws", []string{"Jaws"}},
+		{"ghost", []string{"Ghost and the Darkness, The", "Ghostbusters"}},
+		{"the ghost and the darkness", []string{"Ghost and the Darkness, The"}},
+		{`"the ghost and the darkness"`, []string{"Ghost and the Darkness, The"}},
+		{`title:"the ghost"`, []string{"Ghost and the Darkness, The"}},
+		{`"close encounters"`, []string{"Close Encounters of the Third Kind"}},
+		{"spielberg", []string{"Close Encounters of the Third Kind", "Ghostbusters", "Jaws"}},
+		{"director:spielberg", []string{"Close Encounters of the Third Kind", "Jaws"}},
+		{"director:spielberg jaws", []string{"Jaws"}},
+		{"notes:spielberg", []string{"Ghostbusters"}},
+		{"catalog:vl5080", []string{"Close Encounters of the Third Kind"}},
+		{"catalog:criterion", []string{"Close Encounters of the Third Kind"}},
+		{"genre:comedy", []string{"Amélie", "Ghostbusters"}},
+		{"100%", []string{"Amélie"}},
+		{"nothing like this", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.search, func(t *testing.T) {
+			assert.Equal(t, tt.want, searchTitles(t, service, tt.search))
+		})
+	}
+}
+
+func TestService_SearchWithoutIndex(t *testing.T) {
+	service := setupTestDB(t)
+	service.fullText = false
+	createSearchLaserDiscs(t, service)
+
+	testSearches(t, service)
+
+	laserdiscs, _, err := service.QueryLaserDiscs(LaserDiscQuery{Search: "jaws"})
+	require.NoError(t, err)
+	require.Len(t, laserdiscs, 1)
+	assert.Nil(t, laserdiscs[0].Match, "only full-text searches describe matches")
+}
+
+// setupFullTextDB returns a service searching with the full-text index,
+// skipping the test when SQLite was built without FTS5
+func setupFullTextDB(t *testing.T) *Service {
+	service := setupTestDB(t)
+	if !service.fullText {
+		t.Skip("SQLite built without FTS5, run with -tags sqlite_fts5")
+	}
+	return service
+}
+
+func TestService_FullTextSearch(t *testing.T) {
+	service := setupFullTextDB(t)
+	createSearchLaserDiscs(t, service)
+
+	testSearches(t, service)
+
+	// Accents are ignored, and whole words are matched from their start
+	assert.Equal(t, []string{"Amélie"}, searchTitles(t, service, "amelie"))
+	assert.Equal(t, []string{}, searchTitles(t, service, "busters"))
+}
+
+func TestService_FullTextSearchRanking(t *testing.T) {
+	service := setupFullTextDB(t)
+	createSearchLaserDiscs(t, service)
+
+	// A director match ranks above a mention in the notes
+	laserdiscs, total, err := service.QueryLaserDiscs(LaserDiscQuery{Search: "spielberg"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	require.Len(t, laserdiscs, 3)
+	assert.Equal(t, "Ghostbusters", laserdiscs[2].Title)
+	for i, laserdisc := range laserdiscs {
+		require.NotNil(t, laserdisc.Match, laserdisc.Title)
+		if i > 0 {
+			assert.LessOrEqual(t, laserdisc.Match.Score, laserdiscs[i-1].Match.Score)
+		}
+	}
+	assert.Contains(t, laserdiscs[2].Match.Snippet, "<mark>Spielberg</mark>")
+	assert.Equal(t, "Ghostbusters", laserdiscs[2].Match.Title, "nothing to highlight in the title")
+
+	// An explicit sort overrides the ranking
+	laserdiscs, _, err = service.QueryLaserDiscs(LaserDiscQuery{Search: "spielberg", Sort: "title", Desc: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Jaws", "Ghostbusters", "Close Encounters of the Third Kind"}, titles(laserdiscs))
+
+	// Search combines with filters and paging
+	laserdiscs, total, err = service.QueryLaserDiscs(LaserDiscQuery{Search: "ghost*", Genre: "comedy", Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, laserdiscs, 1)
+	assert.Equal(t, "<mark>Ghostbusters</mark>", laserdiscs[0].Match.Title)
+}
+
+func TestService_FullTextIndexFollowsChanges(t *testing.T) {
+	service := setupFullTextDB(t)
+	createSearchLaserDiscs(t, service)
+
+	var jaws models.LaserDisc
+	require.NoError(t, service.db.Where("title = ?", "Jaws").First(&jaws).Error)
+	require.NoError(t, service.db.Model(&jaws).Updates(map[string]interface{}{"title": "Jaws 2", "director": "Jeannot Szwarc"}).Error)
+	assert.Equal(t, []string{"Close Encounters of the Third Kind"}, searchTitles(t, service, "director:spielberg"))
+	assert.Equal(t, []string{"Jaws 2"}, searchTitles(t, service, "szwarc"))
+
+	// Changes that aren't searched leave the index alone
+	require.NoError(t, service.db.Model(&jaws).Update("watched", true).Error)
+	assert.Equal(t, []string{"Jaws 2"}, searchTitles(t, service, "szwarc"))
+
+	require.NoError(t, service.DeleteLaserDisc(jaws.ID))
+	assert.Equal(t, []string{}, searchTitles(t, service, "jaws"))
+
+	// Rows changed while the triggers were missing are indexed again
+	require.NoError(t, service.db.Exec("DROP TRIGGER laserdiscs_fts_insert").Error)
+	require.NoError(t, service.db.Create(&models.LaserDisc{Title: "Duel"}).Error)
+	assert.Equal(t, []string{}, searchTitles(t, service, "duel"))
+	enabled, err := service.EnableFullTextSearch()
+	require.NoError(t, err)
+	assert.True(t, enabled)
+	assert.Equal(t, []string{"Duel"}, searchTitles(t, service, "duel"))
+}
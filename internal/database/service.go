@@ -2,16 +2,20 @@ package database
 
 import (
 	"errors"
-	"math/rand"
+	"log"
+	"strings"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
+	"github.com/paran01d/lddb/internal/barcode"
 	"github.com/paran01d/lddb/internal/models"
 )
 
 // Service handles all database operations
 type Service struct {
-	db *gorm.DB
+	db       *gorm.DB
+	fullText bool // searches use the FTS5 index, see EnableFullTextSearch
 }
 
 // NewService creates a new database service
@@ -21,9 +25,8 @@ func NewService(db *gorm.DB) *Service {
 
 // GetAllLaserDiscs retrieves all LaserDiscs from the database
 func (s *Service) GetAllLaserDiscs() ([]models.LaserDisc, error) {
-	var laserdiscs []models.LaserDisc
-	result := s.db.Order("title ASC").Find(&laserdiscs)
-	return laserdiscs, result.Error
+	laserdiscs, _, err := s.QueryLaserDiscs(LaserDiscQuery{})
+	return laserdiscs, err
 }
 
 // GetLaserDiscByID retrieves a LaserDisc by its ID
@@ -33,11 +36,31 @@ func (s *Service) GetLaserDiscByID(id uint) (*models.LaserDisc, error) {
 	if result.Error != nil {
 		return nil, result.Error
 	}
+	if err := s.attachProvenance(&laserdisc); err != nil {
+		return nil, err
+	}
+	if err := s.attachCopies(&laserdisc); err != nil {
+		return nil, err
+	}
+	if err := s.attachTags(&laserdisc); err != nil {
+		return nil, err
+	}
+	if err := s.attachCustomFields(&laserdisc); err != nil {
+		return nil, err
+	}
 	return &laserdisc, nil
 }
 
-// GetLaserDiscByUPC retrieves a LaserDisc by its UPC
+// GetLaserDiscByUPC retrieves a LaserDisc by its UPC, in any form that
+// normalizes to the stored GTIN-14
 func (s *Service) GetLaserDiscByUPC(upc string) (*models.LaserDisc, error) {
+	if strings.TrimSpace(upc) == "" {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if gtin, err := barcode.Normalize(upc); err == nil {
+		upc = gtin
+	}
+
 	var laserdisc models.LaserDisc
 	result := s.db.Where("upc = ?", upc).First(&laserdisc)
 	if result.Error != nil {
@@ -48,15 +71,26 @@ func (s *Service) GetLaserDiscByUPC(upc string) (*models.LaserDisc, error) {
 
 // CreateLaserDisc creates a new LaserDisc in the database
 func (s *Service) CreateLaserDisc(req *models.CreateLaserDiscRequest) (*models.LaserDisc, error) {
-	// Check if UPC already exists
-	var existing models.LaserDisc
-	result := s.db.Where("upc = ?", req.UPC).First(&existing)
-	if result.Error == nil {
-		return nil, errors.New("laserdisc with this UPC already exists")
+	// UPCs are stored as GTIN-14 so every form of a barcode is the same disc.
+	// Discs without a readable barcode are saved without one.
+	upc := strings.TrimSpace(req.UPC)
+	if upc != "" {
+		gtin, err := barcode.Normalize(upc)
+		if err != nil {
+			return nil, err
+		}
+		upc = gtin
+
+		// Check if UPC already exists
+		var existing models.LaserDisc
+		result := s.db.Where("upc = ?", upc).First(&existing)
+		if result.Error == nil {
+			return nil, ErrDuplicateUPC
+		}
 	}
 
 	laserdisc := &models.LaserDisc{
-		UPC:           req.UPC,
+		UPC:           upc,
 		Title:         req.Title,
 		Year:          req.Year,
 		Director:      req.Director,
@@ -66,14 +100,67 @@ func (s *Service) CreateLaserDisc(req *models.CreateLaserDiscRequest) (*models.L
 		Runtime:       req.Runtime,
 		CoverImageURL: req.CoverImageURL,
 		LDDBUrl:       req.LDDBUrl,
+		Reference:     req.Reference,
+		Label:         req.Label,
+		ReleaseDate:   req.ReleaseDate,
+		Country:       req.Country,
+		VideoStandard: req.VideoStandard,
+		PictureFormat: req.PictureFormat,
+		AspectRatio:   req.AspectRatio,
+		Sound:         req.Sound,
+		Chapters:      req.Chapters,
+		Price:         req.Price,
+		DiscModes:     req.DiscModes,
+		Cast:          req.Cast,
+		Producer:      req.Producer,
 		Notes:         req.Notes,
 	}
 
-	result = s.db.Create(laserdisc)
-	if result.Error != nil {
-		return nil, result.Error
+	// Record which fields came from a lookup and which were typed in
+	bySource, err := createdFieldSources(laserdisc, req.Sources)
+	if err != nil {
+		return nil, err
 	}
 
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(laserdisc).Error; err != nil {
+			return err
+		}
+		for source, fields := range bySource {
+			if err := setFieldSources(tx, laserdisc.ID, fields, source); err != nil {
+				return err
+			}
+		}
+
+		// Every LaserDisc added starts with the copy in hand
+		copy, err := newCopy(laserdisc, req.Copy)
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(copy).Error; err != nil {
+			return err
+		}
+		if err := setTags(tx, laserdisc.ID, req.Tags); err != nil {
+			return err
+		}
+		return setCustomFieldValues(tx, laserdisc.ID, req.CustomFields)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.attachProvenance(laserdisc); err != nil {
+		return nil, err
+	}
+	if err := s.attachCopies(laserdisc); err != nil {
+		return nil, err
+	}
+	if err := s.attachTags(laserdisc); err != nil {
+		return nil, err
+	}
+	if err := s.attachCustomFields(laserdisc); err != nil {
+		return nil, err
+	}
 	return laserdisc, nil
 }
 
@@ -87,7 +174,7 @@ func (s *Service) UpdateLaserDisc(id uint, req *models.UpdateLaserDiscRequest) (
 
 	// Update only non-nil fields
 	updates := make(map[string]interface{})
-	
+
 	if req.Title != nil {
 		updates["title"] = *req.Title
 	}
@@ -115,103 +202,294 @@ func (s *Service) UpdateLaserDisc(id uint, req *models.UpdateLaserDiscRequest) (
 	if req.LDDBUrl != nil {
 		updates["lddb_url"] = *req.LDDBUrl
 	}
-	if req.Watched != nil {
-		updates["watched"] = *req.Watched
+	if req.Reference != nil {
+		updates["reference"] = *req.Reference
+	}
+	if req.Label != nil {
+		updates["label"] = *req.Label
+	}
+	if req.ReleaseDate != nil {
+		updates["release_date"] = *req.ReleaseDate
+	}
+	if req.Country != nil {
+		updates["country"] = *req.Country
+	}
+	if req.VideoStandard != nil {
+		updates["video_standard"] = *req.VideoStandard
+	}
+	if req.PictureFormat != nil {
+		updates["picture_format"] = *req.PictureFormat
+	}
+	if req.AspectRatio != nil {
+		updates["aspect_ratio"] = *req.AspectRatio
+	}
+	if req.Sound != nil {
+		updates["sound"] = *req.Sound
+	}
+	if req.Chapters != nil {
+		updates["chapters"] = *req.Chapters
+	}
+	if req.Price != nil {
+		updates["price"] = *req.Price
+	}
+	if req.DiscModes != nil {
+		updates["disc_modes"] = *req.DiscModes
+	}
+	if req.Cast != nil {
+		updates["cast"] = *req.Cast
+	}
+	if req.Producer != nil {
+		updates["producer"] = *req.Producer
 	}
 	if req.Notes != nil {
 		updates["notes"] = *req.Notes
 	}
 
-	result = s.db.Model(&laserdisc).Updates(updates)
-	if result.Error != nil {
-		return nil, result.Error
+	// Remember which metadata the user changed so refreshes leave it alone
+	edited, err := changedScrapedFields(&laserdisc, updates)
+	if err != nil {
+		return nil, err
 	}
 
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&laserdisc).Updates(updates).Error; err != nil {
+			return err
+		}
+		// Watched follows the viewings, so changing it adds or removes them
+		if req.Watched != nil && *req.Watched != laserdisc.Watched {
+			if err := setWatched(tx, laserdisc.ID, *req.Watched); err != nil {
+				return err
+			}
+			laserdisc.Watched = *req.Watched
+		}
+		if req.Tags != nil {
+			if err := setTags(tx, laserdisc.ID, *req.Tags); err != nil {
+				return err
+			}
+		}
+		if err := setCustomFieldValues(tx, laserdisc.ID, req.CustomFields); err != nil {
+			return err
+		}
+		return setFieldSources(tx, laserdisc.ID, edited, models.FieldSourceManual)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.attachProvenance(&laserdisc); err != nil {
+		return nil, err
+	}
+	if err := s.attachCopies(&laserdisc); err != nil {
+		return nil, err
+	}
+	if err := s.attachTags(&laserdisc); err != nil {
+		return nil, err
+	}
+	if err := s.attachCustomFields(&laserdisc); err != nil {
+		return nil, err
+	}
 	return &laserdisc, nil
 }
 
-// DeleteLaserDisc deletes a LaserDisc from the database
+// DeleteLaserDisc deletes a LaserDisc, its copies and their inspections, its
+// viewings, tags, custom field values, images and metadata history from the
+// database
 func (s *Service) DeleteLaserDisc(id uint) error {
-	result := s.db.Delete(&models.LaserDisc{}, id)
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return gorm.ErrRecordNotFound
-	}
-	return nil
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.LaserDisc{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		for _, related := range []interface{}{&models.Copy{}, &models.Inspection{}, &models.Viewing{}, &models.RandomPick{}, &models.LaserDiscTag{}, &models.CustomFieldValue{}, &models.LaserDiscImage{}, &models.FieldProvenance{}, &models.MetadataChange{}} {
+			if err := tx.Where("laser_disc_id = ?", id).Delete(related).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-// ToggleWatched toggles the watched status of a LaserDisc
+// ToggleWatched toggles the watched status of a LaserDisc, see SetWatched
 func (s *Service) ToggleWatched(id uint) (*models.LaserDisc, error) {
 	var laserdisc models.LaserDisc
 	result := s.db.First(&laserdisc, id)
 	if result.Error != nil {
 		return nil, result.Error
 	}
+	return s.SetWatched(id, !laserdisc.Watched)
+}
 
-	laserdisc.Watched = !laserdisc.Watched
-	result = s.db.Save(&laserdisc)
+// SetWatched marks a LaserDisc watched, recording a viewing now if it has
+// none, or unwatched. Unwatching deletes only a viewing that marking it
+// watched could have recorded, and returns ErrHasViewings for any other
+// history.
+func (s *Service) SetWatched(id uint, watched bool) (*models.LaserDisc, error) {
+	var laserdisc models.LaserDisc
+	result := s.db.First(&laserdisc, id)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 
-	return &laserdisc, nil
-}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		return setWatched(tx, laserdisc.ID, watched)
+	})
+	if err != nil {
+		return nil, err
+	}
 
-// GetRandomUnwatched returns a random unwatched LaserDisc
-func (s *Service) GetRandomUnwatched() (*models.LaserDisc, error) {
-	var unwatched []models.LaserDisc
-	result := s.db.Where("watched = ?", false).Find(&unwatched)
+	result = s.db.First(&laserdisc, id)
 	if result.Error != nil {
 		return nil, result.Error
 	}
+	return &laserdisc, nil
+}
 
-	if len(unwatched) == 0 {
+// GetRandomUnwatched returns a random unwatched LaserDisc, see
+// PickRandomLaserDiscs. The pick isn't remembered, so it doesn't keep the
+// LaserDisc out of other draws.
+func (s *Service) GetRandomUnwatched() (*models.LaserDisc, error) {
+	unwatched := false
+	laserdiscs, err := s.PickRandomLaserDiscs(RandomQuery{
+		Filter:   LaserDiscQuery{Watched: &unwatched},
+		Seed:     NewRandomSeed(),
+		NoRecord: true,
+	})
+	if errors.Is(err, ErrNoRandomPick) {
 		return nil, errors.New("no unwatched laserdiscs found")
 	}
-
-	// Return random unwatched LaserDisc
-	randomIndex := rand.Intn(len(unwatched))
-	return &unwatched[randomIndex], nil
+	if err != nil {
+		return nil, err
+	}
+	return s.GetLaserDiscByID(laserdiscs[0].ID)
 }
 
-// SearchLaserDiscs searches for LaserDiscs by title, director, or genre
+// SearchLaserDiscs searches for LaserDiscs by title, director, genre, notes
+// or catalog details, best matches first when there's a full-text index
 func (s *Service) SearchLaserDiscs(query string) ([]models.LaserDisc, error) {
-	var laserdiscs []models.LaserDisc
-	searchPattern := "%" + query + "%"
-	
-	result := s.db.Where(
-		"title LIKE ? OR director LIKE ? OR genre LIKE ?",
-		searchPattern, searchPattern, searchPattern,
-	).Order("title ASC").Find(&laserdiscs)
-	
-	return laserdiscs, result.Error
+	laserdiscs, _, err := s.QueryLaserDiscs(LaserDiscQuery{Search: query})
+	return laserdiscs, err
 }
 
 // GetStats returns collection statistics
 func (s *Service) GetStats() (map[string]interface{}, error) {
 	var total, watched, unwatched int64
-	
+
 	// Get total count
 	result := s.db.Model(&models.LaserDisc{}).Count(&total)
 	if result.Error != nil {
 		return nil, result.Error
 	}
-	
+
 	// Get watched count
 	result = s.db.Model(&models.LaserDisc{}).Where("watched = ?", true).Count(&watched)
 	if result.Error != nil {
 		return nil, result.Error
 	}
-	
+
 	unwatched = total - watched
-	
+
 	stats := map[string]interface{}{
 		"total":     total,
 		"watched":   watched,
 		"unwatched": unwatched,
 	}
-	
+
+	if err := s.copyStats(stats); err != nil {
+		return nil, err
+	}
+
 	return stats, nil
-}
\ No newline at end of file
+}
+
+// GetLookupCacheEntry retrieves a cached lookup by kind and normalized key
+func (s *Service) GetLookupCacheEntry(kind, key string) (*models.LookupCacheEntry, error) {
+	var entry models.LookupCacheEntry
+	result := s.db.Where("kind = ? AND key = ?", kind, key).First(&entry)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &entry, nil
+}
+
+// SaveLookupCacheEntry stores a lookup, replacing any previous entry for the same key
+func (s *Service) SaveLookupCacheEntry(entry *models.LookupCacheEntry) error {
+	result := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "kind"}, {Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"result", "detail_html", "found", "fetched_at"}),
+	}).Create(entry)
+	return result.Error
+}
+
+// PurgeLookupCache deletes cached lookups. An empty kind purges every entry,
+// an empty key purges every entry of that kind.
+func (s *Service) PurgeLookupCache(kind, key string) (int64, error) {
+	query := s.db.Session(&gorm.Session{AllowGlobalUpdate: true})
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if key != "" {
+		query = query.Where("key = ?", key)
+	}
+
+	result := query.Delete(&models.LookupCacheEntry{})
+	return result.RowsAffected, result.Error
+}
+
+// NormalizeUPCs rewrites stored UPCs to their canonical GTIN-14. UPCs that
+// aren't valid barcodes, or that would collide with another disc's, are left
+// alone and logged. It returns the number of LaserDiscs updated.
+func (s *Service) NormalizeUPCs() (int, error) {
+	var laserdiscs []models.LaserDisc
+	if err := s.db.Select("id", "upc").Where("upc != ''").Find(&laserdiscs).Error; err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, ld := range laserdiscs {
+		gtin, err := barcode.Normalize(ld.UPC)
+		if err != nil {
+			log.Printf("Warning: LaserDisc %d has an invalid UPC %q: %v", ld.ID, ld.UPC, err)
+			continue
+		}
+		if gtin == ld.UPC {
+			continue
+		}
+
+		var count int64
+		s.db.Model(&models.LaserDisc{}).Where("upc = ? AND id <> ?", gtin, ld.ID).Count(&count)
+		if count > 0 {
+			log.Printf("Warning: LaserDisc %d UPC %q duplicates another disc's, not normalizing", ld.ID, ld.UPC)
+			continue
+		}
+
+		if err := s.db.Model(&models.LaserDisc{}).Where("id = ?", ld.ID).UpdateColumn("upc", gtin).Error; err != nil {
+			return updated, err
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// LaserDiscsWithUnmirroredCovers returns the IDs of LaserDiscs whose cover URL
+// hasn't been mirrored to the cover store
+func (s *Service) LaserDiscsWithUnmirroredCovers() ([]uint, error) {
+	var ids []uint
+	result := s.db.Model(&models.LaserDisc{}).
+		Where("COALESCE(cover_image_url, '') <> COALESCE(cover_source, '')").
+		Order("id ASC").
+		Pluck("id", &ids)
+	return ids, result.Error
+}
+
+// SetMirroredCover records the files a LaserDisc's cover URL was mirrored to.
+// It doesn't touch the updated date since the LaserDisc itself is unchanged.
+func (s *Service) SetMirroredCover(id uint, coverURL, file, thumb string) error {
+	return s.db.Model(&models.LaserDisc{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"cover_source": coverURL,
+		"cover_file":   file,
+		"cover_thumb":  thumb,
+	}).Error
+}
@@ -2,31 +2,40 @@ package database
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
+	"github.com/paran01d/lddb/internal/barcode"
+	"github.com/paran01d/lddb/internal/migrations"
 	"github.com/paran01d/lddb/internal/models"
 )
 
 // setupTestDB creates an in-memory SQLite database for testing
-func setupTestDB(t *testing.T) *Service {
+func setupTestDB(t testing.TB) *Service {
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
 	require.NoError(t, err)
 
-	// Auto-migrate the schema
-	err = db.AutoMigrate(&models.LaserDisc{})
+	// Create the schema the way the server does
+	migrator, err := migrations.New(db)
+	require.NoError(t, err)
+	_, err = migrator.Up()
 	require.NoError(t, err)
 
-	return NewService(db)
+	// Builds tagged sqlite_fts5 search with the full-text index, others with LIKE
+	service := NewService(db)
+	_, err = service.EnableFullTextSearch()
+	require.NoError(t, err)
+	return service
 }
 
 // createTestLaserDisc creates a test LaserDisc
 func createTestLaserDisc() *models.CreateLaserDiscRequest {
 	return &models.CreateLaserDiscRequest{
-		UPC:           "1234567890",
+		UPC:           "012345678905",
 		Title:         "Test Movie",
 		Year:          1995,
 		Director:      "Test Director",
@@ -47,7 +56,7 @@ func TestService_CreateLaserDisc(t *testing.T) {
 	laserdisc, err := service.CreateLaserDisc(req)
 	require.NoError(t, err)
 	assert.NotZero(t, laserdisc.ID)
-	assert.Equal(t, req.UPC, laserdisc.UPC)
+	assert.Equal(t, "00012345678905", laserdisc.UPC) // stored as GTIN-14
 	assert.Equal(t, req.Title, laserdisc.Title)
 	assert.Equal(t, req.Year, laserdisc.Year)
 	assert.Equal(t, req.Director, laserdisc.Director)
@@ -72,6 +81,7 @@ func TestService_CreateLaserDisc_DuplicateUPC(t *testing.T) {
 	// Try to create another with same UPC
 	_, err = service.CreateLaserDisc(req)
 	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrDuplicateUPC)
 	assert.Contains(t, err.Error(), "already exists")
 }
 
@@ -123,11 +133,11 @@ func TestService_GetAllLaserDiscs(t *testing.T) {
 
 	// Create some test LaserDiscs
 	req1 := createTestLaserDisc()
-	req1.UPC = "1111111111"
+	req1.UPC = "111111111117"
 	req1.Title = "Movie A"
 
 	req2 := createTestLaserDisc()
-	req2.UPC = "2222222222"
+	req2.UPC = "222222222224"
 	req2.Title = "Movie B"
 
 	_, err = service.CreateLaserDisc(req1)
@@ -231,15 +241,17 @@ func TestService_GetRandomUnwatched(t *testing.T) {
 
 	// Create some test LaserDiscs
 	req1 := createTestLaserDisc()
-	req1.UPC = "1111111111"
+	req1.UPC = "111111111117"
 	req1.Title = "Unwatched Movie 1"
 
 	req2 := createTestLaserDisc()
-	req2.UPC = "2222222222"
+	req2.UPC = "222222222224"
 	req2.Title = "Unwatched Movie 2"
 
+	req2.Tags = []string{"noir"}
+
 	req3 := createTestLaserDisc()
-	req3.UPC = "3333333333"
+	req3.UPC = "333333333331"
 	req3.Title = "Watched Movie"
 
 	disc1, err := service.CreateLaserDisc(req1)
@@ -258,6 +270,12 @@ func TestService_GetRandomUnwatched(t *testing.T) {
 	require.NoError(t, err)
 	assert.False(t, random.Watched)
 	assert.True(t, random.ID == disc1.ID || random.ID == disc2.ID)
+	assert.NotNil(t, random.Tags)
+
+	// Its picks aren't remembered, so they don't keep LaserDiscs out of other draws
+	var picks int64
+	require.NoError(t, service.db.Model(&models.RandomPick{}).Count(&picks).Error)
+	assert.Zero(t, picks)
 }
 
 func TestService_SearchLaserDiscs(t *testing.T) {
@@ -265,19 +283,19 @@ func TestService_SearchLaserDiscs(t *testing.T) {
 
 	// Create some test LaserDiscs
 	req1 := createTestLaserDisc()
-	req1.UPC = "1111111111"
+	req1.UPC = "111111111117"
 	req1.Title = "Star Wars"
 	req1.Director = "George Lucas"
 	req1.Genre = "Sci-Fi"
 
 	req2 := createTestLaserDisc()
-	req2.UPC = "2222222222"
+	req2.UPC = "222222222224"
 	req2.Title = "Star Trek"
 	req2.Director = "J.J. Abrams"
 	req2.Genre = "Sci-Fi"
 
 	req3 := createTestLaserDisc()
-	req3.UPC = "3333333333"
+	req3.UPC = "333333333331"
 	req3.Title = "The Matrix"
 	req3.Director = "Wachowski Sisters"
 	req3.Genre = "Action"
@@ -323,11 +341,11 @@ func TestService_GetStats(t *testing.T) {
 
 	// Create some test LaserDiscs
 	req1 := createTestLaserDisc()
-	req1.UPC = "1111111111"
+	req1.UPC = "111111111117"
 	req2 := createTestLaserDisc()
-	req2.UPC = "2222222222"
+	req2.UPC = "222222222224"
 	req3 := createTestLaserDisc()
-	req3.UPC = "3333333333"
+	req3.UPC = "333333333331"
 
 	disc1, err := service.CreateLaserDisc(req1)
 	require.NoError(t, err)
@@ -348,4 +366,230 @@ func TestService_GetStats(t *testing.T) {
 	assert.Equal(t, int64(3), stats["total"])
 	assert.Equal(t, int64(2), stats["watched"])
 	assert.Equal(t, int64(1), stats["unwatched"])
-}
\ No newline at end of file
+}
+
+func TestService_LookupCache(t *testing.T) {
+	service := setupTestDB(t)
+
+	// Missing entries return an error
+	_, err := service.GetLookupCacheEntry(models.LookupKindUPC, "1234567890")
+	assert.Error(t, err)
+
+	fetchedAt := time.Now().Add(-time.Hour).Truncate(time.Second)
+	err = service.SaveLookupCacheEntry(&models.LookupCacheEntry{
+		Kind:      models.LookupKindUPC,
+		Key:       "1234567890",
+		Result:    `{"title":"Old Title"}`,
+		Found:     true,
+		FetchedAt: fetchedAt,
+	})
+	require.NoError(t, err)
+
+	entry, err := service.GetLookupCacheEntry(models.LookupKindUPC, "1234567890")
+	require.NoError(t, err)
+	assert.Equal(t, `{"title":"Old Title"}`, entry.Result)
+	assert.True(t, entry.Found)
+	assert.True(t, fetchedAt.Equal(entry.FetchedAt))
+
+	// Saving the same key again replaces the entry
+	err = service.SaveLookupCacheEntry(&models.LookupCacheEntry{
+		Kind:       models.LookupKindUPC,
+		Key:        "1234567890",
+		Result:     `{"title":"New Title"}`,
+		DetailHTML: "<html></html>",
+		Found:      true,
+		FetchedAt:  time.Now(),
+	})
+	require.NoError(t, err)
+
+	entry, err = service.GetLookupCacheEntry(models.LookupKindUPC, "1234567890")
+	require.NoError(t, err)
+	assert.Equal(t, `{"title":"New Title"}`, entry.Result)
+	assert.Equal(t, "<html></html>", entry.DetailHTML)
+
+	// The same key under another kind is a separate entry
+	_, err = service.GetLookupCacheEntry(models.LookupKindReference, "1234567890")
+	assert.Error(t, err)
+}
+
+func TestService_PurgeLookupCache(t *testing.T) {
+	service := setupTestDB(t)
+
+	for _, entry := range []models.LookupCacheEntry{
+		{Kind: models.LookupKindUPC, Key: "1111111111", Result: "{}", FetchedAt: time.Now()},
+		{Kind: models.LookupKindUPC, Key: "2222222222", Result: "{}", FetchedAt: time.Now()},
+		{Kind: models.LookupKindReference, Key: "SF098-1117", Result: "{}", FetchedAt: time.Now()},
+	} {
+		entry := entry
+		require.NoError(t, service.SaveLookupCacheEntry(&entry))
+	}
+
+	// Purge a single key
+	purged, err := service.PurgeLookupCache(models.LookupKindUPC, "1111111111")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	_, err = service.GetLookupCacheEntry(models.LookupKindUPC, "1111111111")
+	assert.Error(t, err)
+
+	// Purge everything that's left
+	purged, err = service.PurgeLookupCache("", "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+}
+
+func TestService_PressingDetails(t *testing.T) {
+	service := setupTestDB(t)
+	req := createTestLaserDisc()
+	req.Reference = "SF098-1117"
+	req.Label = "Fox Video"
+	req.Country = "Japan"
+	req.VideoStandard = "NTSC"
+	req.PictureFormat = "LBX"
+	req.AspectRatio = "2.20:1"
+	req.Sound = "Digital/Analog"
+	req.Chapters = 27
+	req.DiscModes = "CLV/CLV"
+	req.Cast = "Mark Hamill, Harrison Ford"
+
+	created, err := service.CreateLaserDisc(req)
+	require.NoError(t, err)
+
+	retrieved, err := service.GetLaserDiscByID(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "SF098-1117", retrieved.Reference)
+	assert.Equal(t, "Fox Video", retrieved.Label)
+	assert.Equal(t, "Japan", retrieved.Country)
+	assert.Equal(t, "NTSC", retrieved.VideoStandard)
+	assert.Equal(t, "LBX", retrieved.PictureFormat)
+	assert.Equal(t, "2.20:1", retrieved.AspectRatio)
+	assert.Equal(t, "Digital/Analog", retrieved.Sound)
+	assert.Equal(t, 27, retrieved.Chapters)
+	assert.Equal(t, "CLV/CLV", retrieved.DiscModes)
+	assert.Equal(t, "Mark Hamill, Harrison Ford", retrieved.Cast)
+
+	// Pressing details can be corrected individually
+	pal := "PAL"
+	chapters := 30
+	updated, err := service.UpdateLaserDisc(created.ID, &models.UpdateLaserDiscRequest{
+		VideoStandard: &pal,
+		Chapters:      &chapters,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "PAL", updated.VideoStandard)
+	assert.Equal(t, 30, updated.Chapters)
+	assert.Equal(t, "LBX", updated.PictureFormat)
+}
+
+func TestService_CreateLaserDisc_NormalizesUPC(t *testing.T) {
+	service := setupTestDB(t)
+	req := createTestLaserDisc()
+	req.UPC = "0 12345 67890 5"
+
+	created, err := service.CreateLaserDisc(req)
+	require.NoError(t, err)
+	assert.Equal(t, "00012345678905", created.UPC)
+
+	// The same barcode as EAN-13 or GTIN-14 is the same disc
+	for _, upc := range []string{"0012345678905", "00012345678905"} {
+		req.UPC = upc
+		_, err = service.CreateLaserDisc(req)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "already exists")
+
+		retrieved, err := service.GetLaserDiscByUPC(upc)
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, retrieved.ID)
+	}
+
+	// Invalid barcodes are rejected
+	req.UPC = "012345678904"
+	_, err = service.CreateLaserDisc(req)
+	assert.ErrorIs(t, err, barcode.ErrInvalid)
+}
+
+func TestService_CreateLaserDisc_WithoutUPC(t *testing.T) {
+	service := setupTestDB(t)
+
+	// Discs with no readable barcode are saved without one, as many as needed
+	for _, upc := range []string{"", "  "} {
+		req := createTestLaserDisc()
+		req.UPC = upc
+		created, err := service.CreateLaserDisc(req)
+		require.NoError(t, err)
+		assert.Empty(t, created.UPC)
+	}
+	_, err := service.GetLaserDiscByUPC("")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// And don't stop a disc with a barcode being added
+	_, err = service.CreateLaserDisc(createTestLaserDisc())
+	require.NoError(t, err)
+	_, err = service.CreateLaserDisc(createTestLaserDisc())
+	assert.ErrorIs(t, err, ErrDuplicateUPC)
+}
+
+func TestService_NormalizeUPCs(t *testing.T) {
+	service := setupTestDB(t)
+
+	// Rows saved before normalization existed
+	require.NoError(t, service.db.Create(&models.LaserDisc{UPC: "012345678905", Title: "Old"}).Error)
+	require.NoError(t, service.db.Create(&models.LaserDisc{UPC: "00012345678905", Title: "Already Canonical"}).Error)
+	require.NoError(t, service.db.Create(&models.LaserDisc{UPC: "111111111117", Title: "Other"}).Error)
+	require.NoError(t, service.db.Create(&models.LaserDisc{UPC: "not-a-barcode", Title: "Invalid"}).Error)
+	require.NoError(t, service.db.Create(&models.LaserDisc{Title: "No Barcode"}).Error)
+
+	updated, err := service.NormalizeUPCs()
+	require.NoError(t, err)
+	assert.Equal(t, 1, updated)
+
+	other, err := service.GetLaserDiscByUPC("00111111111117")
+	require.NoError(t, err)
+	assert.Equal(t, "Other", other.Title)
+
+	// A UPC colliding with an already canonical one is left for the user to resolve
+	var old models.LaserDisc
+	require.NoError(t, service.db.Where("title = ?", "Old").First(&old).Error)
+	assert.Equal(t, "012345678905", old.UPC)
+
+	var invalid models.LaserDisc
+	require.NoError(t, service.db.Where("title = ?", "Invalid").First(&invalid).Error)
+	assert.Equal(t, "not-a-barcode", invalid.UPC)
+}
+
+func TestService_MirroredCovers(t *testing.T) {
+	service := setupTestDB(t)
+
+	withCover := createTestLaserDisc()
+	created, err := service.CreateLaserDisc(withCover)
+	require.NoError(t, err)
+
+	noCover := createTestLaserDisc()
+	noCover.UPC = "111111111117"
+	noCover.CoverImageURL = ""
+	_, err = service.CreateLaserDisc(noCover)
+	require.NoError(t, err)
+
+	ids, err := service.LaserDiscsWithUnmirroredCovers()
+	require.NoError(t, err)
+	assert.Equal(t, []uint{created.ID}, ids)
+
+	require.NoError(t, service.SetMirroredCover(created.ID, withCover.CoverImageURL, "full.jpg", "thumb.jpg"))
+	ids, err = service.LaserDiscsWithUnmirroredCovers()
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	retrieved, err := service.GetLaserDiscByID(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "full.jpg", retrieved.CoverFile)
+	assert.Equal(t, "thumb.jpg", retrieved.CoverThumb)
+	assert.Equal(t, created.UpdatedDate.Unix(), retrieved.UpdatedDate.Unix())
+
+	// Changing the cover URL needs a new mirror
+	newURL := "https://example.com/other.jpg"
+	_, err = service.UpdateLaserDisc(created.ID, &models.UpdateLaserDiscRequest{CoverImageURL: &newURL})
+	require.NoError(t, err)
+	ids, err = service.LaserDiscsWithUnmirroredCovers()
+	require.NoError(t, err)
+	assert.Equal(t, []uint{created.ID}, ids)
+}
@@ -0,0 +1,258 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// ErrInvalidTag is returned for a tag without a name, or with a malformed
+// colour
+var ErrInvalidTag = errors.New("invalid tag")
+
+// ErrDuplicateTag is returned when a tag would have the same name as another,
+// ignoring case
+var ErrDuplicateTag = errors.New("a tag with this name already exists")
+
+// maxTagName is the longest a tag's name can be
+const maxTagName = 50
+
+// tagColor matches a #rrggbb colour
+var tagColor = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// tagPalette are the colours new tags are given in turn
+var tagPalette = []string{"#c0392b", "#d35400", "#f39c12", "#27ae60", "#16a085", "#2980b9", "#8e44ad", "#7f8c8d"}
+
+// GetTags returns every tag by name, with how many LaserDiscs have it
+func (s *Service) GetTags() ([]models.Tag, error) {
+	tags := []models.Tag{}
+	if err := s.db.Order("name").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+
+	var counts []struct {
+		TagID uint
+		Count int64
+	}
+	err := s.db.Model(&models.LaserDiscTag{}).
+		Select("tag_id, count(*) AS count").
+		Group("tag_id").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]int64, len(counts))
+	for _, row := range counts {
+		byID[row.TagID] = row.Count
+	}
+	for i := range tags {
+		count := byID[tags[i].ID]
+		tags[i].Count = &count
+	}
+	return tags, nil
+}
+
+// CreateTag adds a tag, which isn't on any LaserDisc yet
+func (s *Service) CreateTag(req *models.TagRequest) (*models.Tag, error) {
+	tag := &models.Tag{}
+	applyTagRequest(tag, req)
+	if err := createTag(s.db, tag); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// UpdateTag renames or recolours a tag
+func (s *Service) UpdateTag(id uint, req *models.TagRequest) (*models.Tag, error) {
+	var tag models.Tag
+	if err := s.db.First(&tag, id).Error; err != nil {
+		return nil, err
+	}
+
+	applyTagRequest(&tag, req)
+	if err := validateTag(&tag); err != nil {
+		return nil, err
+	}
+	if err := checkTagName(s.db, &tag); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(&tag).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// DeleteTag deletes a tag, taking it off every LaserDisc
+func (s *Service) DeleteTag(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.Tag{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("tag_id = ?", id).Delete(&models.LaserDiscTag{}).Error
+	})
+}
+
+// applyTagRequest copies the fields given in a request onto a tag
+func applyTagRequest(tag *models.Tag, req *models.TagRequest) {
+	if req.Name != nil {
+		tag.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Color != nil {
+		tag.Color = strings.ToLower(strings.TrimSpace(*req.Color))
+	}
+}
+
+// validateTag checks a tag's name and colour
+func validateTag(tag *models.Tag) error {
+	if tag.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidTag)
+	}
+	if len([]rune(tag.Name)) > maxTagName {
+		return fmt.Errorf("%w: name is longer than %d characters", ErrInvalidTag, maxTagName)
+	}
+	if !tagColor.MatchString(tag.Color) {
+		return fmt.Errorf("%w: color %q, use one like #c0392b", ErrInvalidTag, tag.Color)
+	}
+	return nil
+}
+
+// checkTagName returns ErrDuplicateTag when another tag has the same name
+func checkTagName(db *gorm.DB, tag *models.Tag) error {
+	var count int64
+	if err := db.Model(&models.Tag{}).Where("name = ? AND id != ?", tag.Name, tag.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: %s", ErrDuplicateTag, tag.Name)
+	}
+	return nil
+}
+
+// createTag adds a valid tag, giving it the next colour of tagPalette
+// unless it has one
+func createTag(db *gorm.DB, tag *models.Tag) error {
+	if tag.Color == "" {
+		var count int64
+		if err := db.Model(&models.Tag{}).Count(&count).Error; err != nil {
+			return err
+		}
+		tag.Color = tagPalette[count%int64(len(tagPalette))]
+	}
+	if err := validateTag(tag); err != nil {
+		return err
+	}
+	if err := checkTagName(db, tag); err != nil {
+		return err
+	}
+	return db.Create(tag).Error
+}
+
+// setTags replaces a LaserDisc's tags with those named, ignoring case,
+// creating any that don't exist yet
+func setTags(tx *gorm.DB, laserdiscID uint, names []string) error {
+	if err := tx.Where("laser_disc_id = ?", laserdiscID).Delete(&models.LaserDiscTag{}).Error; err != nil {
+		return err
+	}
+
+	tagged := make(map[uint]bool)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		var tag models.Tag
+		err := tx.Where("name = ?", name).First(&tag).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			tag = models.Tag{Name: name}
+			err = createTag(tx, &tag)
+		}
+		if err != nil {
+			return err
+		}
+		if tagged[tag.ID] {
+			continue
+		}
+		tagged[tag.ID] = true
+		if err := tx.Create(&models.LaserDiscTag{LaserDiscID: laserdiscID, TagID: tag.ID}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AttachTags fills in the Tags of each LaserDisc
+func (s *Service) AttachTags(laserdiscs []models.LaserDisc) error {
+	ids := make([]uint, len(laserdiscs))
+	for i := range laserdiscs {
+		ids[i] = laserdiscs[i].ID
+	}
+
+	byID, err := s.tagsFor(ids)
+	if err != nil {
+		return err
+	}
+	for i := range laserdiscs {
+		laserdiscs[i].Tags = byID[laserdiscs[i].ID]
+	}
+	return nil
+}
+
+// attachTags fills in the Tags of one LaserDisc
+func (s *Service) attachTags(laserdisc *models.LaserDisc) error {
+	byID, err := s.tagsFor([]uint{laserdisc.ID})
+	if err != nil {
+		return err
+	}
+	laserdisc.Tags = byID[laserdisc.ID]
+	return nil
+}
+
+// tagsFor loads the tags of the given LaserDiscs by name, keyed by LaserDisc
+// ID. Each ID has a list, empty when it has no tags.
+func (s *Service) tagsFor(ids []uint) (map[uint][]models.Tag, error) {
+	byID := make(map[uint][]models.Tag, len(ids))
+	for _, id := range ids {
+		byID[id] = []models.Tag{}
+	}
+
+	// There are few enough tags to load them all
+	var tags []models.Tag
+	if err := s.db.Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	if len(tags) == 0 {
+		return byID, nil
+	}
+	tagsByID := make(map[uint]models.Tag, len(tags))
+	for _, tag := range tags {
+		tagsByID[tag.ID] = tag
+	}
+
+	// In batches, to stay under SQLite's variable limit
+	for start := 0; start < len(ids); start += 500 {
+		end := min(start+500, len(ids))
+		var links []models.LaserDiscTag
+		if err := s.db.Where("laser_disc_id IN ?", ids[start:end]).Find(&links).Error; err != nil {
+			return nil, err
+		}
+		for _, link := range links {
+			byID[link.LaserDiscID] = append(byID[link.LaserDiscID], tagsByID[link.TagID])
+		}
+	}
+	for _, tags := range byID {
+		sort.Slice(tags, func(i, j int) bool {
+			return strings.ToLower(tags[i].Name) < strings.ToLower(tags[j].Name)
+		})
+	}
+	return byID, nil
+}
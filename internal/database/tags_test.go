@@ -0,0 +1,130 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// tagNames returns the names of a LaserDisc's tags
+func tagNames(laserdisc *models.LaserDisc) []string {
+	names := make([]string, len(laserdisc.Tags))
+	for i, tag := range laserdisc.Tags {
+		names[i] = tag.Name
+	}
+	return names
+}
+
+func TestService_Tags(t *testing.T) {
+	service := setupTestDB(t)
+
+	thx, err := service.CreateTag(&models.TagRequest{Name: stringPtr(" THX "), Color: stringPtr("#FF0000")})
+	require.NoError(t, err)
+	assert.Equal(t, "THX", thx.Name)
+	assert.Equal(t, "#ff0000", thx.Color)
+
+	invalid := []models.TagRequest{
+		{},
+		{Name: stringPtr("   ")},
+		{Name: stringPtr("Red"), Color: stringPtr("red")},
+	}
+	for _, req := range invalid {
+		_, err := service.CreateTag(&req)
+		assert.ErrorIs(t, err, ErrInvalidTag, "%+v", req)
+	}
+	_, err = service.CreateTag(&models.TagRequest{Name: stringPtr("thx")})
+	assert.ErrorIs(t, err, ErrDuplicateTag)
+
+	// Tags named on a LaserDisc are matched ignoring case, or created with a
+	// colour of their own
+	req := createTestLaserDisc()
+	req.Tags = []string{"Criterion", "thx", "criterion", " "}
+	laserdisc, err := service.CreateLaserDisc(req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Criterion", "THX"}, tagNames(laserdisc))
+	assert.Regexp(t, tagColor, laserdisc.Tags[0].Color)
+
+	tags, err := service.GetTags()
+	require.NoError(t, err)
+	require.Len(t, tags, 2)
+	assert.Equal(t, "Criterion", tags[0].Name)
+	assert.Equal(t, int64(1), *tags[0].Count)
+
+	// Updates replace the tags, and leave them alone when they're not given
+	tags2 := []string{"Japanese import"}
+	updated, err := service.UpdateLaserDisc(laserdisc.ID, &models.UpdateLaserDiscRequest{Tags: &tags2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Japanese import"}, tagNames(updated))
+	updated, err = service.UpdateLaserDisc(laserdisc.ID, &models.UpdateLaserDiscRequest{Notes: stringPtr("Boxed set")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Japanese import"}, tagNames(updated))
+
+	// Renaming can't clash with another tag
+	_, err = service.UpdateTag(thx.ID, &models.TagRequest{Name: stringPtr("CRITERION")})
+	assert.ErrorIs(t, err, ErrDuplicateTag)
+	renamed, err := service.UpdateTag(thx.ID, &models.TagRequest{Name: stringPtr("THX Certified")})
+	require.NoError(t, err)
+	assert.Equal(t, "#ff0000", renamed.Color)
+	_, err = service.UpdateTag(999999, &models.TagRequest{})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// Deleting a tag takes it off the LaserDiscs, and deleting a LaserDisc
+	// its tags
+	tags, err = service.GetTags()
+	require.NoError(t, err)
+	for _, tag := range tags {
+		if tag.Name == "Japanese import" {
+			require.NoError(t, service.DeleteTag(tag.ID))
+			assert.ErrorIs(t, service.DeleteTag(tag.ID), gorm.ErrRecordNotFound)
+		}
+	}
+	retrieved, err := service.GetLaserDiscByID(laserdisc.ID)
+	require.NoError(t, err)
+	assert.Empty(t, retrieved.Tags)
+	assert.NotNil(t, retrieved.Tags)
+
+	tags2 = []string{"Criterion"}
+	_, err = service.UpdateLaserDisc(laserdisc.ID, &models.UpdateLaserDiscRequest{Tags: &tags2})
+	require.NoError(t, err)
+	require.NoError(t, service.DeleteLaserDisc(laserdisc.ID))
+	var count int64
+	require.NoError(t, service.db.Model(&models.LaserDiscTag{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestService_QueryLaserDiscs_Tags(t *testing.T) {
+	service := setupTestDB(t)
+	laserdiscs := createRandomLaserDiscs(t, service, 4)
+
+	tag := func(i int, names ...string) {
+		_, err := service.UpdateLaserDisc(laserdiscs[i].ID, &models.UpdateLaserDiscRequest{Tags: &names})
+		require.NoError(t, err)
+	}
+	tag(0, "THX", "Criterion")
+	tag(1, "criterion")
+	tag(2, "Anamorphic")
+
+	query := func(q LaserDiscQuery) []string {
+		page, _, err := service.QueryLaserDiscs(q)
+		require.NoError(t, err)
+		return titles(page)
+	}
+
+	// Every tag must match, ignoring case
+	assert.Equal(t, []string{"Disc 0", "Disc 1"}, query(LaserDiscQuery{Tags: []string{"CRITERION"}}))
+	assert.Equal(t, []string{"Disc 0"}, query(LaserDiscQuery{Tags: []string{"criterion", "thx"}}))
+	assert.Empty(t, query(LaserDiscQuery{Tags: []string{"Laserdisc Club"}}))
+
+	// Sorted by tag names, untagged last either way
+	assert.Equal(t, []string{"Disc 2", "Disc 1", "Disc 0", "Disc 3"}, query(LaserDiscQuery{Sort: "tags"}))
+	assert.Equal(t, []string{"Disc 0", "Disc 1", "Disc 2", "Disc 3"}, query(LaserDiscQuery{Sort: "tags", Desc: true}))
+
+	// And picked by tag at random
+	picks, err := service.PickRandomLaserDiscs(RandomQuery{Filter: LaserDiscQuery{Tags: []string{"Anamorphic"}}, Count: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Disc 2"}, titles(picks))
+}
@@ -0,0 +1,92 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// ValueFields are the free-text fields whose values can be suggested and
+// merged
+var ValueFields = []string{"title", "director", "genre"}
+
+// ErrInvalidField is returned for a field that isn't one of ValueFields
+var ErrInvalidField = errors.New("invalid field")
+
+// valueField checks that field is one of ValueFields
+func valueField(field string) error {
+	for _, f := range ValueFields {
+		if f == field {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %q, use one of %s", ErrInvalidField, field, strings.Join(ValueFields, ", "))
+}
+
+// SuggestValues returns up to limit distinct values of a field starting
+// with prefix, ignoring case, the most used first. Values differing only in
+// case are listed separately, so they can be merged.
+func (s *Service) SuggestValues(field, prefix string, limit int) ([]models.ValueCount, error) {
+	if err := valueField(field); err != nil {
+		return nil, err
+	}
+
+	// The prefix is matched with LIKE, which is case-insensitive like the
+	// field's NOCASE index
+	values := []models.ValueCount{}
+	err := s.db.Model(&models.LaserDisc{}).
+		Select(field+" AS value, count(*) AS count").
+		Where(field+` LIKE ? ESCAPE '\'`, likeEscaper.Replace(prefix)+"%").
+		Where(field + " != ''").
+		Group(field).
+		Order("count DESC, value").
+		Limit(limit).
+		Scan(&values).Error
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// MergeValues replaces one value of a field with another on every LaserDisc
+// that has it exactly, returning how many changed. The new values count as
+// edited by hand, so refreshes don't put the old ones back.
+func (s *Service) MergeValues(field, from, into string) (int64, error) {
+	if err := valueField(field); err != nil {
+		return 0, err
+	}
+
+	var merged int64
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var ids []uint
+		if err := tx.Model(&models.LaserDisc{}).Where(field+" = ?", from).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		result := tx.Model(&models.LaserDisc{}).Where("id IN ?", ids).
+			Updates(map[string]interface{}{field: into, "updated_date": time.Now()})
+		if result.Error != nil {
+			return result.Error
+		}
+		merged = result.RowsAffected
+
+		for _, id := range ids {
+			if err := setFieldSources(tx, id, []string{field}, models.FieldSourceManual); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return merged, nil
+}
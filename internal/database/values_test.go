@@ -0,0 +1,96 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// createGenreLaserDiscs adds LaserDiscs with inconsistently named genres
+func createGenreLaserDiscs(t *testing.T, service *Service) {
+	genres := []string{"Sci-Fi", "Science Fiction", "Sci-Fi", "SciFi", "sci-fi", "Sci-Fi", "Horror", "", "Science Fiction", "100% Comedy"}
+	laserdiscs := make([]models.LaserDisc, len(genres))
+	for i, genre := range genres {
+		laserdiscs[i] = models.LaserDisc{UPC: string(rune('a' + i)), Title: "Title", Genre: genre}
+	}
+	require.NoError(t, service.db.Create(&laserdiscs).Error)
+}
+
+func TestService_SuggestValues(t *testing.T) {
+	service := setupTestDB(t)
+	createGenreLaserDiscs(t, service)
+
+	values, err := service.SuggestValues("genre", "sci", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ValueCount{
+		{Value: "Sci-Fi", Count: 3},
+		{Value: "Science Fiction", Count: 2},
+		{Value: "SciFi", Count: 1},
+		{Value: "sci-fi", Count: 1},
+	}, values)
+
+	values, err = service.SuggestValues("genre", "SCIENCE", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ValueCount{{Value: "Science Fiction", Count: 2}}, values)
+
+	values, err = service.SuggestValues("genre", "", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ValueCount{{Value: "Sci-Fi", Count: 3}, {Value: "Science Fiction", Count: 2}}, values, "empty values aren't suggested")
+
+	values, err = service.SuggestValues("genre", "100%", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ValueCount{{Value: "100% Comedy", Count: 1}}, values)
+	values, err = service.SuggestValues("genre", "%", 10)
+	require.NoError(t, err)
+	assert.Empty(t, values, "wildcards in the prefix are literal")
+	assert.NotNil(t, values)
+
+	_, err = service.SuggestValues("notes", "", 10)
+	assert.ErrorIs(t, err, ErrInvalidField)
+	_, err = service.SuggestValues("genre; DROP TABLE laserdiscs", "", 10)
+	assert.ErrorIs(t, err, ErrInvalidField)
+}
+
+func TestService_SuggestValuesUsesIndex(t *testing.T) {
+	service := setupTestDB(t)
+
+	for _, field := range ValueFields {
+		var plan []struct{ Detail string }
+		require.NoError(t, service.db.Raw("EXPLAIN QUERY PLAN SELECT "+field+", count(*) FROM laserdiscs WHERE "+field+` LIKE ? ESCAPE '\' GROUP BY `+field, "sci%").Scan(&plan).Error)
+		require.NotEmpty(t, plan)
+		assert.Contains(t, plan[0].Detail, "INDEX idx_laserdiscs_"+field+"_nocase", field)
+	}
+}
+
+func TestService_MergeValues(t *testing.T) {
+	service := setupTestDB(t)
+	createGenreLaserDiscs(t, service)
+
+	merged, err := service.MergeValues("genre", "SciFi", "Sci-Fi")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), merged)
+	merged, err = service.MergeValues("genre", "sci-fi", "Sci-Fi")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), merged, "only the exact value is merged")
+
+	values, err := service.SuggestValues("genre", "sci", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []models.ValueCount{{Value: "Sci-Fi", Count: 5}, {Value: "Science Fiction", Count: 2}}, values)
+
+	// Merged values count as edited by hand
+	var laserdisc models.LaserDisc
+	require.NoError(t, service.db.Where("upc = ?", "d").First(&laserdisc).Error)
+	manual, err := service.ManualFields(laserdisc.ID)
+	require.NoError(t, err)
+	assert.True(t, manual["genre"])
+
+	merged, err = service.MergeValues("genre", "Westerns", "Western")
+	require.NoError(t, err)
+	assert.Zero(t, merged)
+
+	_, err = service.MergeValues("upc", "a", "b")
+	assert.ErrorIs(t, err, ErrInvalidField)
+}
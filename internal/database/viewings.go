@@ -0,0 +1,232 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// ErrInvalidViewing is returned for a viewing with a malformed date, a rating
+// out of range or a copy of another LaserDisc
+var ErrInvalidViewing = errors.New("invalid viewing")
+
+// ErrHasViewings is returned when marking a LaserDisc unwatched would delete
+// viewings that record more than it being marked watched
+var ErrHasViewings = errors.New("laserdisc has viewings on record")
+
+// GetViewingTimeline returns a LaserDisc's viewings with a summary of them
+func (s *Service) GetViewingTimeline(laserdiscID uint) (*models.ViewingTimeline, error) {
+	var laserdisc models.LaserDisc
+	if err := s.db.First(&laserdisc, laserdiscID).Error; err != nil {
+		return nil, err
+	}
+
+	// SQLite sorts NULLs first, so undated viewings lead
+	viewings := []models.Viewing{}
+	if err := s.db.Where("laser_disc_id = ?", laserdiscID).Order("watched_at, id").Find(&viewings).Error; err != nil {
+		return nil, err
+	}
+
+	timeline := &models.ViewingTimeline{
+		LaserDiscID: laserdisc.ID,
+		Title:       laserdisc.Title,
+		Count:       len(viewings),
+		Viewings:    viewings,
+	}
+	var rated, total int
+	for i := range viewings {
+		if viewings[i].Viewers == nil {
+			viewings[i].Viewers = []string{}
+		}
+		if viewings[i].Rating > 0 {
+			rated++
+			total += viewings[i].Rating
+		}
+		if viewings[i].WatchedAt != nil {
+			timeline.LastWatched = viewings[i].WatchedAt
+		}
+	}
+	if rated > 0 {
+		timeline.AverageRating = math.Round(float64(total)/float64(rated)*10) / 10
+	}
+	return timeline, nil
+}
+
+// GetViewing retrieves one of a LaserDisc's viewings
+func (s *Service) GetViewing(laserdiscID, viewingID uint) (*models.Viewing, error) {
+	var viewing models.Viewing
+	result := s.db.Where("laser_disc_id = ?", laserdiscID).First(&viewing, viewingID)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if viewing.Viewers == nil {
+		viewing.Viewers = []string{}
+	}
+	return &viewing, nil
+}
+
+// AddViewing records watching a LaserDisc, marking it watched
+func (s *Service) AddViewing(laserdiscID uint, req *models.ViewingRequest) (*models.Viewing, error) {
+	if err := s.db.First(&models.LaserDisc{}, laserdiscID).Error; err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	viewing := &models.Viewing{LaserDiscID: laserdiscID, WatchedAt: &now, Viewers: []string{}}
+	if err := s.applyViewingRequest(viewing, req); err != nil {
+		return nil, err
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(viewing).Error; err != nil {
+			return err
+		}
+		return syncWatched(tx, laserdiscID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return viewing, nil
+}
+
+// UpdateViewing changes the date, viewers, rating, review or copy of a
+// viewing
+func (s *Service) UpdateViewing(laserdiscID, viewingID uint, req *models.ViewingRequest) (*models.Viewing, error) {
+	viewing, err := s.GetViewing(laserdiscID, viewingID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyViewingRequest(viewing, req); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(viewing).Error; err != nil {
+		return nil, err
+	}
+	return viewing, nil
+}
+
+// DeleteViewing deletes one of a LaserDisc's viewings, marking it unwatched
+// if it was the last
+func (s *Service) DeleteViewing(laserdiscID, viewingID uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("laser_disc_id = ?", laserdiscID).Delete(&models.Viewing{}, viewingID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return syncWatched(tx, laserdiscID)
+	})
+}
+
+// setWatched records a viewing now when a LaserDisc without any is marked
+// watched. Marking it unwatched only undoes that: a single viewing with
+// nothing but its date is deleted, and any other history is kept and
+// ErrHasViewings returned.
+func setWatched(tx *gorm.DB, laserdiscID uint, watched bool) error {
+	if watched {
+		var count int64
+		if err := tx.Model(&models.Viewing{}).Where("laser_disc_id = ?", laserdiscID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			now := time.Now()
+			if err := tx.Create(&models.Viewing{LaserDiscID: laserdiscID, WatchedAt: &now, Viewers: []string{}}).Error; err != nil {
+				return err
+			}
+		}
+	} else {
+		var viewings []models.Viewing
+		if err := tx.Where("laser_disc_id = ?", laserdiscID).Find(&viewings).Error; err != nil {
+			return err
+		}
+		if len(viewings) > 1 || len(viewings) == 1 && !bareViewing(&viewings[0]) {
+			return ErrHasViewings
+		}
+		if err := tx.Where("laser_disc_id = ?", laserdiscID).Delete(&models.Viewing{}).Error; err != nil {
+			return err
+		}
+	}
+	return syncWatched(tx, laserdiscID)
+}
+
+// bareViewing reports whether a viewing records nothing but when it was,
+// like those marking a LaserDisc watched records
+func bareViewing(viewing *models.Viewing) bool {
+	return len(viewing.Viewers) == 0 && viewing.Rating == 0 && viewing.Review == "" && viewing.CopyID == 0 && viewing.Player == ""
+}
+
+// syncWatched derives a LaserDisc's Watched flag from its viewings. The flag
+// is stored so the collection can be filtered and sorted on it.
+func syncWatched(tx *gorm.DB, laserdiscID uint) error {
+	return tx.Model(&models.LaserDisc{}).Where("id = ?", laserdiscID).
+		Update("watched", gorm.Expr("EXISTS (SELECT 1 FROM viewings WHERE laser_disc_id = ?)", laserdiscID)).Error
+}
+
+// applyViewingRequest sets and checks the fields a request gives
+func (s *Service) applyViewingRequest(viewing *models.Viewing, req *models.ViewingRequest) error {
+	if req.WatchedAt != nil {
+		watchedAt, err := parseWatchedAt(*req.WatchedAt)
+		if err != nil {
+			return err
+		}
+		viewing.WatchedAt = watchedAt
+	}
+	if req.Viewers != nil {
+		viewers := []string{}
+		for _, viewer := range *req.Viewers {
+			if viewer = strings.TrimSpace(viewer); viewer != "" {
+				viewers = append(viewers, viewer)
+			}
+		}
+		viewing.Viewers = viewers
+	}
+	if req.Rating != nil {
+		if *req.Rating != 0 && (*req.Rating < models.MinRating || *req.Rating > models.MaxRating) {
+			return fmt.Errorf("%w: rating %d, use %d to %d, or 0 for none", ErrInvalidViewing, *req.Rating, models.MinRating, models.MaxRating)
+		}
+		viewing.Rating = *req.Rating
+	}
+	if req.Review != nil {
+		viewing.Review = *req.Review
+	}
+	if req.CopyID != nil {
+		if *req.CopyID != 0 {
+			if _, err := s.GetCopy(viewing.LaserDiscID, *req.CopyID); err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("%w: copy %d isn't a copy of this LaserDisc", ErrInvalidViewing, *req.CopyID)
+				}
+				return err
+			}
+		}
+		viewing.CopyID = *req.CopyID
+	}
+	if req.Player != nil {
+		viewing.Player = strings.TrimSpace(*req.Player)
+	}
+	return nil
+}
+
+// parseWatchedAt reads a YYYY-MM-DD date or an RFC 3339 time. An empty one
+// leaves a viewing undated.
+func parseWatchedAt(value string) (*time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return &parsed, nil
+	}
+	parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("%w: watched_at %q, use a date like 2024-03-01 or an RFC 3339 time", ErrInvalidViewing, value)
+	}
+	return &parsed, nil
+}
@@ -0,0 +1,161 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+func intPtr(i int) *int { return &i }
+
+func TestService_Viewings(t *testing.T) {
+	service := setupTestDB(t)
+	laserdisc, err := service.CreateLaserDisc(createTestLaserDisc())
+	require.NoError(t, err)
+	copy := laserdisc.Copies[0]
+
+	viewers := []string{" Sam ", "Alex", ""}
+	first, err := service.AddViewing(laserdisc.ID, &models.ViewingRequest{
+		WatchedAt: stringPtr("2024-03-01"),
+		Viewers:   &viewers,
+		Rating:    intPtr(8),
+		Review:    stringPtr("Still holds up"),
+		CopyID:    &copy.ID,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Sam", "Alex"}, first.Viewers)
+	assert.Equal(t, copy.ID, first.CopyID)
+
+	// Viewings mark the LaserDisc watched
+	retrieved, err := service.GetLaserDiscByID(laserdisc.ID)
+	require.NoError(t, err)
+	assert.True(t, retrieved.Watched)
+
+	// Dated now unless told otherwise, or undated when it isn't known
+	before := time.Now()
+	second, err := service.AddViewing(laserdisc.ID, &models.ViewingRequest{Rating: intPtr(5)})
+	require.NoError(t, err)
+	require.NotNil(t, second.WatchedAt)
+	assert.False(t, second.WatchedAt.Before(before))
+	undated, err := service.AddViewing(laserdisc.ID, &models.ViewingRequest{WatchedAt: stringPtr("")})
+	require.NoError(t, err)
+	assert.Nil(t, undated.WatchedAt)
+
+	timeline, err := service.GetViewingTimeline(laserdisc.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, timeline.Count)
+	assert.Equal(t, 6.5, timeline.AverageRating)
+	require.NotNil(t, timeline.LastWatched)
+	assert.Equal(t, second.WatchedAt.Unix(), timeline.LastWatched.Unix())
+	require.Len(t, timeline.Viewings, 3)
+	assert.Equal(t, []uint{undated.ID, first.ID, second.ID}, []uint{timeline.Viewings[0].ID, timeline.Viewings[1].ID, timeline.Viewings[2].ID})
+	assert.Equal(t, []string{"Sam", "Alex"}, timeline.Viewings[1].Viewers)
+	assert.NotNil(t, timeline.Viewings[0].Viewers)
+
+	// Updates change only the fields given
+	updated, err := service.UpdateViewing(laserdisc.ID, first.ID, &models.ViewingRequest{Rating: intPtr(9)})
+	require.NoError(t, err)
+	assert.Equal(t, 9, updated.Rating)
+	assert.Equal(t, "Still holds up", updated.Review)
+	assert.Equal(t, "2024-03-01", updated.WatchedAt.Format("2006-01-02"))
+
+	invalid := []models.ViewingRequest{
+		{Rating: intPtr(11)},
+		{Rating: intPtr(-1)},
+		{WatchedAt: stringPtr("last tuesday")},
+		{CopyID: func() *uint { id := copy.ID + 1; return &id }()},
+	}
+	for _, req := range invalid {
+		_, err := service.UpdateViewing(laserdisc.ID, first.ID, &req)
+		assert.ErrorIs(t, err, ErrInvalidViewing, "%+v", req)
+	}
+	_, err = service.UpdateViewing(laserdisc.ID+1, first.ID, &models.ViewingRequest{})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// Deleting a copy keeps the viewings it was played in
+	require.NoError(t, service.DeleteCopy(laserdisc.ID, copy.ID))
+	viewing, err := service.GetViewing(laserdisc.ID, first.ID)
+	require.NoError(t, err)
+	assert.Zero(t, viewing.CopyID)
+
+	// Deleting the last viewing marks the LaserDisc unwatched
+	for _, id := range []uint{first.ID, second.ID} {
+		require.NoError(t, service.DeleteViewing(laserdisc.ID, id))
+	}
+	retrieved, err = service.GetLaserDiscByID(laserdisc.ID)
+	require.NoError(t, err)
+	assert.True(t, retrieved.Watched)
+	require.NoError(t, service.DeleteViewing(laserdisc.ID, undated.ID))
+	retrieved, err = service.GetLaserDiscByID(laserdisc.ID)
+	require.NoError(t, err)
+	assert.False(t, retrieved.Watched)
+	assert.ErrorIs(t, service.DeleteViewing(laserdisc.ID, undated.ID), gorm.ErrRecordNotFound)
+
+	_, err = service.GetViewingTimeline(999999)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestService_SetWatched(t *testing.T) {
+	service := setupTestDB(t)
+	laserdisc, err := service.CreateLaserDisc(createTestLaserDisc())
+	require.NoError(t, err)
+
+	countViewings := func() int {
+		timeline, err := service.GetViewingTimeline(laserdisc.ID)
+		require.NoError(t, err)
+		return timeline.Count
+	}
+
+	// Marking it watched records a viewing, once
+	toggled, err := service.ToggleWatched(laserdisc.ID)
+	require.NoError(t, err)
+	assert.True(t, toggled.Watched)
+	assert.Equal(t, 1, countViewings())
+
+	watched := true
+	updated, err := service.UpdateLaserDisc(laserdisc.ID, &models.UpdateLaserDiscRequest{Watched: &watched})
+	require.NoError(t, err)
+	assert.True(t, updated.Watched)
+	assert.Equal(t, 1, countViewings())
+
+	// Marking it unwatched undoes that
+	watched = false
+	updated, err = service.UpdateLaserDisc(laserdisc.ID, &models.UpdateLaserDiscRequest{Watched: &watched})
+	require.NoError(t, err)
+	assert.False(t, updated.Watched)
+	assert.Zero(t, countViewings())
+
+	// But doesn't delete a history of viewings
+	_, err = service.AddViewing(laserdisc.ID, &models.ViewingRequest{Rating: intPtr(8)})
+	require.NoError(t, err)
+	_, err = service.ToggleWatched(laserdisc.ID)
+	assert.ErrorIs(t, err, ErrHasViewings)
+	_, err = service.SetWatched(laserdisc.ID, false)
+	assert.ErrorIs(t, err, ErrHasViewings)
+	_, err = service.UpdateLaserDisc(laserdisc.ID, &models.UpdateLaserDiscRequest{Watched: &watched})
+	assert.ErrorIs(t, err, ErrHasViewings)
+	assert.Equal(t, 1, countViewings())
+
+	_, err = service.AddViewing(laserdisc.ID, &models.ViewingRequest{})
+	require.NoError(t, err)
+	_, err = service.ToggleWatched(laserdisc.ID)
+	assert.ErrorIs(t, err, ErrHasViewings)
+	assert.Equal(t, 2, countViewings())
+
+	// Marking it watched again leaves them alone
+	updated, err = service.SetWatched(laserdisc.ID, true)
+	require.NoError(t, err)
+	assert.True(t, updated.Watched)
+	assert.Equal(t, 2, countViewings())
+
+	// Deleting the LaserDisc deletes its viewings
+	require.NoError(t, service.DeleteLaserDisc(laserdisc.ID))
+	var count int64
+	require.NoError(t, service.db.Model(&models.Viewing{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
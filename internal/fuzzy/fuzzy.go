@@ -0,0 +1,172 @@
+// Package fuzzy compares LaserDisc titles loosely, so a search still finds
+// a title that was misspelled, spaced differently, filed with its article
+// at the end or numbered with roman numerals.
+package fuzzy
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Score thresholds, from 0 to 1
+const (
+	// MatchThreshold is the lowest Score a fuzzy search returns
+	MatchThreshold = 0.75
+	// SuggestThreshold is the lowest Score offered as a "did you mean"
+	SuggestThreshold = 0.6
+	// DuplicateThreshold is the lowest Similarity of two titles that are
+	// probably the same
+	DuplicateThreshold = 0.9
+)
+
+// partialPenalty scales a match against only some of a title's words, so
+// whole titles rank first
+const partialPenalty = 0.95
+
+// articles are dropped from the start or end of titles
+var articles = map[string]bool{"the": true, "a": true, "an": true}
+
+// withoutTrailingArticle removes an article filed at the end of a title,
+// e.g. "Ghost and the Darkness, The"
+func withoutTrailingArticle(title string) string {
+	title = strings.TrimRightFunc(title, unicode.IsSpace)
+	comma := strings.LastIndexByte(title, ',')
+	if comma >= 0 && articles[strings.ToLower(strings.TrimSpace(title[comma+1:]))] {
+		return title[:comma]
+	}
+	return title
+}
+
+// punctuation is replaced before titles are split into words, so "&" counts
+// as "and" and apostrophes don't split words
+var punctuation = strings.NewReplacer("&", " and ", "'", "", "’", "")
+
+// romanNumerals are the sequel numbers replaced with digits. I is left
+// alone, as it's more often a word.
+var romanNumerals = map[string]string{
+	"ii": "2", "iii": "3", "iv": "4", "v": "5", "vi": "6", "vii": "7",
+	"viii": "8", "ix": "9", "x": "10", "xi": "11", "xii": "12", "xiii": "13",
+}
+
+// Normalize lowercases a title and reduces it to words, dropping
+// punctuation and articles and writing roman numerals as digits, e.g.
+// "Rocky II" and "rocky 2" both become "rocky 2"
+func Normalize(title string) string {
+	title = withoutTrailingArticle(title)
+	title = punctuation.Replace(strings.ToLower(title))
+	words := strings.FieldsFunc(title, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	if len(words) > 1 && articles[words[0]] {
+		words = words[1:]
+	}
+	for i, word := range words {
+		if number, ok := romanNumerals[word]; ok {
+			words[i] = number
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// Matcher scores titles against a search
+type Matcher struct {
+	words  int    // in the normalized search
+	joined string // the normalized search without spaces
+}
+
+// NewMatcher returns a Matcher for search
+func NewMatcher(search string) *Matcher {
+	query := strings.Fields(Normalize(search))
+	return &Matcher{words: len(query), joined: strings.Join(query, "")}
+}
+
+// Score rates how well the search matches a title, from 0 to 1. The search
+// may match the whole title, or a run of about as many of its words, so
+// "termnator" matches "Terminator 2: Judgment Day". Spaces don't count, so
+// "bladerunner" matches "Blade Runner" exactly.
+func (m *Matcher) Score(title string) float64 {
+	words := strings.Fields(Normalize(title))
+	if m.words == 0 || len(words) == 0 {
+		return 0
+	}
+
+	best := ratio(m.joined, strings.Join(words, ""))
+	for size := m.words - 1; size <= m.words+1; size++ {
+		if size < 1 || size >= len(words) {
+			continue
+		}
+		for start := 0; start+size <= len(words); start++ {
+			if score := partialPenalty * ratio(m.joined, strings.Join(words[start:start+size], "")); score > best {
+				best = score
+			}
+		}
+	}
+	return best
+}
+
+// Score rates how well a search matches a title, see Matcher.Score
+func Score(search, title string) float64 {
+	return NewMatcher(search).Score(title)
+}
+
+// Similarity rates how alike two whole titles are, from 0 to 1. Titles
+// with different numbers, like sequels, aren't alike at all.
+func Similarity(a, b string) float64 {
+	a, b = Normalize(a), Normalize(b)
+	if !sameNumbers(a, b) {
+		return 0
+	}
+	return ratio(strings.ReplaceAll(a, " ", ""), strings.ReplaceAll(b, " ", ""))
+}
+
+// sameNumbers reports whether two normalized titles have the same numbers
+// in the same order
+func sameNumbers(a, b string) bool {
+	numbers := func(s string) string {
+		return strings.Join(strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsDigit(r) }), " ")
+	}
+	return numbers(a) == numbers(b)
+}
+
+// ratio turns the edit distance between two strings into a similarity
+// from 0 to 1
+func ratio(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(distance(ra, rb))/float64(longest)
+}
+
+// distance counts the insertions, deletions, substitutions and swaps of
+// neighbouring characters that turn a into b
+func distance(a, b []rune) int {
+	// Three rows of the edit distance matrix, for swaps
+	previous2 := make([]int, len(b)+1)
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				current[j] = min(current[j], previous2[j-2]+1)
+			}
+		}
+		previous2, previous, current = previous, current, previous2
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,91 @@
+package fuzzy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Blade Runner", "blade runner"},
+		{"The Terminator", "terminator"},
+		{"Terminator, The", "terminator"},
+		{"Ghost and the Darkness, The", "ghost and the darkness"},
+		{"A Fish Called Wanda", "fish called wanda"},
+		{"Rocky II", "rocky 2"},
+		{"Star Trek IV: The Voyage Home", "star trek 4 the voyage home"},
+		{"Schindler's List", "schindlers list"},
+		{"Bill & Ted's Excellent Adventure", "bill and teds excellent adventure"},
+		{"E.T. the Extra-Terrestrial", "e t the extra terrestrial"},
+		{"The", "the"},
+		{"  ", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			assert.Equal(t, tt.want, Normalize(tt.title))
+		})
+	}
+}
+
+func TestScore(t *testing.T) {
+	matches := []struct{ search, title string }{
+		{"Bladerunner", "Blade Runner"},
+		{"Termnator", "The Terminator"},
+		{"termiantor", "Terminator, The"},
+		{"termnator", "Terminator 2: Judgment Day"},
+		{"rocky 2", "Rocky II"},
+		{"ghost and darknes", "Ghost and the Darkness, The"},
+		{"raiders of the lost arc", "Raiders of the Lost Ark"},
+	}
+	for _, tt := range matches {
+		assert.GreaterOrEqual(t, Score(tt.search, tt.title), MatchThreshold, "%q should match %q", tt.search, tt.title)
+	}
+
+	misses := []struct{ search, title string }{
+		{"star wars", "Star Trek"},
+		{"aliens", "Jaws"},
+		{"", "Jaws"},
+		{"jaws", ""},
+	}
+	for _, tt := range misses {
+		assert.Less(t, Score(tt.search, tt.title), MatchThreshold, "%q shouldn't match %q", tt.search, tt.title)
+	}
+
+	assert.Equal(t, 1.0, Score("Bladerunner", "Blade Runner"))
+	assert.Greater(t, Score("terminator", "The Terminator"), Score("terminator", "Terminator 2: Judgment Day"),
+		"a whole title ranks above part of one")
+}
+
+func TestSimilarity(t *testing.T) {
+	duplicates := []struct{ a, b string }{
+		{"The Terminator", "Terminator, The"},
+		{"Rocky II", "Rocky 2"},
+		{"Blade Runner", "Bladerunner"},
+		{"Raiders of the Lost Ark", "Raiders of the Lost Arc"},
+	}
+	for _, tt := range duplicates {
+		assert.GreaterOrEqual(t, Similarity(tt.a, tt.b), DuplicateThreshold, "%q and %q", tt.a, tt.b)
+	}
+
+	different := []struct{ a, b string }{
+		{"Rocky II", "Rocky III"},
+		{"Friday the 13th Part 2", "Friday the 13th Part 3"},
+		{"Alien", "Aliens"},
+		{"Blade Runner", "Blade Runner: The Director's Cut"},
+	}
+	for _, tt := range different {
+		assert.Less(t, Similarity(tt.a, tt.b), DuplicateThreshold, "%q and %q", tt.a, tt.b)
+	}
+}
+
+func TestDistance(t *testing.T) {
+	assert.Equal(t, 0, distance([]rune("jaws"), []rune("jaws")))
+	assert.Equal(t, 1, distance([]rune("termnator"), []rune("terminator")))
+	assert.Equal(t, 1, distance([]rune("termiantor"), []rune("terminator")), "a swap counts once")
+	assert.Equal(t, 3, distance([]rune(""), []rune("jaw")))
+	assert.Equal(t, 1, distance([]rune("amelie"), []rune("amélie")))
+}
@@ -1,33 +1,57 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	"github.com/paran01d/lddb/internal/barcode"
+	"github.com/paran01d/lddb/internal/covers"
 	"github.com/paran01d/lddb/internal/database"
+	"github.com/paran01d/lddb/internal/images"
 	"github.com/paran01d/lddb/internal/models"
 )
 
 // CollectionHandler handles collection-related HTTP requests
 type CollectionHandler struct {
-	dbService *database.Service
+	dbService   *database.Service
+	coverMirror *covers.Mirror
+	images      *images.Store
 }
 
-// NewCollectionHandler creates a new collection handler
-func NewCollectionHandler(dbService *database.Service) *CollectionHandler {
+// NewCollectionHandler creates a new collection handler. Covers of added
+// and edited LaserDiscs are queued on coverMirror, if given, and photos of
+// deleted LaserDiscs are removed from imageStore.
+func NewCollectionHandler(dbService *database.Service, coverMirror *covers.Mirror, imageStore *images.Store) *CollectionHandler {
 	return &CollectionHandler{
-		dbService: dbService,
+		dbService:   dbService,
+		coverMirror: coverMirror,
+		images:      imageStore,
 	}
 }
 
-// GetCollection retrieves all LaserDiscs in the collection
-// GET /api/collection?search=query&limit=10&offset=0
+// mirrorCover queues a LaserDisc's cover to be mirrored locally
+func (h *CollectionHandler) mirrorCover(id uint) {
+	if h.coverMirror != nil {
+		h.coverMirror.Enqueue(id)
+	}
+}
+
+// GetCollection retrieves a page of the LaserDiscs in the collection,
+// optionally filtered by watched, genre, format, year_min, year_max,
+// runtime_min, runtime_max, sides, added_from, added_to, has_notes, tag
+// (repeated for several) and custom fields, e.g. custom[Region]=NTSC-J or
+// custom_min[Purchased]=2024-01-01. sort=custom:Region sorts by a custom field.
+// fuzzy=true matches the search loosely against titles, and a search that
+// finds nothing suggests titles it may have meant in did_you_mean.
+// GET /api/collection?search=query&sort=year&order=desc&limit=10&offset=0
 func (h *CollectionHandler) GetCollection(c *gin.Context) {
 	// Get query parameters
-	search := c.Query("search")
 	limitStr := c.DefaultQuery("limit", "50")
 	offsetStr := c.DefaultQuery("offset", "0")
 
@@ -43,47 +67,33 @@ func (h *CollectionHandler) GetCollection(c *gin.Context) {
 		return
 	}
 
-	var laserdiscs []models.LaserDisc
-	var total int64
+	query, problems := parseCollectionQuery(c)
+	if len(problems) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": strings.Join(problems, "; ")})
+		return
+	}
+	query.Limit, query.Offset = limit, offset
 
-	if search != "" {
-		// Search with query
-		laserdiscs, err = h.dbService.SearchLaserDiscs(search)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search collection"})
+	// Filter, sort and page in SQL rather than loading the whole collection
+	laserdiscs, total, err := h.dbService.QueryLaserDiscs(query)
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": err.Error()})
 			return
 		}
-		total = int64(len(laserdiscs))
-		
-		// Apply pagination to search results
-		if offset >= len(laserdiscs) {
-			laserdiscs = []models.LaserDisc{}
-		} else {
-			end := offset + limit
-			if end > len(laserdiscs) {
-				end = len(laserdiscs)
-			}
-			laserdiscs = laserdiscs[offset:end]
-		}
-	} else {
-		// Get all with pagination
-		laserdiscs, err = h.dbService.GetAllLaserDiscs()
-		if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve collection"})
+		return
+	}
+
+	if err := h.dbService.AttachProvenance(laserdiscs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve collection"})
+		return
+	}
+	for _, attach := range []func([]models.LaserDisc) error{h.dbService.AttachCopies, h.dbService.AttachTags, h.dbService.AttachCustomFields} {
+		if err := attach(laserdiscs); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve collection"})
 			return
 		}
-		total = int64(len(laserdiscs))
-
-		// Apply pagination
-		if offset >= len(laserdiscs) {
-			laserdiscs = []models.LaserDisc{}
-		} else {
-			end := offset + limit
-			if end > len(laserdiscs) {
-				end = len(laserdiscs)
-			}
-			laserdiscs = laserdiscs[offset:end]
-		}
 	}
 
 	// Get collection statistics
@@ -96,7 +106,7 @@ func (h *CollectionHandler) GetCollection(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"laserdiscs": laserdiscs,
 		"pagination": gin.H{
 			"total":  total,
@@ -104,10 +114,23 @@ func (h *CollectionHandler) GetCollection(c *gin.Context) {
 			"offset": offset,
 		},
 		"stats": stats,
-	})
+	}
+
+	// Suggest what a search that found nothing may have been looking for
+	if total == 0 && query.Search != "" && !query.Fuzzy {
+		suggestions, err := h.dbService.SuggestTitles(query.Search, 5)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve collection"})
+			return
+		}
+		response["did_you_mean"] = suggestions
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
-// AddLaserDisc adds a new LaserDisc to the collection
+// AddLaserDisc adds a new LaserDisc to the collection, warning when there's
+// already one with nearly the same title
 // POST /api/collection
 func (h *CollectionHandler) AddLaserDisc(c *gin.Context) {
 	var req models.CreateLaserDiscRequest
@@ -118,18 +141,51 @@ func (h *CollectionHandler) AddLaserDisc(c *gin.Context) {
 
 	laserdisc, err := h.dbService.CreateLaserDisc(&req)
 	if err != nil {
-		if err.Error() == "laserdisc with this UPC already exists" {
-			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		if errors.Is(err, database.ErrDuplicateUPC) {
+			// Owning the same release twice is a second copy of it
+			response := gin.H{
+				"error": err.Error(),
+				"hint":  "Add another copy with POST /api/collection/:id/copies",
+			}
+			if existing, err := h.dbService.GetLaserDiscByUPC(req.UPC); err == nil {
+				response["laserdisc_id"] = existing.ID
+			}
+			c.JSON(http.StatusConflict, response)
+			return
+		}
+		if errors.Is(err, database.ErrInvalidCopy) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid copy", "details": err.Error()})
+			return
+		}
+		if respondInvalidTagsOrFields(c, err) {
+			return
+		}
+		if errors.Is(err, barcode.ErrInvalid) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid barcode", "details": err.Error()})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create LaserDisc", "details": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"message":    "LaserDisc added successfully",
+	h.mirrorCover(laserdisc.ID)
+
+	response := gin.H{
+		"message":   "LaserDisc added successfully",
 		"laserdisc": laserdisc,
-	})
+	}
+
+	// A near-identical title is often the same disc under another barcode,
+	// but can be another edition, so it's added anyway
+	similar, err := h.dbService.SimilarTitles(laserdisc.Title, laserdisc.ID)
+	if err != nil {
+		log.Printf("Warning: Could not check for titles like %q: %v", laserdisc.Title, err)
+	} else if len(similar) > 0 {
+		response["warning"] = "A LaserDisc with a similar title is already in the collection"
+		response["similar"] = similar
+	}
+
+	c.JSON(http.StatusCreated, response)
 }
 
 // UpdateLaserDisc updates an existing LaserDisc
@@ -154,12 +210,18 @@ func (h *CollectionHandler) UpdateLaserDisc(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "LaserDisc not found"})
 			return
 		}
+		if respondInvalidTagsOrFields(c, err) || respondHasViewings(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update LaserDisc", "details": err.Error()})
 		return
 	}
+	if req.CoverImageURL != nil {
+		h.mirrorCover(laserdisc.ID)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":    "LaserDisc updated successfully",
+		"message":   "LaserDisc updated successfully",
 		"laserdisc": laserdisc,
 	})
 }
@@ -174,6 +236,12 @@ func (h *CollectionHandler) DeleteLaserDisc(c *gin.Context) {
 		return
 	}
 
+	photos, err := h.dbService.GetLaserDiscImages(uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete LaserDisc", "details": err.Error()})
+		return
+	}
+
 	err = h.dbService.DeleteLaserDisc(uint(id))
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -183,12 +251,17 @@ func (h *CollectionHandler) DeleteLaserDisc(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete LaserDisc", "details": err.Error()})
 		return
 	}
+	if h.images != nil {
+		removeImageFiles(h.dbService, h.images, photos)
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "LaserDisc deleted successfully"})
 }
 
-// ToggleWatched toggles the watched status of a LaserDisc
-// POST /api/collection/:id/watched
+// ToggleWatched toggles the watched status of a LaserDisc, or sets it when
+// watched is given. Viewings with more than a date aren't deleted by
+// unwatching.
+// POST /api/collection/:id/watched?watched=true
 func (h *CollectionHandler) ToggleWatched(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -197,12 +270,25 @@ func (h *CollectionHandler) ToggleWatched(c *gin.Context) {
 		return
 	}
 
-	laserdisc, err := h.dbService.ToggleWatched(uint(id))
+	var laserdisc *models.LaserDisc
+	if value := c.Query("watched"); value != "" {
+		watched, parseErr := strconv.ParseBool(value)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "watched must be true or false"})
+			return
+		}
+		laserdisc, err = h.dbService.SetWatched(uint(id), watched)
+	} else {
+		laserdisc, err = h.dbService.ToggleWatched(uint(id))
+	}
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "LaserDisc not found"})
 			return
 		}
+		if respondHasViewings(c, err) {
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update watched status", "details": err.Error()})
 		return
 	}
@@ -213,8 +299,8 @@ func (h *CollectionHandler) ToggleWatched(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":    "Watched status updated successfully",
-		"status":     status,
+		"message":   "Watched status updated successfully",
+		"status":    status,
 		"laserdisc": laserdisc,
 	})
 }
@@ -233,7 +319,7 @@ func (h *CollectionHandler) GetRandomUnwatched(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":    "Random unwatched LaserDisc selected",
+		"message":   "Random unwatched LaserDisc selected",
 		"laserdisc": laserdisc,
 	})
-}
\ No newline at end of file
+}
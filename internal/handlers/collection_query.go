@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/paran01d/lddb/internal/database"
+)
+
+// collectionQueryParser reads GET /api/collection's filter and sort
+// parameters, noting each one it can't parse
+type collectionQueryParser struct {
+	c        *gin.Context
+	problems []string
+}
+
+// bool reads a true/false parameter
+func (p *collectionQueryParser) bool(name string) *bool {
+	value := p.c.Query(name)
+	if value == "" {
+		return nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		p.problems = append(p.problems, fmt.Sprintf("%s must be true or false", name))
+		return nil
+	}
+	return &parsed
+}
+
+// int reads a whole number parameter, zero when it's missing
+func (p *collectionQueryParser) int(name string) int {
+	value := p.c.Query(name)
+	if value == "" {
+		return 0
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		p.problems = append(p.problems, fmt.Sprintf("%s must be a whole number", name))
+		return 0
+	}
+	return parsed
+}
+
+// date reads a YYYY-MM-DD date or an RFC 3339 time. A date on its own
+// means the start of that day, or the end of it when endOfDay is set.
+func (p *collectionQueryParser) date(name string, endOfDay bool) *time.Time {
+	value := p.c.Query(name)
+	if value == "" {
+		return nil
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return &parsed
+	}
+	parsed, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		p.problems = append(p.problems, fmt.Sprintf("%s must be a date like 2024-03-01", name))
+		return nil
+	}
+	if endOfDay {
+		parsed = parsed.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	return &parsed
+}
+
+// tags reads the tag parameters, e.g. tag=Criterion&tag=THX
+func (p *collectionQueryParser) tags() []string {
+	var tags []string
+	for _, tag := range p.c.QueryArray("tag") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// custom reads the custom field filters, e.g. custom[Region]=NTSC-J or
+// custom_min[Purchased]=2024-01-01, in order of field name
+func (p *collectionQueryParser) custom() []database.CustomFilter {
+	byField := make(map[string]*database.CustomFilter)
+	for _, param := range []string{"custom", "custom_min", "custom_max"} {
+		for name, value := range p.c.QueryMap(param) {
+			filter, ok := byField[name]
+			if !ok {
+				filter = &database.CustomFilter{Field: name}
+				byField[name] = filter
+			}
+			switch param {
+			case "custom":
+				filter.Value = value
+			case "custom_min":
+				filter.Min = value
+			case "custom_max":
+				filter.Max = value
+			}
+		}
+	}
+
+	filters := make([]database.CustomFilter, 0, len(byField))
+	for _, filter := range byField {
+		filters = append(filters, *filter)
+	}
+	sort.Slice(filters, func(i, j int) bool { return filters[i].Field < filters[j].Field })
+	return filters
+}
+
+// parseCollectionQuery reads the filter and sort parameters of a collection
+// request. It returns the problems with any it couldn't parse; the query's
+// Validate checks whether the parsed values make sense together.
+func parseCollectionQuery(c *gin.Context) (database.LaserDiscQuery, []string) {
+	p := &collectionQueryParser{c: c}
+	fuzzy := p.bool("fuzzy")
+	query := database.LaserDiscQuery{
+		Search:     c.Query("search"),
+		Fuzzy:      fuzzy != nil && *fuzzy,
+		Watched:    p.bool("watched"),
+		Genre:      c.Query("genre"),
+		Format:     c.Query("format"),
+		YearMin:    p.int("year_min"),
+		YearMax:    p.int("year_max"),
+		RuntimeMin: p.int("runtime_min"),
+		RuntimeMax: p.int("runtime_max"),
+		Sides:      p.int("sides"),
+		AddedFrom:  p.date("added_from", false),
+		AddedTo:    p.date("added_to", true),
+		HasNotes:   p.bool("has_notes"),
+		Tags:       p.tags(),
+		Custom:     p.custom(),
+		Sort:       c.Query("sort"),
+	}
+
+	switch c.DefaultQuery("order", "asc") {
+	case "asc":
+	case "desc":
+		query.Desc = true
+	default:
+		p.problems = append(p.problems, "order must be asc or desc")
+	}
+	return query, p.problems
+}
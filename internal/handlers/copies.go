@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/database"
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// ListCopies lists the copies owned of a LaserDisc
+// GET /api/collection/:id/copies
+func (h *CollectionHandler) ListCopies(c *gin.Context) {
+	id, ok := parseUintParam(c, "id", "Invalid LaserDisc ID")
+	if !ok {
+		return
+	}
+	if _, err := h.dbService.GetLaserDiscByID(id); err != nil {
+		respondLaserDiscError(c, err)
+		return
+	}
+
+	copies, err := h.dbService.GetCopies(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve copies", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"copies": copies})
+}
+
+// AddCopy adds another copy of a LaserDisc already in the collection, e.g.
+// {"sealed": true, "sleeve_grade": "NM", "purchase_price": 25, "currency": "USD"}
+// POST /api/collection/:id/copies
+func (h *CollectionHandler) AddCopy(c *gin.Context) {
+	id, ok := parseUintParam(c, "id", "Invalid LaserDisc ID")
+	if !ok {
+		return
+	}
+
+	var req models.CopyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	copy, err := h.dbService.AddCopy(id, &req)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "LaserDisc not found"})
+			return
+		}
+		respondCopyError(c, err, "Failed to add copy")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Copy added successfully",
+		"copy":    copy,
+	})
+}
+
+// UpdateCopy updates the condition or purchase details of a copy
+// PUT /api/collection/:id/copies/:copyId
+func (h *CollectionHandler) UpdateCopy(c *gin.Context) {
+	id, copyID, ok := parseCopyParams(c)
+	if !ok {
+		return
+	}
+
+	var req models.CopyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	copy, err := h.dbService.UpdateCopy(id, copyID, &req)
+	if err != nil {
+		respondCopyError(c, err, "Failed to update copy")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Copy updated successfully",
+		"copy":    copy,
+	})
+}
+
+// DeleteCopy deletes a copy, keeping the LaserDisc's metadata
+// DELETE /api/collection/:id/copies/:copyId
+func (h *CollectionHandler) DeleteCopy(c *gin.Context) {
+	id, copyID, ok := parseCopyParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.dbService.DeleteCopy(id, copyID); err != nil {
+		respondCopyError(c, err, "Failed to delete copy")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Copy deleted successfully"})
+}
+
+// parseCopyParams reads the LaserDisc and copy IDs from the path
+func parseCopyParams(c *gin.Context) (uint, uint, bool) {
+	id, ok := parseUintParam(c, "id", "Invalid LaserDisc ID")
+	if !ok {
+		return 0, 0, false
+	}
+	copyID, ok := parseUintParam(c, "copyId", "Invalid copy ID")
+	if !ok {
+		return 0, 0, false
+	}
+	return id, copyID, true
+}
+
+// respondCopyError responds to a failed copy operation
+func respondCopyError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Copy not found"})
+	case errors.Is(err, database.ErrInvalidCopy):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid copy", "details": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
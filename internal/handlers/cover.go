@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/covers"
+	"github.com/paran01d/lddb/internal/database"
+	"github.com/paran01d/lddb/internal/images"
+)
+
+// CoverHandler serves cover art mirrored to the cover store, or the user's
+// own photo when they've chosen one as the cover
+type CoverHandler struct {
+	dbService *database.Service
+	store     *covers.Store
+	images    *images.Store
+}
+
+// NewCoverHandler creates a new cover handler
+func NewCoverHandler(dbService *database.Service, store *covers.Store, imageStore *images.Store) *CoverHandler {
+	return &CoverHandler{
+		dbService: dbService,
+		store:     store,
+		images:    imageStore,
+	}
+}
+
+// GetCover serves a LaserDisc's cover. A primary uploaded image takes
+// precedence, at its full size. Covers that haven't been mirrored yet
+// redirect to the original URL.
+// GET /api/collection/:id/cover?size=thumb|small|medium|large|full
+func (h *CoverHandler) GetCover(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid LaserDisc ID"})
+		return
+	}
+
+	size := c.DefaultQuery("size", "full")
+	if _, ok := covers.Sizes[size]; !ok && size != "full" && size != "thumb" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cover size", "details": "size must be thumb, small, medium, large or full"})
+		return
+	}
+
+	laserdisc, err := h.dbService.GetLaserDiscByID(uint(id))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "LaserDisc not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve LaserDisc", "details": err.Error()})
+		return
+	}
+
+	if image, err := h.dbService.GetPrimaryLaserDiscImage(laserdisc.ID); err == nil {
+		serveImage(c, h.images, image)
+		return
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve cover", "details": err.Error()})
+		return
+	}
+
+	file := laserdisc.CoverFile
+	if size == "thumb" {
+		file, size = laserdisc.CoverThumb, "full"
+	}
+	if file == "" || laserdisc.CoverSource != laserdisc.CoverImageURL {
+		if laserdisc.CoverImageURL != "" {
+			c.Redirect(http.StatusFound, laserdisc.CoverImageURL)
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "LaserDisc has no cover"})
+		return
+	}
+
+	path, err := h.store.Path(file, size)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to serve cover", "details": err.Error()})
+		return
+	}
+
+	// The file name is a content hash, so it makes a strong ETag
+	c.Header("ETag", `"`+file+"-"+size+`"`)
+	c.Header("Cache-Control", "private, max-age=3600")
+	c.File(path)
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/database"
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// ListCustomFields lists every custom field, oldest first
+// GET /api/custom-fields
+func (h *CollectionHandler) ListCustomFields(c *gin.Context) {
+	fields, err := h.dbService.GetCustomFields()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve custom fields", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"custom_fields": fields,
+		"types":         models.FieldTypes,
+	})
+}
+
+// AddCustomField creates a custom field, e.g.
+// {"name": "Region", "type": "enum", "options": ["NTSC-U", "NTSC-J", "PAL"]}
+// POST /api/custom-fields
+func (h *CollectionHandler) AddCustomField(c *gin.Context) {
+	var req models.CustomFieldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	field, err := h.dbService.CreateCustomField(&req)
+	if err != nil {
+		respondCustomFieldError(c, err, "Failed to add custom field")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":      "Custom field added successfully",
+		"custom_field": field,
+	})
+}
+
+// UpdateCustomField renames a custom field or changes an enum's options
+// PUT /api/custom-fields/:fieldId
+func (h *CollectionHandler) UpdateCustomField(c *gin.Context) {
+	id, ok := parseUintParam(c, "fieldId", "Invalid custom field ID")
+	if !ok {
+		return
+	}
+
+	var req models.CustomFieldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	field, err := h.dbService.UpdateCustomField(id, &req)
+	if err != nil {
+		respondCustomFieldError(c, err, "Failed to update custom field")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Custom field updated successfully",
+		"custom_field": field,
+	})
+}
+
+// DeleteCustomField deletes a custom field and every LaserDisc's value for it
+// DELETE /api/custom-fields/:fieldId
+func (h *CollectionHandler) DeleteCustomField(c *gin.Context) {
+	id, ok := parseUintParam(c, "fieldId", "Invalid custom field ID")
+	if !ok {
+		return
+	}
+
+	if err := h.dbService.DeleteCustomField(id); err != nil {
+		respondCustomFieldError(c, err, "Failed to delete custom field")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Custom field deleted successfully"})
+}
+
+// respondCustomFieldError responds to a failed custom field operation
+func respondCustomFieldError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Custom field not found"})
+	case errors.Is(err, database.ErrInvalidCustomField):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid custom field", "details": err.Error()})
+	case errors.Is(err, database.ErrDuplicateCustomField):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
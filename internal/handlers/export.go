@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/paran01d/lddb/internal/database"
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// exportColumns are the CSV export's columns before the copies, tags and
+// custom fields, with each LaserDisc's value for them
+var exportColumns = []struct {
+	name  string
+	value func(*models.LaserDisc) string
+}{
+	{"id", func(l *models.LaserDisc) string { return strconv.FormatUint(uint64(l.ID), 10) }},
+	{"upc", func(l *models.LaserDisc) string { return l.UPC }},
+	{"title", func(l *models.LaserDisc) string { return l.Title }},
+	{"year", func(l *models.LaserDisc) string { return exportInt(l.Year) }},
+	{"director", func(l *models.LaserDisc) string { return l.Director }},
+	{"genre", func(l *models.LaserDisc) string { return l.Genre }},
+	{"format", func(l *models.LaserDisc) string { return l.Format }},
+	{"sides", func(l *models.LaserDisc) string { return exportInt(l.Sides) }},
+	{"runtime", func(l *models.LaserDisc) string { return exportInt(l.Runtime) }},
+	{"reference", func(l *models.LaserDisc) string { return l.Reference }},
+	{"label", func(l *models.LaserDisc) string { return l.Label }},
+	{"release_date", func(l *models.LaserDisc) string { return l.ReleaseDate }},
+	{"country", func(l *models.LaserDisc) string { return l.Country }},
+	{"video_standard", func(l *models.LaserDisc) string { return l.VideoStandard }},
+	{"picture_format", func(l *models.LaserDisc) string { return l.PictureFormat }},
+	{"aspect_ratio", func(l *models.LaserDisc) string { return l.AspectRatio }},
+	{"sound", func(l *models.LaserDisc) string { return l.Sound }},
+	{"chapters", func(l *models.LaserDisc) string { return exportInt(l.Chapters) }},
+	{"price", func(l *models.LaserDisc) string { return l.Price }},
+	{"disc_modes", func(l *models.LaserDisc) string { return l.DiscModes }},
+	{"cast", func(l *models.LaserDisc) string { return l.Cast }},
+	{"producer", func(l *models.LaserDisc) string { return l.Producer }},
+	{"lddb_url", func(l *models.LaserDisc) string { return l.LDDBUrl }},
+	{"watched", func(l *models.LaserDisc) string { return strconv.FormatBool(l.Watched) }},
+	{"notes", func(l *models.LaserDisc) string { return l.Notes }},
+	{"added_date", func(l *models.LaserDisc) string { return l.AddedDate.Format(time.RFC3339) }},
+}
+
+// exportInt formats a whole number for the CSV export, empty when it's zero
+func exportInt(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// ExportCollection downloads the whole collection, or the LaserDiscs matching
+// GET /api/collection's filters, as CSV or JSON. Both include each
+// LaserDisc's tags and custom fields; the CSV has a column per custom field
+// and the tags separated by semicolons.
+// GET /api/export/csv?tag=Criterion&sort=year
+func (h *CollectionHandler) ExportCollection(c *gin.Context) {
+	// In the path, as the format parameter filters by disc format
+	format := c.Param("format")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid export format (csv or json)"})
+		return
+	}
+
+	query, problems := parseCollectionQuery(c)
+	if len(problems) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": strings.Join(problems, "; ")})
+		return
+	}
+
+	laserdiscs, _, err := h.dbService.QueryLaserDiscs(query)
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidQuery) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export collection", "details": err.Error()})
+		return
+	}
+	for _, attach := range []func([]models.LaserDisc) error{h.dbService.AttachCopies, h.dbService.AttachTags, h.dbService.AttachCustomFields} {
+		if err := attach(laserdiscs); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export collection", "details": err.Error()})
+			return
+		}
+	}
+	fields, err := h.dbService.GetCustomFields()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export collection", "details": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="lddb-collection-%s.%s"`, now.Format("20060102"), format))
+	if format == "json" {
+		c.JSON(http.StatusOK, gin.H{
+			"exported_at":   now,
+			"custom_fields": fields,
+			"laserdiscs":    laserdiscs,
+		})
+		return
+	}
+
+	header := make([]string, 0, len(exportColumns)+2+len(fields))
+	for _, column := range exportColumns {
+		header = append(header, column.name)
+	}
+	header = append(header, "copies", "tags")
+	for _, field := range fields {
+		header = append(header, field.Name)
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Status(http.StatusOK)
+	w := csv.NewWriter(c.Writer)
+	if err := w.Write(header); err != nil {
+		return
+	}
+	for i := range laserdiscs {
+		laserdisc := &laserdiscs[i]
+		row := make([]string, 0, len(header))
+		for _, column := range exportColumns {
+			row = append(row, column.value(laserdisc))
+		}
+
+		tags := make([]string, len(laserdisc.Tags))
+		for j, tag := range laserdisc.Tags {
+			tags[j] = tag.Name
+		}
+		row = append(row, strconv.Itoa(len(laserdisc.Copies)), strings.Join(tags, "; "))
+
+		for _, field := range fields {
+			switch value := laserdisc.CustomFields[field.Name].(type) {
+			case nil:
+				row = append(row, "")
+			case float64:
+				row = append(row, strconv.FormatFloat(value, 'f', -1, 64))
+			default:
+				row = append(row, fmt.Sprint(value))
+			}
+		}
+		if err := w.Write(row); err != nil {
+			return
+		}
+	}
+	w.Flush()
+}
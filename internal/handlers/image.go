@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/database"
+	"github.com/paran01d/lddb/internal/images"
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// maxUploadFiles caps how many images one upload request may carry
+const maxUploadFiles = 10
+
+// ImageHandler handles photos users upload of their LaserDiscs
+type ImageHandler struct {
+	dbService *database.Service
+	store     *images.Store
+}
+
+// NewImageHandler creates a new image handler
+func NewImageHandler(dbService *database.Service, store *images.Store) *ImageHandler {
+	return &ImageHandler{
+		dbService: dbService,
+		store:     store,
+	}
+}
+
+// ListImages returns a LaserDisc's images in display order
+// GET /api/collection/:id/images
+func (h *ImageHandler) ListImages(c *gin.Context) {
+	id, ok := parseUintParam(c, "id", "Invalid LaserDisc ID")
+	if !ok {
+		return
+	}
+	if _, err := h.dbService.GetLaserDiscByID(id); err != nil {
+		respondLaserDiscError(c, err)
+		return
+	}
+
+	list, err := h.dbService.GetLaserDiscImages(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve images", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"images": list})
+}
+
+// UploadImages adds photos to a LaserDisc. Files go in the multipart "image"
+// field, optionally with a kind, caption and primary=true for the first file.
+// POST /api/collection/:id/images
+func (h *ImageHandler) UploadImages(c *gin.Context) {
+	id, ok := parseUintParam(c, "id", "Invalid LaserDisc ID")
+	if !ok {
+		return
+	}
+	if _, err := h.dbService.GetLaserDiscByID(id); err != nil {
+		respondLaserDiscError(c, err)
+		return
+	}
+
+	// Refuse oversized requests before reading them
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadFiles*h.store.MaxBytes()+1<<20)
+	form, err := c.MultipartForm()
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Upload is too large", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid upload", "details": err.Error()})
+		return
+	}
+
+	files := form.File["image"]
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No image uploaded", "details": `send the files in the "image" field`})
+		return
+	}
+	if len(files) > maxUploadFiles {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Too many images", "details": "upload at most " + strconv.Itoa(maxUploadFiles) + " images at a time"})
+		return
+	}
+
+	kind := c.DefaultPostForm("kind", models.ImageKindOther)
+	if !models.ValidImageKind(kind) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image kind", "details": "kind must be front, back, label, damage or other"})
+		return
+	}
+	primary := false
+	if value := c.PostForm("primary"); value != "" {
+		if primary, err = strconv.ParseBool(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid primary flag", "details": err.Error()})
+			return
+		}
+	}
+
+	// Store every file before recording any, so a bad file rejects the whole upload
+	var saved []images.Saved
+	for _, header := range files {
+		s, err := h.saveUpload(header)
+		if err != nil {
+			h.removeUnusedFiles(saved)
+			respondImageError(c, header.Filename, err)
+			return
+		}
+		saved = append(saved, s)
+	}
+
+	created := make([]models.LaserDiscImage, 0, len(saved))
+	for i, s := range saved {
+		image := models.LaserDiscImage{
+			LaserDiscID: id,
+			Kind:        kind,
+			Caption:     c.PostForm("caption"),
+			File:        s.File,
+			ContentType: s.ContentType,
+			Width:       s.Width,
+			Height:      s.Height,
+			Size:        s.Size,
+			Primary:     primary && i == 0,
+		}
+		if err := h.dbService.AddLaserDiscImage(&image); err != nil {
+			h.removeUnusedFiles(saved[i:])
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save image", "details": err.Error()})
+			return
+		}
+		created = append(created, image)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"images": created})
+}
+
+// ReorderImages sets the display order of a LaserDisc's images. The body
+// lists every image ID in the new order: {"image_ids": [3, 1, 2]}
+// PUT /api/collection/:id/images
+func (h *ImageHandler) ReorderImages(c *gin.Context) {
+	id, ok := parseUintParam(c, "id", "Invalid LaserDisc ID")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		ImageIDs []uint `json:"image_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+
+	list, err := h.dbService.ReorderLaserDiscImages(id, req.ImageIDs)
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidImageOrder) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image order", "details": err.Error()})
+			return
+		}
+		respondLaserDiscError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"images": list})
+}
+
+// SetPrimaryImage makes an image the LaserDisc's cover
+// PUT /api/collection/:id/images/:imageId/primary
+func (h *ImageHandler) SetPrimaryImage(c *gin.Context) {
+	id, imageID, ok := parseImageParams(c)
+	if !ok {
+		return
+	}
+
+	image, err := h.dbService.SetPrimaryLaserDiscImage(id, imageID)
+	if err != nil {
+		respondImageNotFound(c, err, "Failed to set primary image")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"image": image})
+}
+
+// DeleteImage deletes an image, removing its file once nothing else uses it
+// DELETE /api/collection/:id/images/:imageId
+func (h *ImageHandler) DeleteImage(c *gin.Context) {
+	id, imageID, ok := parseImageParams(c)
+	if !ok {
+		return
+	}
+
+	image, err := h.dbService.DeleteLaserDiscImage(id, imageID)
+	if err != nil {
+		respondImageNotFound(c, err, "Failed to delete image")
+		return
+	}
+	removeImageFiles(h.dbService, h.store, []models.LaserDiscImage{*image})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Image deleted successfully"})
+}
+
+// GetImageFile serves an image
+// GET /api/collection/:id/images/:imageId/file
+func (h *ImageHandler) GetImageFile(c *gin.Context) {
+	id, imageID, ok := parseImageParams(c)
+	if !ok {
+		return
+	}
+
+	image, err := h.dbService.GetLaserDiscImage(id, imageID)
+	if err != nil {
+		respondImageNotFound(c, err, "Failed to retrieve image")
+		return
+	}
+	serveImage(c, h.store, image)
+}
+
+// saveUpload stores one uploaded file, rejecting it early when the client
+// already reported a size over the limit
+func (h *ImageHandler) saveUpload(header *multipart.FileHeader) (images.Saved, error) {
+	if header.Size > h.store.MaxBytes() {
+		return images.Saved{}, images.ErrTooLarge
+	}
+	f, err := header.Open()
+	if err != nil {
+		return images.Saved{}, err
+	}
+	defer f.Close()
+	return h.store.Save(f)
+}
+
+// removeUnusedFiles removes stored files that no image refers to, after an
+// upload fails part way
+func (h *ImageHandler) removeUnusedFiles(saved []images.Saved) {
+	list := make([]models.LaserDiscImage, len(saved))
+	for i, s := range saved {
+		list[i].File = s.File
+	}
+	removeImageFiles(h.dbService, h.store, list)
+}
+
+// removeImageFiles removes the files of deleted images that no remaining
+// image shares. Failures are logged, leaving at worst an orphaned file.
+func removeImageFiles(dbService *database.Service, store *images.Store, deleted []models.LaserDiscImage) {
+	for _, image := range deleted {
+		inUse, err := dbService.ImageFileInUse(image.File)
+		if err != nil {
+			log.Printf("Warning: Could not check whether image %s is in use: %v", image.File, err)
+			continue
+		}
+		if inUse {
+			continue
+		}
+		if err := store.Remove(image.File); err != nil {
+			log.Printf("Warning: Could not remove image %s: %v", image.File, err)
+		}
+	}
+}
+
+// serveImage sends a stored image with caching headers
+func serveImage(c *gin.Context, store *images.Store, image *models.LaserDiscImage) {
+	path, err := store.Path(image.File)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to serve image", "details": err.Error()})
+		return
+	}
+
+	// The file name is a content hash, so it makes a strong ETag
+	c.Header("ETag", `"`+image.File+`"`)
+	c.Header("Cache-Control", "private, max-age=3600")
+	c.File(path)
+}
+
+// parseUintParam reads a numeric path parameter, responding with a 400 if it isn't one
+func parseUintParam(c *gin.Context, name, message string) (uint, bool) {
+	value, err := strconv.ParseUint(c.Param(name), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": message})
+		return 0, false
+	}
+	return uint(value), true
+}
+
+// parseImageParams reads the LaserDisc and image IDs from the path
+func parseImageParams(c *gin.Context) (uint, uint, bool) {
+	id, ok := parseUintParam(c, "id", "Invalid LaserDisc ID")
+	if !ok {
+		return 0, 0, false
+	}
+	imageID, ok := parseUintParam(c, "imageId", "Invalid image ID")
+	if !ok {
+		return 0, 0, false
+	}
+	return id, imageID, true
+}
+
+// respondLaserDiscError responds to a failed LaserDisc lookup
+func respondLaserDiscError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "LaserDisc not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve LaserDisc", "details": err.Error()})
+}
+
+// respondImageNotFound responds to a failed image operation
+func respondImageNotFound(c *gin.Context, err error, message string) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+}
+
+// respondImageError responds to an upload the image store refused
+func respondImageError(c *gin.Context, filename string, err error) {
+	switch {
+	case errors.Is(err, images.ErrTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Image is too large", "file": filename, "details": err.Error()})
+	case errors.Is(err, images.ErrUnsupportedType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Unsupported image type", "file": filename, "details": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save image", "file": filename, "details": err.Error()})
+	}
+}
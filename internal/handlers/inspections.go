@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/paran01d/lddb/internal/database"
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// ListInspections lists the inspections of a copy, oldest first
+// GET /api/collection/:id/copies/:copyId/inspections
+func (h *CollectionHandler) ListInspections(c *gin.Context) {
+	id, copyID, ok := parseCopyParams(c)
+	if !ok {
+		return
+	}
+
+	inspections, err := h.dbService.GetInspections(id, copyID)
+	if err != nil {
+		respondCopyError(c, err, "Failed to retrieve inspections")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"inspections": inspections})
+}
+
+// AddInspection logs playing one side of a copy, e.g.
+// {"side": 2, "player": "Pioneer CLD-D704", "defect": "rot", "chapter": 14, "severity": 2}
+// POST /api/collection/:id/copies/:copyId/inspections
+func (h *CollectionHandler) AddInspection(c *gin.Context) {
+	id, copyID, ok := parseCopyParams(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateInspectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	inspection, err := h.dbService.AddInspection(id, copyID, &req)
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidInspection) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid inspection", "details": err.Error()})
+			return
+		}
+		respondCopyError(c, err, "Failed to add inspection")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "Inspection added successfully",
+		"inspection": inspection,
+	})
+}
+
+// DeleteInspection deletes an inspection logged by mistake
+// DELETE /api/collection/:id/copies/:copyId/inspections/:inspectionId
+func (h *CollectionHandler) DeleteInspection(c *gin.Context) {
+	id, copyID, ok := parseCopyParams(c)
+	if !ok {
+		return
+	}
+	inspectionID, ok := parseUintParam(c, "inspectionId", "Invalid inspection ID")
+	if !ok {
+		return
+	}
+
+	if err := h.dbService.DeleteInspection(id, copyID, inspectionID); err != nil {
+		respondCopyError(c, err, "Failed to delete inspection")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Inspection deleted successfully"})
+}
+
+// GetWorstConditions lists the copies with the worst defects found when they
+// were last inspected
+// GET /api/inspections/worst?limit=20
+func (h *CollectionHandler) GetWorstConditions(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter (1-100)"})
+		return
+	}
+
+	conditions, err := h.dbService.WorstConditions(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve conditions", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"copies": conditions})
+}
+
+// GetRotProgressions shows how the rot on each side where some was found has
+// changed over its inspections, optionally only those with one trend
+// GET /api/inspections/rot?trend=worsening
+func (h *CollectionHandler) GetRotProgressions(c *gin.Context) {
+	trend := c.Query("trend")
+	switch trend {
+	case "", models.RotTrendWorsening, models.RotTrendStable, models.RotTrendImproving, models.RotTrendUnknown:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid trend parameter (worsening, stable, improving or unknown)"})
+		return
+	}
+
+	progressions, err := h.dbService.RotProgressions(trend)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve rot progression", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"sides": progressions})
+}
@@ -1,40 +1,194 @@
 package handlers
 
 import (
+	"encoding/json"
+	"log"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
+	"github.com/paran01d/lddb/internal/barcode"
 	"github.com/paran01d/lddb/internal/database"
+	"github.com/paran01d/lddb/internal/models"
+	"github.com/paran01d/lddb/internal/providers"
 	"github.com/paran01d/lddb/internal/scraper"
 )
 
+// LookupConfig configures the lookup cache and the scraper behind it
+type LookupConfig struct {
+	CacheTTL         time.Duration    // how long a found result is served from the cache
+	NegativeCacheTTL time.Duration    // how long a "not found" result is served from the cache
+	ScraperOptions   []scraper.Option // passed through to the LDDB scraper
+	Providers        []string         // metadata providers in the order they are tried: mapping, lddb, dataset
+	DatasetPath      string           // JSON file for the offline dataset provider
+	MappingPath      string           // JSON file for the user-defined mapping provider
+}
+
+// DefaultLookupConfig returns the lookup settings used when nothing is configured
+func DefaultLookupConfig() LookupConfig {
+	return LookupConfig{
+		CacheTTL:         30 * 24 * time.Hour,
+		NegativeCacheTTL: 24 * time.Hour,
+		Providers:        []string{"mapping", "lddb", "dataset"},
+		DatasetPath:      "data/dataset.json",
+		MappingPath:      "data/mappings.json",
+	}
+}
+
 // LookupHandler handles lookup-related HTTP requests
 type LookupHandler struct {
 	dbService *database.Service
 	scraper   *scraper.LDDBScraper
+	providers *providers.Registry
+	config    LookupConfig
 }
 
 // NewLookupHandler creates a new lookup handler
-func NewLookupHandler(dbService *database.Service) *LookupHandler {
+func NewLookupHandler(dbService *database.Service, config LookupConfig) *LookupHandler {
+	lddb := scraper.NewLDDBScraper(config.ScraperOptions...)
 	return &LookupHandler{
 		dbService: dbService,
-		scraper:   scraper.NewLDDBScraper(),
+		scraper:   lddb,
+		providers: newProviderRegistry(config, lddb),
+		config:    config,
+	}
+}
+
+// newProviderRegistry builds the metadata providers named in the config.
+// File based providers whose file can't be loaded are left out.
+func newProviderRegistry(config LookupConfig, lddb *scraper.LDDBScraper) *providers.Registry {
+	var chain []providers.MetadataProvider
+	for _, name := range config.Providers {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "lddb":
+			chain = append(chain, lddb)
+		case "dataset":
+			dataset, err := providers.LoadDataset(config.DatasetPath)
+			if err != nil {
+				log.Printf("Skipping offline dataset provider: %v", err)
+				continue
+			}
+			chain = append(chain, dataset)
+		case "mapping":
+			mapping, err := providers.LoadMapping(config.MappingPath)
+			if err != nil {
+				log.Printf("Skipping mapping provider: %v", err)
+				continue
+			}
+			chain = append(chain, mapping)
+		default:
+			log.Printf("Warning: Ignoring unknown metadata provider %q", name)
+		}
+	}
+
+	registry := providers.NewRegistry(chain...)
+	log.Printf("Metadata providers: %s", strings.Join(registry.Names(), ", "))
+	return registry
+}
+
+// HTTPClient returns a client for other lddb.com downloads that shares the
+// scraper's rate limit
+func (h *LookupHandler) HTTPClient() *http.Client {
+	return h.scraper.HTTPClient()
+}
+
+// Scraper returns the LDDB scraper, for background jobs that re-scrape
+// saved LaserDiscs
+func (h *LookupHandler) Scraper() *scraper.LDDBScraper {
+	return h.scraper
+}
+
+// referenceCacheKey normalizes a catalog reference for the lookup cache
+func referenceCacheKey(reference string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(reference), " "))
+}
+
+// cachedLookup serves a fresh cache entry for the key if there is one and
+// refresh isn't requested, otherwise it scrapes and caches the result.
+// Results carrying a fetch error are never cached so they get retried.
+func (h *LookupHandler) cachedLookup(kind, key string, refresh bool, scrape func() (*models.LookupResult, error)) (*models.LookupResult, time.Time, bool, error) {
+	if key != "" && !refresh {
+		entry, err := h.dbService.GetLookupCacheEntry(kind, key)
+		if err == nil && entry.IsFresh(h.config.CacheTTL, h.config.NegativeCacheTTL, time.Now()) {
+			var result models.LookupResult
+			if err := json.Unmarshal([]byte(entry.Result), &result); err == nil {
+				result.DetailHTML = entry.DetailHTML
+				result.Diagnostics = nil // a cache hit makes no requests
+				return &result, entry.FetchedAt, true, nil
+			}
+			log.Printf("Warning: Discarding unreadable lookup cache entry %s/%s", kind, key)
+		}
+	}
+
+	result, err := scrape()
+	fetchedAt := time.Now()
+	if err != nil || key == "" || result.Error != "" {
+		return result, fetchedAt, false, err
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return result, fetchedAt, false, nil
+	}
+
+	entry := &models.LookupCacheEntry{
+		Kind:       kind,
+		Key:        key,
+		Result:     string(encoded),
+		DetailHTML: result.DetailHTML,
+		Found:      result.Found || len(result.Candidates) > 0,
+		FetchedAt:  fetchedAt,
+	}
+	if err := h.dbService.SaveLookupCacheEntry(entry); err != nil {
+		log.Printf("Warning: Could not cache lookup %s/%s: %v", kind, key, err)
+	}
+
+	return result, fetchedAt, false, nil
+}
+
+// lookupSource names the metadata provider a found LaserDisc's title came
+// from. The others it was merged with are listed in the result's sources.
+func lookupSource(result *models.LookupResult) string {
+	return result.Sources["title"]
+}
+
+// lookupFoundMessage names the provider that answered a lookup, and whether
+// the LaserDisc is already in the collection.
+func lookupFoundMessage(result *models.LookupResult, inCollection bool) string {
+	message := "LaserDisc information found"
+	if source := lookupSource(result); source != "" {
+		message += " by the " + source + " provider"
 	}
+	if inCollection {
+		message += " (also exists in local collection)"
+	}
+	return message
 }
 
 // LookupByUPC looks up LaserDisc information by UPC
-// GET /api/lookup/:upc
+// GET /api/lookup/:upc?refresh=true
 func (h *LookupHandler) LookupByUPC(c *gin.Context) {
 	upc := strings.TrimSpace(c.Param("upc"))
 	if upc == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "UPC parameter is required"})
 		return
 	}
+	gtin, err := barcode.Normalize(upc)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid barcode", "details": err.Error()})
+		return
+	}
+	upc = gtin
+	refresh, _ := strconv.ParseBool(c.Query("refresh"))
 
-	// Always scrape LDDB for the most up-to-date information
-	result, err := h.scraper.LookupByUPC(upc)
+	// Serve from the lookup cache unless it's stale or a refresh was requested.
+	// Providers get the barcode in the form printed on the jacket.
+	result, fetchedAt, cached, err := h.cachedLookup(models.LookupKindUPC, gtin, refresh, func() (*models.LookupResult, error) {
+		return h.providers.LookupByUPC(barcode.Short(gtin))
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to lookup LaserDisc information",
@@ -43,46 +197,145 @@ func (h *LookupHandler) LookupByUPC(c *gin.Context) {
 		return
 	}
 
+	if len(result.Candidates) > 1 {
+		c.JSON(http.StatusOK, gin.H{
+			"message":    "Several LaserDiscs match this UPC, choose one",
+			"upc":        upc,
+			"candidates": result.Candidates,
+			"cached":     cached,
+			"fetched_at": fetchedAt,
+		})
+		return
+	}
+
 	if !result.Found {
 		c.JSON(http.StatusNotFound, gin.H{
-			"message": "LaserDisc not found",
-			"upc":     upc,
-			"source":  "lddb.com",
-			"error":   result.Error,
+			"message":    "LaserDisc not found",
+			"upc":        upc,
+			"error":      result.Error,
+			"cached":     cached,
+			"fetched_at": fetchedAt,
 		})
 		return
 	}
+	result.UPC = upc
 
 	// Check if we already have this UPC in our collection for reference
 	existing, _ := h.dbService.GetLaserDiscByUPC(upc)
-	
-	// Prepare response with both LDDB result and local info
+
+	// Prepare response with both the lookup result and local info
 	response := gin.H{
-		"source": "lddb.com",
-		"result": result,
+		"source":     lookupSource(result),
+		"result":     result,
+		"cached":     cached,
+		"fetched_at": fetchedAt,
 	}
-	
+
+	response["message"] = lookupFoundMessage(result, existing != nil)
 	if existing != nil {
 		response["existing"] = existing
-		response["message"] = "LaserDisc found in LDDB (also exists in local collection)"
-	} else {
-		response["message"] = "LaserDisc information found in LDDB"
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
 // LookupByReference looks up LaserDisc information by catalog reference
-// GET /api/lookup/reference/:reference
+// GET /api/lookup/reference/:reference?refresh=true
 func (h *LookupHandler) LookupByReference(c *gin.Context) {
 	reference := strings.TrimSpace(c.Param("reference"))
 	if reference == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Reference parameter is required"})
 		return
 	}
+	refresh, _ := strconv.ParseBool(c.Query("refresh"))
+
+	// Serve from the lookup cache unless it's stale or a refresh was requested
+	result, fetchedAt, cached, err := h.cachedLookup(models.LookupKindReference, referenceCacheKey(reference), refresh, func() (*models.LookupResult, error) {
+		return h.providers.LookupByReference(reference)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to lookup LaserDisc information",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if len(result.Candidates) > 1 {
+		c.JSON(http.StatusOK, gin.H{
+			"message":    "Several LaserDiscs match this reference, choose one",
+			"reference":  reference,
+			"candidates": result.Candidates,
+			"cached":     cached,
+			"fetched_at": fetchedAt,
+		})
+		return
+	}
+
+	if !result.Found {
+		c.JSON(http.StatusNotFound, gin.H{
+			"message":    "LaserDisc not found",
+			"reference":  reference,
+			"error":      result.Error,
+			"cached":     cached,
+			"fetched_at": fetchedAt,
+		})
+		return
+	}
+	result.UPC = reference
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "LaserDisc information found by reference",
+		"source":     lookupSource(result),
+		"reference":  reference,
+		"result":     result,
+		"cached":     cached,
+		"fetched_at": fetchedAt,
+	})
+}
+
+// LookupByLDDBID fetches one specific LDDB detail page, e.g. a candidate
+// picked after an ambiguous UPC or reference lookup
+// GET /api/lookup/lddb/:id?refresh=true
+func (h *LookupHandler) LookupByLDDBID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid LDDB ID"})
+		return
+	}
+
+	h.lookupDetail(c, id, func() (*models.LookupResult, error) {
+		return h.providers.LookupByID(id)
+	})
+}
+
+// LookupByURL fetches the detail page behind a pasted lddb.com URL, for discs
+// whose barcode is worn or missing
+// GET /api/lookup/url?url=https://www.lddb.com/laserdisc/...&refresh=true
+func (h *LookupHandler) LookupByURL(c *gin.Context) {
+	rawURL := strings.TrimSpace(c.Query("url"))
+	if rawURL == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "URL parameter is required"})
+		return
+	}
 
-	// Scrape LDDB using reference search
-	result, err := h.scraper.LookupByReference(reference)
+	id, err := h.scraper.ParseLDDBID(rawURL)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid LDDB URL", "details": err.Error()})
+		return
+	}
+
+	h.lookupDetail(c, id, func() (*models.LookupResult, error) {
+		return h.providers.LookupByID(id)
+	})
+}
+
+// lookupDetail answers a direct detail page lookup. ID and URL lookups share
+// cache entries since both resolve to the same page.
+func (h *LookupHandler) lookupDetail(c *gin.Context, id int, scrape func() (*models.LookupResult, error)) {
+	refresh, _ := strconv.ParseBool(c.Query("refresh"))
+
+	result, fetchedAt, cached, err := h.cachedLookup(models.LookupKindLDDBID, strconv.Itoa(id), refresh, scrape)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to lookup LaserDisc information",
@@ -93,18 +346,124 @@ func (h *LookupHandler) LookupByReference(c *gin.Context) {
 
 	if !result.Found {
 		c.JSON(http.StatusNotFound, gin.H{
-			"message":   "LaserDisc not found",
-			"reference": reference,
-			"source":    "lddb.com",
-			"error":     result.Error,
+			"message":    "LaserDisc not found",
+			"lddb_id":    id,
+			"error":      result.Error,
+			"cached":     cached,
+			"fetched_at": fetchedAt,
+		})
+		return
+	}
+
+	response := gin.H{
+		"message":    lookupFoundMessage(result, false),
+		"source":     lookupSource(result),
+		"lddb_id":    id,
+		"result":     result,
+		"cached":     cached,
+		"fetched_at": fetchedAt,
+	}
+
+	// The detail page lists the UPC, so check the local collection too
+	if gtin, err := barcode.Normalize(result.UPC); err == nil {
+		result.UPC = gtin
+		if existing, _ := h.dbService.GetLaserDiscByUPC(result.UPC); existing != nil {
+			response["existing"] = existing
+			response["message"] = lookupFoundMessage(result, true)
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SearchByTitle searches LDDB by title or keyword for discs without a usable
+// barcode, e.g. Japanese imports, promos and early DiscoVision titles
+// GET /api/lookup/search?q=...&year=...&country=...&page=...
+func (h *LookupHandler) SearchByTitle(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter q is required"})
+		return
+	}
+
+	filter := scraper.TitleSearch{
+		Country: strings.TrimSpace(c.Query("country")),
+		Page:    1,
+	}
+	if year := c.Query("year"); year != "" {
+		parsed, err := strconv.Atoi(year)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year"})
+			return
+		}
+		filter.Year = parsed
+	}
+	if page := c.Query("page"); page != "" {
+		parsed, err := strconv.Atoi(page)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid page"})
+			return
+		}
+		filter.Page = parsed
+	}
+
+	result, err := h.scraper.LookupByTitle(query, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "Failed to search LDDB",
+			"details": err.Error(),
+		})
+		return
+	}
+	if result.Error != "" {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error":   "Failed to search LDDB",
+			"details": result.Error,
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":   "LaserDisc information found by reference",
-		"source":    "lddb.com",
-		"reference": reference,
-		"result":    result,
+		"source":      h.scraper.Name(),
+		"query":       result.Query,
+		"year":        result.Year,
+		"country":     result.Country,
+		"page":        result.Page,
+		"has_more":    result.HasMore,
+		"candidates":  result.Candidates,
+		"diagnostics": result.Diagnostics,
 	})
-}
\ No newline at end of file
+}
+
+// PurgeLookupCache removes cached lookups so the next lookup scrapes LDDB
+// again. The whole cache is only purged when asked for with all=true.
+// DELETE /api/admin/lookup-cache?upc=...|reference=...|lddb_id=...|all=true
+func (h *LookupHandler) PurgeLookupCache(c *gin.Context) {
+	var kind, key string
+	if upc := strings.TrimSpace(c.Query("upc")); upc != "" {
+		gtin, err := barcode.Normalize(upc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid barcode", "details": err.Error()})
+			return
+		}
+		kind, key = models.LookupKindUPC, gtin
+	} else if reference := referenceCacheKey(c.Query("reference")); reference != "" {
+		kind, key = models.LookupKindReference, reference
+	} else if lddbID := strings.TrimSpace(c.Query("lddb_id")); lddbID != "" {
+		kind, key = models.LookupKindLDDBID, lddbID
+	} else if all, err := strconv.ParseBool(c.Query("all")); err != nil || !all {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Give a upc, reference or lddb_id to purge, or all=true to purge the whole cache"})
+		return
+	}
+
+	purged, err := h.dbService.PurgeLookupCache(kind, key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge lookup cache", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Lookup cache purged",
+		"purged":  purged,
+	})
+}
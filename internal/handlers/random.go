@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/paran01d/lddb/internal/database"
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// defaultExcludeDays is how long a pick is left out of other draws, unless
+// exclude_days says otherwise
+const defaultExcludeDays = 7
+
+// GetRandom picks LaserDiscs for movie night, filtered like the collection
+// by search, genre, format, year_min, year_max, runtime_min, runtime_max,
+// sides, tag and custom fields. Only unwatched LaserDiscs are picked unless
+// watched or not_watched_years is given. mode weights the draw, count picks
+// several for a vote, and the seed in the response draws the same picks
+// again.
+// GET /api/random?runtime_max=100&mode=oldest_added&count=3&seed=42
+func (h *CollectionHandler) GetRandom(c *gin.Context) {
+	p := &collectionQueryParser{c: c}
+	query := database.RandomQuery{
+		Filter: database.LaserDiscQuery{
+			Search:     c.Query("search"),
+			Watched:    p.bool("watched"),
+			Genre:      c.Query("genre"),
+			Format:     c.Query("format"),
+			YearMin:    p.int("year_min"),
+			YearMax:    p.int("year_max"),
+			RuntimeMin: p.int("runtime_min"),
+			RuntimeMax: p.int("runtime_max"),
+			Sides:      p.int("sides"),
+			Tags:       p.tags(),
+			Custom:     p.custom(),
+		},
+		NotWatchedYears: p.int("not_watched_years"),
+		ExcludeDays:     defaultExcludeDays,
+		Mode:            c.DefaultQuery("mode", database.RandomUniform),
+		Count:           p.int("count"),
+		Seed:            database.NewRandomSeed(),
+	}
+	if c.Query("exclude_days") != "" {
+		query.ExcludeDays = p.int("exclude_days")
+	}
+	if value := c.Query("seed"); value != "" {
+		seed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			p.problems = append(p.problems, "seed must be a whole number")
+		}
+		query.Seed = seed
+	}
+	if len(p.problems) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": strings.Join(p.problems, "; ")})
+		return
+	}
+	if query.Filter.Watched == nil && query.NotWatchedYears == 0 {
+		unwatched := false
+		query.Filter.Watched = &unwatched
+	}
+
+	laserdiscs, err := h.dbService.PickRandomLaserDiscs(query)
+	if err != nil {
+		switch {
+		case errors.Is(err, database.ErrInvalidQuery):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid query parameters", "details": err.Error()})
+		case errors.Is(err, database.ErrNoRandomPick):
+			c.JSON(http.StatusNotFound, gin.H{"error": "No LaserDiscs match, or all of them were picked recently", "seed": query.Seed})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to pick LaserDiscs", "details": err.Error()})
+		}
+		return
+	}
+	for _, attach := range []func([]models.LaserDisc) error{h.dbService.AttachCopies, h.dbService.AttachTags, h.dbService.AttachCustomFields} {
+		if err := attach(laserdiscs); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to pick LaserDiscs", "details": err.Error()})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"seed":       query.Seed,
+		"mode":       query.Mode,
+		"laserdiscs": laserdiscs,
+	})
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/database"
+	"github.com/paran01d/lddb/internal/models"
+	"github.com/paran01d/lddb/internal/refresh"
+)
+
+// recentRefreshJobs is how many jobs the job list shows
+const recentRefreshJobs = 20
+
+// RefreshHandler handles background metadata refresh jobs and the
+// suggestions they leave for review
+type RefreshHandler struct {
+	dbService *database.Service
+	runner    *refresh.Runner
+}
+
+// NewRefreshHandler creates a new refresh handler
+func NewRefreshHandler(dbService *database.Service, runner *refresh.Runner) *RefreshHandler {
+	return &RefreshHandler{
+		dbService: dbService,
+		runner:    runner,
+	}
+}
+
+// StartRefresh starts re-checking LaserDiscs against LDDB. The optional body
+// narrows it down: {"ids": [1, 2], "missing": ["genre", "runtime"]}
+// POST /api/refresh/jobs
+func (h *RefreshHandler) StartRefresh(c *gin.Context) {
+	var filter models.RefreshFilter
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&filter); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+			return
+		}
+	}
+	for _, field := range filter.Missing {
+		if !models.IsScrapedField(field) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid missing field", "details": field + " is not a metadata field"})
+			return
+		}
+	}
+
+	job, err := h.runner.Start(filter)
+	if err != nil {
+		if errors.Is(err, refresh.ErrJobRunning) {
+			c.JSON(http.StatusConflict, gin.H{"error": "A refresh job is already running", "job_id": h.runner.Running()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start refresh job", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"job": job})
+}
+
+// GetRefreshJobs lists the most recent refresh jobs
+// GET /api/refresh/jobs
+func (h *RefreshHandler) GetRefreshJobs(c *gin.Context) {
+	jobs, err := h.dbService.GetRefreshJobs(recentRefreshJobs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve refresh jobs", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"jobs": jobs, "running": h.runner.Running()})
+}
+
+// GetRefreshJob returns a job's progress and the changes it found so far
+// GET /api/refresh/jobs/:id?status=applied|pending|accepted|rejected|superseded
+func (h *RefreshHandler) GetRefreshJob(c *gin.Context) {
+	id, ok := parseUintParam(c, "id", "Invalid job ID")
+	if !ok {
+		return
+	}
+
+	job, err := h.dbService.GetRefreshJob(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Refresh job not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve refresh job", "details": err.Error()})
+		return
+	}
+
+	changes, err := h.dbService.GetMetadataChanges(id, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve changes", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"job": job, "changes": changes})
+}
+
+// CancelRefreshJob stops a running job once it finishes the current LaserDisc
+// DELETE /api/refresh/jobs/:id
+func (h *RefreshHandler) CancelRefreshJob(c *gin.Context) {
+	id, ok := parseUintParam(c, "id", "Invalid job ID")
+	if !ok {
+		return
+	}
+
+	if err := h.runner.Cancel(id); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Refresh job is not running"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Refresh job cancelled"})
+}
+
+// GetSuggestions lists refreshed values waiting for review, for fields the
+// user edited by hand
+// GET /api/refresh/suggestions?laserdisc_id=
+func (h *RefreshHandler) GetSuggestions(c *gin.Context) {
+	var laserdiscID uint64
+	if value := c.Query("laserdisc_id"); value != "" {
+		var err error
+		if laserdiscID, err = strconv.ParseUint(value, 10, 32); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid LaserDisc ID"})
+			return
+		}
+	}
+
+	suggestions, err := h.dbService.GetPendingSuggestions(uint(laserdiscID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve suggestions", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"suggestions": suggestions})
+}
+
+// AcceptSuggestion applies a suggested value to its LaserDisc
+// POST /api/refresh/suggestions/:id/accept
+func (h *RefreshHandler) AcceptSuggestion(c *gin.Context) {
+	h.resolveSuggestion(c, h.dbService.AcceptSuggestion)
+}
+
+// RejectSuggestion dismisses a suggested value
+// POST /api/refresh/suggestions/:id/reject
+func (h *RefreshHandler) RejectSuggestion(c *gin.Context) {
+	h.resolveSuggestion(c, h.dbService.RejectSuggestion)
+}
+
+// resolveSuggestion answers an accept or reject request
+func (h *RefreshHandler) resolveSuggestion(c *gin.Context, resolve func(id uint) (*models.MetadataChange, error)) {
+	id, ok := parseUintParam(c, "id", "Invalid suggestion ID")
+	if !ok {
+		return
+	}
+
+	change, err := resolve(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Suggestion not found"})
+		case errors.Is(err, database.ErrChangeResolved):
+			c.JSON(http.StatusConflict, gin.H{"error": "Suggestion was already resolved"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve suggestion", "details": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"suggestion": change})
+}
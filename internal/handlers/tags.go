@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/database"
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// ListTags lists every tag by name, with how many LaserDiscs have it
+// GET /api/tags
+func (h *CollectionHandler) ListTags(c *gin.Context) {
+	tags, err := h.dbService.GetTags()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tags", "details": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// AddTag creates a tag, e.g. {"name": "Criterion", "color": "#c0392b"}.
+// Tags are also created by naming them in a LaserDisc's tags.
+// POST /api/tags
+func (h *CollectionHandler) AddTag(c *gin.Context) {
+	var req models.TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	tag, err := h.dbService.CreateTag(&req)
+	if err != nil {
+		respondTagError(c, err, "Failed to add tag")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Tag added successfully",
+		"tag":     tag,
+	})
+}
+
+// UpdateTag renames or recolours a tag
+// PUT /api/tags/:tagId
+func (h *CollectionHandler) UpdateTag(c *gin.Context) {
+	id, ok := parseUintParam(c, "tagId", "Invalid tag ID")
+	if !ok {
+		return
+	}
+
+	var req models.TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	tag, err := h.dbService.UpdateTag(id, &req)
+	if err != nil {
+		respondTagError(c, err, "Failed to update tag")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tag updated successfully",
+		"tag":     tag,
+	})
+}
+
+// DeleteTag deletes a tag, taking it off every LaserDisc
+// DELETE /api/tags/:tagId
+func (h *CollectionHandler) DeleteTag(c *gin.Context) {
+	id, ok := parseUintParam(c, "tagId", "Invalid tag ID")
+	if !ok {
+		return
+	}
+
+	if err := h.dbService.DeleteTag(id); err != nil {
+		respondTagError(c, err, "Failed to delete tag")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tag deleted successfully"})
+}
+
+// respondTagError responds to a failed tag operation
+func respondTagError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+	case errors.Is(err, database.ErrInvalidTag):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag", "details": err.Error()})
+	case errors.Is(err, database.ErrDuplicateTag):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
+
+// respondInvalidTagsOrFields responds to a LaserDisc given an invalid tag or
+// custom field value, reporting whether it was
+func respondInvalidTagsOrFields(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, database.ErrInvalidTag):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tag", "details": err.Error()})
+	case errors.Is(err, database.ErrInvalidCustomField):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid custom field", "details": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/paran01d/lddb/internal/database"
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// SuggestValues completes a title, director or genre from the values already
+// in the collection, the most used first
+// GET /api/suggest?field=genre&prefix=sci&limit=10
+func (h *CollectionHandler) SuggestValues(c *gin.Context) {
+	field := c.Query("field")
+	prefix := c.Query("prefix")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit < 1 || limit > 50 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter (1-50)"})
+		return
+	}
+
+	values, err := h.dbService.SuggestValues(field, prefix, limit)
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidField) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid field", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suggest values", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"field":       field,
+		"prefix":      prefix,
+		"suggestions": values,
+	})
+}
+
+// MergeValues replaces one value of a title, director or genre with another
+// on every LaserDisc, e.g. {"field": "genre", "from": "SciFi", "into": "Sci-Fi"}
+// POST /api/admin/merge
+func (h *CollectionHandler) MergeValues(c *gin.Context) {
+	var req models.MergeValuesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+	if req.From == req.Into {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": "from and into are the same value"})
+		return
+	}
+
+	merged, err := h.dbService.MergeValues(req.Field, req.From, req.Into)
+	if err != nil {
+		if errors.Is(err, database.ErrInvalidField) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid field", "details": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to merge values", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Values merged",
+		"field":   req.Field,
+		"from":    req.From,
+		"into":    req.Into,
+		"updated": merged,
+	})
+}
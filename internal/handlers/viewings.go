@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/paran01d/lddb/internal/database"
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// GetViewingTimeline lists every time a LaserDisc was watched, with how often
+// and how it was rated
+// GET /api/collection/:id/viewings
+func (h *CollectionHandler) GetViewingTimeline(c *gin.Context) {
+	id, ok := parseUintParam(c, "id", "Invalid LaserDisc ID")
+	if !ok {
+		return
+	}
+
+	timeline, err := h.dbService.GetViewingTimeline(id)
+	if err != nil {
+		respondLaserDiscError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, timeline)
+}
+
+// AddViewing records watching a LaserDisc, e.g.
+// {"watched_at": "2024-03-01", "viewers": ["Sam", "Alex"], "rating": 8, "review": "Still holds up"}
+// POST /api/collection/:id/viewings
+func (h *CollectionHandler) AddViewing(c *gin.Context) {
+	id, ok := parseUintParam(c, "id", "Invalid LaserDisc ID")
+	if !ok {
+		return
+	}
+
+	var req models.ViewingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	viewing, err := h.dbService.AddViewing(id, &req)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "LaserDisc not found"})
+			return
+		}
+		respondViewingError(c, err, "Failed to add viewing")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Viewing added successfully",
+		"viewing": viewing,
+	})
+}
+
+// UpdateViewing changes the date, viewers, rating, review or copy of a
+// viewing
+// PUT /api/collection/:id/viewings/:viewingId
+func (h *CollectionHandler) UpdateViewing(c *gin.Context) {
+	id, viewingID, ok := parseViewingParams(c)
+	if !ok {
+		return
+	}
+
+	var req models.ViewingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format", "details": err.Error()})
+		return
+	}
+
+	viewing, err := h.dbService.UpdateViewing(id, viewingID, &req)
+	if err != nil {
+		respondViewingError(c, err, "Failed to update viewing")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Viewing updated successfully",
+		"viewing": viewing,
+	})
+}
+
+// DeleteViewing deletes a viewing. A LaserDisc without any is unwatched.
+// DELETE /api/collection/:id/viewings/:viewingId
+func (h *CollectionHandler) DeleteViewing(c *gin.Context) {
+	id, viewingID, ok := parseViewingParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.dbService.DeleteViewing(id, viewingID); err != nil {
+		respondViewingError(c, err, "Failed to delete viewing")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Viewing deleted successfully"})
+}
+
+// parseViewingParams reads the LaserDisc and viewing IDs from the path
+func parseViewingParams(c *gin.Context) (uint, uint, bool) {
+	id, ok := parseUintParam(c, "id", "Invalid LaserDisc ID")
+	if !ok {
+		return 0, 0, false
+	}
+	viewingID, ok := parseUintParam(c, "viewingId", "Invalid viewing ID")
+	if !ok {
+		return 0, 0, false
+	}
+	return id, viewingID, true
+}
+
+// respondViewingError responds to a failed viewing operation
+func respondViewingError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Viewing not found"})
+	case errors.Is(err, database.ErrInvalidViewing):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid viewing", "details": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
+
+// respondHasViewings responds to marking a LaserDisc unwatched when that
+// would delete its viewing history, reporting whether it was
+func respondHasViewings(c *gin.Context, err error) bool {
+	if !errors.Is(err, database.ErrHasViewings) {
+		return false
+	}
+	c.JSON(http.StatusConflict, gin.H{
+		"error": err.Error(),
+		"hint":  "Remove its viewings with DELETE /api/collection/:id/viewings/:viewingId",
+	})
+	return true
+}
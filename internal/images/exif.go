@@ -0,0 +1,88 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// orientationTag is the EXIF tag holding how the camera was held
+const orientationTag = 0x0112
+
+// orientation reads the EXIF orientation (1-8) from a JPEG, returning 1, the
+// upright default, when there is none or the EXIF data can't be read
+func orientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	// Walk the segments before the image data looking for APP1 Exif
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return 1
+		}
+		marker := data[i+1]
+		if marker == 0xD8 || (marker >= 0xD0 && marker <= 0xD7) || marker == 0x01 || marker == 0xFF {
+			i++ // standalone marker or fill byte
+			continue
+		}
+		if marker == 0xDA || marker == 0xD9 {
+			return 1 // start of scan, no EXIF from here on
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			return 1
+		}
+		segment := data[i+4 : end]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		i = end
+	}
+	return 1
+}
+
+// tiffOrientation finds the orientation tag in the first IFD of a TIFF header
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for n := 0; n < entries; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) != orientationTag {
+			continue
+		}
+		// A SHORT value sits in the first two bytes of the value field
+		if order.Uint16(tiff[entry+2:]) != 3 {
+			return 1
+		}
+		value := int(order.Uint16(tiff[entry+8:]))
+		if value < 1 || value > 8 {
+			return 1
+		}
+		return value
+	}
+	return 1
+}
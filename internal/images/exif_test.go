@@ -0,0 +1,115 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testJPEG encodes an image whose left half is black and right half white
+func testJPEG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if x >= width/2 {
+				img.Set(x, y, color.White)
+			} else {
+				img.Set(x, y, color.Black)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 100}))
+	return buf.Bytes()
+}
+
+// withOrientation inserts an APP1 Exif segment holding the given orientation
+// straight after the JPEG's start of image marker
+func withOrientation(data []byte, orientation int, order binary.ByteOrder) []byte {
+	var tiff bytes.Buffer
+	if order == binary.LittleEndian {
+		tiff.WriteString("II")
+	} else {
+		tiff.WriteString("MM")
+	}
+	binary.Write(&tiff, order, uint16(42))
+	binary.Write(&tiff, order, uint32(8)) // IFD0 follows the header
+	binary.Write(&tiff, order, uint16(2)) // two entries
+	// An unrelated tag first: ImageWidth, LONG
+	binary.Write(&tiff, order, uint16(0x0100))
+	binary.Write(&tiff, order, uint16(4))
+	binary.Write(&tiff, order, uint32(1))
+	binary.Write(&tiff, order, uint32(640))
+	// Orientation, SHORT, padded to four bytes
+	binary.Write(&tiff, order, uint16(orientationTag))
+	binary.Write(&tiff, order, uint16(3))
+	binary.Write(&tiff, order, uint32(1))
+	binary.Write(&tiff, order, uint16(orientation))
+	binary.Write(&tiff, order, uint16(0))
+	binary.Write(&tiff, order, uint32(0)) // no next IFD
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	result := append([]byte{}, data[:2]...)
+	result = append(result, segment...)
+	return append(result, data[2:]...)
+}
+
+func TestOrientation(t *testing.T) {
+	plain := testJPEG(t, 4, 2)
+	assert.Equal(t, 1, orientation(plain))
+
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for o := 1; o <= 8; o++ {
+			assert.Equal(t, o, orientation(withOrientation(plain, o, order)), "%v orientation %d", order, o)
+		}
+	}
+
+	// Out of range values and non-JPEG data fall back to upright
+	assert.Equal(t, 1, orientation(withOrientation(plain, 9, binary.BigEndian)))
+	assert.Equal(t, 1, orientation([]byte("\x89PNG\r\n\x1a\n")))
+	assert.Equal(t, 1, orientation(nil))
+
+	// Truncated EXIF data doesn't panic
+	broken := withOrientation(plain, 6, binary.LittleEndian)
+	assert.Equal(t, 1, orientation(broken[:20]))
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// 2x1: black then white
+	src := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	src.Set(0, 0, color.Black)
+	src.Set(1, 0, color.White)
+	black := color.RGBAModel.Convert(color.Black)
+	white := color.RGBAModel.Convert(color.White)
+
+	assert.Same(t, image.Image(src), applyOrientation(src, 1))
+
+	// Mirrored swaps left and right
+	dst := applyOrientation(src, 2)
+	assert.Equal(t, image.Rect(0, 0, 2, 1), dst.Bounds())
+	assert.Equal(t, white, dst.At(0, 0))
+
+	// Turning clockwise puts the left edge at the top
+	dst = applyOrientation(src, 6)
+	assert.Equal(t, image.Rect(0, 0, 1, 2), dst.Bounds())
+	assert.Equal(t, black, dst.At(0, 0))
+	assert.Equal(t, white, dst.At(0, 1))
+
+	// Turning anticlockwise puts the right edge at the top
+	dst = applyOrientation(src, 8)
+	assert.Equal(t, image.Rect(0, 0, 1, 2), dst.Bounds())
+	assert.Equal(t, white, dst.At(0, 0))
+	assert.Equal(t, black, dst.At(0, 1))
+}
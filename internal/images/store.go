@@ -0,0 +1,180 @@
+// Package images stores photos users upload of their own LaserDiscs, such
+// as the sleeve, disc labels or damage.
+package images
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	_ "image/gif" // registered for image.Decode
+	_ "image/png"
+)
+
+// DefaultMaxBytes is the largest upload accepted unless configured otherwise
+const DefaultMaxBytes = 20 << 20
+
+// MaxPixels is the most pixels an image may have. Small files can claim huge
+// dimensions, and decoding one would take gigabytes of memory.
+const MaxPixels = 50_000_000
+
+var (
+	// ErrTooLarge is returned for uploads over the store's size limit
+	ErrTooLarge = errors.New("image is too large")
+
+	// ErrUnsupportedType is returned for uploads that aren't a JPEG, PNG or GIF
+	ErrUnsupportedType = errors.New("unsupported image type")
+)
+
+// extensions maps the accepted content types to the extension files are saved with
+var extensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+// fileNamePattern matches the names the store gives saved images
+var fileNamePattern = regexp.MustCompile(`^[0-9a-f]{64}\.(jpg|png|gif)$`)
+
+// Store keeps uploaded images on disk, named by the SHA-256 of their content
+type Store struct {
+	dir      string
+	maxBytes int64
+}
+
+// Saved describes an image written to the store
+type Saved struct {
+	File        string
+	ContentType string
+	Width       int
+	Height      int
+	Size        int64
+}
+
+// NewStore creates a store saving images under dir, refusing uploads larger
+// than maxBytes (DefaultMaxBytes when zero or negative)
+func NewStore(dir string, maxBytes int64) *Store {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	return &Store{dir: dir, maxBytes: maxBytes}
+}
+
+// MaxBytes returns the largest upload the store accepts
+func (s *Store) MaxBytes() int64 {
+	return s.maxBytes
+}
+
+// Save validates and stores an uploaded image. JPEGs taken with the camera
+// on its side are turned upright according to their EXIF orientation.
+func (s *Store) Save(r io.Reader) (Saved, error) {
+	data, err := io.ReadAll(io.LimitReader(r, s.maxBytes+1))
+	if err != nil {
+		return Saved{}, err
+	}
+	if int64(len(data)) > s.maxBytes {
+		return Saved{}, fmt.Errorf("%w: the limit is %d bytes", ErrTooLarge, s.maxBytes)
+	}
+
+	// Trust the bytes rather than the file name or the client's content type
+	contentType := http.DetectContentType(data)
+	ext, ok := extensions[contentType]
+	if !ok {
+		return Saved{}, fmt.Errorf("%w %s, expected JPEG, PNG or GIF", ErrUnsupportedType, contentType)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Saved{}, fmt.Errorf("%w: %v", ErrUnsupportedType, err)
+	}
+	if int64(config.Width)*int64(config.Height) > MaxPixels {
+		return Saved{}, fmt.Errorf("%w: %dx%d pixels, the limit is %d megapixels", ErrTooLarge, config.Width, config.Height, MaxPixels/1_000_000)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Saved{}, fmt.Errorf("%w: %v", ErrUnsupportedType, err)
+	}
+
+	if contentType == "image/jpeg" {
+		if o := orientation(data); o != 1 {
+			img = applyOrientation(img, o)
+			var buf bytes.Buffer
+			if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 92}); err != nil {
+				return Saved{}, err
+			}
+			data = buf.Bytes()
+		}
+	}
+
+	sum := sha256.Sum256(data)
+	file := hex.EncodeToString(sum[:]) + "." + ext
+	if err := s.writeFile(file, data); err != nil {
+		return Saved{}, err
+	}
+
+	return Saved{
+		File:        file,
+		ContentType: contentType,
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+		Size:        int64(len(data)),
+	}, nil
+}
+
+// Path returns where a saved image is on disk
+func (s *Store) Path(file string) (string, error) {
+	if !fileNamePattern.MatchString(file) {
+		return "", fmt.Errorf("invalid image file name %q", file)
+	}
+	return filepath.Join(s.dir, file), nil
+}
+
+// Remove deletes a saved image. Images are shared by content, so only call
+// it once nothing refers to the file any more.
+func (s *Store) Remove(file string) error {
+	path, err := s.Path(file)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// writeFile stores data under name unless it's already there
+func (s *Store) writeFile(name string, data []byte) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(s.dir, name)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	// Write to a temporary file first so a crash never leaves half an image
+	tmp, err := os.CreateTemp(s.dir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,118 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_Save(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir, 0)
+	assert.Equal(t, int64(DefaultMaxBytes), store.MaxBytes())
+
+	data := testJPEG(t, 40, 20)
+	saved, err := store.Save(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Regexp(t, `^[0-9a-f]{64}\.jpg$`, saved.File)
+	assert.Equal(t, "image/jpeg", saved.ContentType)
+	assert.Equal(t, 40, saved.Width)
+	assert.Equal(t, 20, saved.Height)
+	assert.Equal(t, int64(len(data)), saved.Size)
+
+	// Upright images are stored byte for byte
+	path, err := store.Path(saved.File)
+	require.NoError(t, err)
+	stored, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, data, stored)
+
+	// The same upload is stored once
+	again, err := store.Save(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, saved.File, again.File)
+
+	require.NoError(t, store.Remove(saved.File))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+	assert.NoError(t, store.Remove(saved.File), "removing a missing file is fine")
+}
+
+func TestStore_SaveRotatesByEXIF(t *testing.T) {
+	store := NewStore(t.TempDir(), 0)
+
+	// Taken on its side: needs turning clockwise, so the left (black) half ends up on top
+	data := withOrientation(testJPEG(t, 40, 20), 6, binary.LittleEndian)
+	saved, err := store.Save(bytes.NewReader(data))
+	require.NoError(t, err)
+	assert.Equal(t, 20, saved.Width)
+	assert.Equal(t, 40, saved.Height)
+
+	path, err := store.Path(saved.File)
+	require.NoError(t, err)
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	img, err := jpeg.Decode(f)
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 20, 40), img.Bounds())
+
+	top, _, _, _ := img.At(10, 5).RGBA()
+	bottom, _, _, _ := img.At(10, 35).RGBA()
+	assert.Less(t, top, uint32(0x2000))
+	assert.Greater(t, bottom, uint32(0xe000))
+
+	// The rewritten file no longer carries the orientation
+	assert.Equal(t, 1, orientation(mustReadFile(t, path)))
+}
+
+func TestStore_SaveValidates(t *testing.T) {
+	store := NewStore(t.TempDir(), 1024)
+
+	_, err := store.Save(bytes.NewReader([]byte("<html>not an image</html>")))
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+
+	// Looks like a PNG but doesn't decode
+	_, err = store.Save(bytes.NewReader([]byte("\x89PNG\r\n\x1a\n" + "garbage")))
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+
+	_, err = store.Save(bytes.NewReader(make([]byte, 1025)))
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	// A GIF header claiming 8000x8000 pixels is refused before decoding
+	_, err = store.Save(bytes.NewReader([]byte("GIF89a\x40\x1f\x40\x1f\x00\x00\x00")))
+	assert.ErrorIs(t, err, ErrTooLarge)
+	assert.ErrorContains(t, err, "8000x8000")
+
+	img := image.NewRGBA(image.Rect(0, 0, 3, 3))
+	img.Set(1, 1, color.White)
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	saved, err := store.Save(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", saved.ContentType)
+	assert.Regexp(t, `\.png$`, saved.File)
+}
+
+func TestStore_Path(t *testing.T) {
+	store := NewStore(t.TempDir(), 0)
+
+	_, err := store.Path("../collection.db")
+	assert.Error(t, err)
+	assert.Error(t, store.Remove("../collection.db"))
+}
+
+func mustReadFile(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return data
+}
@@ -0,0 +1,89 @@
+package migrations
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// column is a row of SQLite's PRAGMA table_info
+type column struct {
+	Name      string
+	Type      string
+	NotNull   bool    `gorm:"column:notnull"`
+	DfltValue *string `gorm:"column:dflt_value"`
+}
+
+// tableColumns returns the columns of a table by name
+func tableColumns(db *gorm.DB, table string) (map[string]column, error) {
+	var rows []column
+	if err := db.Raw(fmt.Sprintf("PRAGMA table_info(`%s`)", table)).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	columns := make(map[string]column, len(rows))
+	for _, row := range rows {
+		columns[row.Name] = row
+	}
+	return columns, nil
+}
+
+// addMissingColumns brings tables AutoMigrate created before migrations
+// existed up to the initial migration. AutoMigrate only ever added columns,
+// so a table from an older build can only be missing some. The initial
+// migration is run on a scratch database to see which columns it expects.
+func addMissingColumns(db *gorm.DB, initial Migration) error {
+	scratch, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		return err
+	}
+	sqlDB, err := scratch.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+	sqlDB.SetMaxOpenConns(1) // each connection would get its own empty database
+
+	if err := scratch.Exec(initial.Up).Error; err != nil {
+		return err
+	}
+	var tables []string
+	if err := scratch.Raw("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%'").Scan(&tables).Error; err != nil {
+		return err
+	}
+
+	for _, table := range tables {
+		if !db.Migrator().HasTable(table) {
+			continue // created by the migration itself
+		}
+		want, err := tableColumns(scratch, table)
+		if err != nil {
+			return err
+		}
+		have, err := tableColumns(db, table)
+		if err != nil {
+			return err
+		}
+
+		for name, col := range want {
+			if _, ok := have[name]; ok {
+				continue
+			}
+			// Added columns can't be NOT NULL without a default, as the
+			// existing rows have no value for them
+			definition := []string{fmt.Sprintf("`%s`", name), col.Type}
+			if col.DfltValue != nil {
+				if col.NotNull {
+					definition = append(definition, "NOT NULL")
+				}
+				definition = append(definition, "DEFAULT", *col.DfltValue)
+			}
+			if err := db.Exec(fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN %s", table, strings.Join(definition, " "))).Error; err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BackupPath names the backup of the database at dbPath taken before
+// migrating it at the given time
+func BackupPath(dbPath string, at time.Time) string {
+	return fmt.Sprintf("%s.%s.pre-migration", dbPath, at.Format("20060102-150405"))
+}
+
+// Backup copies an SQLite database to path. VACUUM INTO writes a consistent
+// copy even while the database is in use.
+func Backup(db *gorm.DB, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("backup %s already exists", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return db.Exec("VACUUM INTO ?", path).Error
+}
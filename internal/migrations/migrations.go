@@ -0,0 +1,253 @@
+// Package migrations versions the database schema. Migrations are numbered
+// SQL files embedded in the binary, named like 0002_add_tags.up.sql with a
+// matching .down.sql, and the versions applied to a database are recorded
+// in its schema_migrations table.
+package migrations
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+var (
+	// ErrNothingToRollBack is returned by Down when no migration is applied
+	ErrNothingToRollBack = errors.New("no migrations have been applied")
+
+	// ErrIrreversible is returned by Down for a migration without a down file
+	ErrIrreversible = errors.New("migration can't be rolled back")
+)
+
+// Migration is one numbered schema change
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string // empty when the migration can't be rolled back
+}
+
+// Status is a migration and when it was applied, if it has been
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// appliedMigration is a row of the schema_migrations table
+type appliedMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName returns the table name for the appliedMigration model
+func (appliedMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// fileName matches migration files, e.g. 0001_initial.up.sql
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Load reads the migrations embedded in the binary, in version order
+func Load() ([]Migration, error) {
+	return load(files, "sql")
+}
+
+// load reads the migrations in dir of fsys, in version order
+func load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		contents, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration := byVersion[version]
+		if migration == nil {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(contents)
+		} else {
+			migration.Down = string(contents)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d has no up file", migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Migrator applies and rolls back migrations on a database
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New creates a migrator for db using the embedded migrations
+func New(db *gorm.DB) (*Migrator, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// applied returns when each applied version was applied. It doesn't create
+// the schema_migrations table, so reading the status changes nothing.
+func (m *Migrator) applied() (map[int]time.Time, error) {
+	applied := make(map[int]time.Time)
+	if !m.db.Migrator().HasTable(&appliedMigration{}) {
+		return applied, nil
+	}
+
+	var rows []appliedMigration
+	if err := m.db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		applied[row.Version] = row.AppliedAt
+	}
+	return applied, nil
+}
+
+// Status lists every migration and whether it has been applied
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i].Migration = migration
+		if at, ok := applied[migration.Version]; ok {
+			statuses[i].AppliedAt = &at
+		}
+	}
+	return statuses, nil
+}
+
+// Pending lists the migrations not yet applied. It fails if the database has
+// a migration this build doesn't know, since it was migrated by a newer one.
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[int]bool, len(m.migrations))
+	var pending []Migration
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	for version := range applied {
+		if !known[version] {
+			return nil, fmt.Errorf("database has migration %d, which this build doesn't know; it was migrated by a newer version", version)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies every pending migration in order, each in its own transaction,
+// and returns the ones it applied
+func (m *Migrator) Up() ([]Migration, error) {
+	// Databases from before migrations have tables but no schema_migrations
+	adopting := !m.db.Migrator().HasTable(&appliedMigration{}) && m.db.Migrator().HasTable("laserdiscs")
+
+	pending, err := m.Pending()
+	if err != nil || len(pending) == 0 {
+		return nil, err
+	}
+	if err := m.db.AutoMigrate(&appliedMigration{}); err != nil {
+		return nil, err
+	}
+	if adopting {
+		if err := addMissingColumns(m.db, m.migrations[0]); err != nil {
+			return nil, fmt.Errorf("adopting existing schema: %w", err)
+		}
+	}
+
+	var done []Migration
+	for _, migration := range pending {
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(migration.Up).Error; err != nil {
+				return err
+			}
+			return tx.Create(&appliedMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return done, fmt.Errorf("migration %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+// Down rolls back the most recently applied migration and returns it
+func (m *Migrator) Down() (*Migration, error) {
+	// Refuse to roll back underneath a newer build's migrations
+	if _, err := m.Pending(); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == "" {
+			return nil, fmt.Errorf("%04d_%s: %w", migration.Version, migration.Name, ErrIrreversible)
+		}
+
+		err := m.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Exec(migration.Down).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&appliedMigration{}, migration.Version).Error
+		})
+		if err != nil {
+			return nil, fmt.Errorf("rolling back %04d_%s: %w", migration.Version, migration.Name, err)
+		}
+		return &migration, nil
+	}
+	return nil, ErrNothingToRollBack
+}
@@ -0,0 +1,246 @@
+package migrations
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// openTestDB opens an empty SQLite database file
+func openTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	return db
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	for i, migration := range migrations {
+		assert.Equal(t, i+1, migration.Version, "versions are numbered without gaps")
+		assert.NotEmpty(t, migration.Up)
+	}
+	assert.Equal(t, "initial", migrations[0].Name)
+}
+
+func TestLoad_Files(t *testing.T) {
+	migrations, err := load(fstest.MapFS{
+		"sql/0002_second.up.sql":   {Data: []byte("CREATE TABLE b (id integer);")},
+		"sql/0001_first.up.sql":    {Data: []byte("CREATE TABLE a (id integer);")},
+		"sql/0001_first.down.sql":  {Data: []byte("DROP TABLE a;")},
+		"sql/0010_tenth.up.sql":    {Data: []byte("CREATE TABLE c (id integer);")},
+		"sql/0010_tenth.down.sql":  {Data: []byte("DROP TABLE c;")},
+		"sql/0002_second.down.sql": {Data: []byte("DROP TABLE b;")},
+	}, "sql")
+	require.NoError(t, err)
+	require.Len(t, migrations, 3)
+	assert.Equal(t, []int{1, 2, 10}, []int{migrations[0].Version, migrations[1].Version, migrations[2].Version})
+	assert.Equal(t, "DROP TABLE a;", migrations[0].Down)
+
+	_, err = load(fstest.MapFS{"sql/0001_first.down.sql": {Data: []byte("DROP TABLE a;")}}, "sql")
+	assert.ErrorContains(t, err, "no up file")
+
+	_, err = load(fstest.MapFS{"sql/first.up.sql": {Data: []byte("")}}, "sql")
+	assert.ErrorContains(t, err, "unexpected migration file")
+
+	_, err = load(fstest.MapFS{
+		"sql/0001_first.up.sql":   {Data: []byte("")},
+		"sql/0001_other.up.sql":   {Data: []byte("")},
+		"sql/0001_first.down.sql": {Data: []byte("")},
+	}, "sql")
+	assert.ErrorContains(t, err, "named both")
+}
+
+func TestMigrator_UpMatchesModels(t *testing.T) {
+	db := openTestDB(t)
+	migrator, err := New(db)
+	require.NoError(t, err)
+
+	applied, err := migrator.Up()
+	require.NoError(t, err)
+	assert.Len(t, applied, len(migrator.migrations))
+
+	// Every model field needs a column, or queries through the model fail
+	for _, model := range []interface{}{
+		&models.LaserDisc{}, &models.LookupCacheEntry{}, &models.LaserDiscImage{},
+		&models.FieldProvenance{}, &models.RefreshJob{}, &models.MetadataChange{},
+		&models.Copy{}, &models.Inspection{}, &models.Viewing{},
+		&models.RandomPick{}, &models.Tag{}, &models.LaserDiscTag{},
+		&models.CustomField{}, &models.CustomFieldValue{},
+	} {
+		parsed, err := schema.Parse(model, &sync.Map{}, db.NamingStrategy)
+		require.NoError(t, err)
+		require.True(t, db.Migrator().HasTable(model), parsed.Table)
+		for _, field := range parsed.Fields {
+			if field.DBName != "" {
+				assert.True(t, db.Migrator().HasColumn(model, field.DBName), "%s.%s", parsed.Table, field.DBName)
+			}
+		}
+	}
+
+	// Nothing left to do the second time
+	applied, err = migrator.Up()
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	statuses, err := migrator.Status()
+	require.NoError(t, err)
+	for _, status := range statuses {
+		assert.NotNil(t, status.AppliedAt, status.Name)
+	}
+}
+
+func TestMigrator_Down(t *testing.T) {
+	db := openTestDB(t)
+	migrator, err := New(db)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+
+	// Stand-ins for the full-text index and a trigger keeping it up to date
+	require.NoError(t, db.Exec("CREATE TABLE laserdiscs_fts (title text)").Error)
+	require.NoError(t, db.Exec("CREATE TRIGGER laserdiscs_fts_insert AFTER INSERT ON lookup_cache BEGIN INSERT INTO laserdiscs_fts (title) VALUES (new.key); END").Error)
+
+	for i := len(migrator.migrations); i > 0; i-- {
+		rolledBack, err := migrator.Down()
+		require.NoError(t, err)
+		assert.Equal(t, i, rolledBack.Version)
+	}
+	assert.False(t, db.Migrator().HasTable("laserdiscs"))
+	assert.False(t, db.Migrator().HasTable("laserdiscs_fts"))
+	var triggers int64
+	require.NoError(t, db.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger'").Scan(&triggers).Error)
+	assert.Zero(t, triggers)
+
+	_, err = migrator.Down()
+	assert.ErrorIs(t, err, ErrNothingToRollBack)
+
+	pending, err := migrator.Pending()
+	require.NoError(t, err)
+	assert.Len(t, pending, len(migrator.migrations))
+}
+
+func TestMigrator_Irreversible(t *testing.T) {
+	db := openTestDB(t)
+	migrator := &Migrator{db: db, migrations: []Migration{{Version: 1, Name: "one_way", Up: "CREATE TABLE a (id integer);"}}}
+	_, err := migrator.Up()
+	require.NoError(t, err)
+
+	_, err = migrator.Down()
+	assert.ErrorIs(t, err, ErrIrreversible)
+	assert.True(t, db.Migrator().HasTable("a"))
+}
+
+func TestMigrator_FailedMigrationRollsBack(t *testing.T) {
+	db := openTestDB(t)
+	migrator := &Migrator{db: db, migrations: []Migration{
+		{Version: 1, Name: "good", Up: "CREATE TABLE a (id integer);"},
+		{Version: 2, Name: "bad", Up: "CREATE TABLE b (id integer); INSERT INTO missing VALUES (1);"},
+	}}
+
+	applied, err := migrator.Up()
+	assert.ErrorContains(t, err, "0002_bad")
+	require.Len(t, applied, 1)
+	assert.True(t, db.Migrator().HasTable("a"))
+	assert.False(t, db.Migrator().HasTable("b"), "a failed migration leaves nothing behind")
+
+	pending, err := migrator.Pending()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, 2, pending[0].Version)
+}
+
+func TestMigrator_NewerDatabase(t *testing.T) {
+	db := openTestDB(t)
+	migrator, err := New(db)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+	require.NoError(t, db.Create(&appliedMigration{Version: 9999, Name: "future", AppliedAt: time.Now()}).Error)
+
+	_, err = migrator.Up()
+	assert.ErrorContains(t, err, "newer version")
+	_, err = migrator.Down()
+	assert.ErrorContains(t, err, "newer version")
+}
+
+func TestMigrator_AdoptsAutoMigratedDatabase(t *testing.T) {
+	db := openTestDB(t)
+
+	// The laserdiscs table as AutoMigrate created it in the first release
+	require.NoError(t, db.Exec("CREATE TABLE `laserdiscs` (`id` integer PRIMARY KEY AUTOINCREMENT,`upc` text NOT NULL,`title` text NOT NULL,`year` integer,`director` text,`genre` text,`format` text,`sides` integer,`runtime` integer,`cover_image_url` text,`lddb_url` text,`watched` numeric DEFAULT false,`notes` text,`added_date` datetime,`updated_date` datetime)").Error)
+	require.NoError(t, db.Exec("CREATE UNIQUE INDEX `idx_laserdiscs_upc` ON `laserdiscs`(`upc`)").Error)
+	require.NoError(t, db.Exec("INSERT INTO laserdiscs (upc, title, watched) VALUES ('00012345678905', 'Alien', true)").Error)
+
+	migrator, err := New(db)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+
+	var laserdisc models.LaserDisc
+	require.NoError(t, db.First(&laserdisc).Error)
+	assert.Equal(t, "Alien", laserdisc.Title)
+	assert.True(t, laserdisc.Watched)
+	assert.True(t, db.Migrator().HasColumn(&models.LaserDisc{}, "disc_modes"))
+	assert.True(t, db.Migrator().HasTable(&models.MetadataChange{}))
+
+	// Each LaserDisc already added becomes one copy
+	var copies []models.Copy
+	require.NoError(t, db.Find(&copies).Error)
+	require.Len(t, copies, 1)
+	assert.Equal(t, laserdisc.ID, copies[0].LaserDiscID)
+	assert.Equal(t, models.RotUnknown, copies[0].RotStatus)
+
+	// Watched ones were watched once, on a day that isn't known
+	var viewings []models.Viewing
+	require.NoError(t, db.Find(&viewings).Error)
+	require.Len(t, viewings, 1)
+	assert.Equal(t, laserdisc.ID, viewings[0].LaserDiscID)
+	assert.Nil(t, viewings[0].WatchedAt)
+
+	// Only UPCs that are given must be unique
+	require.NoError(t, db.Exec("INSERT INTO laserdiscs (upc, title) VALUES ('', 'Promo'), ('', 'Import')").Error)
+	assert.Error(t, db.Exec("INSERT INTO laserdiscs (upc, title) VALUES ('00012345678905', 'Alien again')").Error)
+
+	// and they can't be required again while several LaserDiscs have none
+	_, err = migrator.Down()
+	assert.ErrorContains(t, err, "several LaserDiscs have no UPC")
+	require.NoError(t, db.Exec("DELETE FROM laserdiscs WHERE title = 'Import'").Error)
+	_, err = migrator.Down()
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+
+	pending, err := migrator.Pending()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestBackup(t *testing.T) {
+	db := openTestDB(t)
+	require.NoError(t, db.Exec("CREATE TABLE a (id integer); INSERT INTO a VALUES (1), (2);").Error)
+
+	at := time.Date(2024, 3, 1, 14, 30, 0, 0, time.UTC)
+	path := BackupPath(filepath.Join(t.TempDir(), "backups", "collection.db"), at)
+	assert.Equal(t, "collection.db.20240301-143000.pre-migration", filepath.Base(path))
+	require.NoError(t, Backup(db, path))
+	assert.Error(t, Backup(db, path), "never overwrites a backup")
+
+	backup, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Discard})
+	require.NoError(t, err)
+	var count int64
+	require.NoError(t, backup.Table("a").Count(&count).Error)
+	assert.Equal(t, int64(2), count)
+}
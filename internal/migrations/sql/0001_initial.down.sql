@@ -0,0 +1,12 @@
+-- The full-text index and its triggers are created outside migrations, by
+-- EnableFullTextSearch, but are only meaningful alongside laserdiscs
+DROP TRIGGER IF EXISTS `laserdiscs_fts_insert`;
+DROP TRIGGER IF EXISTS `laserdiscs_fts_update`;
+DROP TRIGGER IF EXISTS `laserdiscs_fts_delete`;
+DROP TABLE IF EXISTS `laserdiscs_fts`;
+DROP TABLE IF EXISTS `metadata_changes`;
+DROP TABLE IF EXISTS `refresh_jobs`;
+DROP TABLE IF EXISTS `field_provenance`;
+DROP TABLE IF EXISTS `laserdisc_images`;
+DROP TABLE IF EXISTS `lookup_cache`;
+DROP TABLE IF EXISTS `laserdiscs`;
@@ -0,0 +1,105 @@
+-- Schema as created by AutoMigrate before versioned migrations. Everything
+-- is IF NOT EXISTS so databases created back then can adopt it.
+
+CREATE TABLE IF NOT EXISTS `laserdiscs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `upc` text NOT NULL,
+    `title` text NOT NULL,
+    `year` integer,
+    `director` text,
+    `genre` text,
+    `format` text,
+    `sides` integer,
+    `runtime` integer,
+    `cover_image_url` text,
+    `cover_file` text,
+    `cover_thumb` text,
+    `cover_source` text,
+    `lddb_url` text,
+    `reference` text,
+    `label` text,
+    `release_date` text,
+    `country` text,
+    `video_standard` text,
+    `picture_format` text,
+    `aspect_ratio` text,
+    `sound` text,
+    `chapters` integer,
+    `price` text,
+    `disc_modes` text,
+    `cast` text,
+    `producer` text,
+    `watched` numeric DEFAULT false,
+    `notes` text,
+    `added_date` datetime,
+    `updated_date` datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_laserdiscs_upc` ON `laserdiscs`(`upc`);
+
+CREATE TABLE IF NOT EXISTS `lookup_cache` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `kind` text NOT NULL,
+    `key` text NOT NULL,
+    `result` text NOT NULL,
+    `detail_html` text,
+    `found` numeric,
+    `fetched_at` datetime NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_lookup_cache_kind_key` ON `lookup_cache`(`kind`, `key`);
+
+CREATE TABLE IF NOT EXISTS `laserdisc_images` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `laser_disc_id` integer NOT NULL,
+    `kind` text NOT NULL DEFAULT "other",
+    `caption` text,
+    `file` text NOT NULL,
+    `content_type` text,
+    `width` integer,
+    `height` integer,
+    `size` integer,
+    `position` integer,
+    `is_primary` numeric DEFAULT false,
+    `created_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_laserdisc_images_laser_disc_id` ON `laserdisc_images`(`laser_disc_id`);
+
+CREATE TABLE IF NOT EXISTS `field_provenance` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `laser_disc_id` integer NOT NULL,
+    `field` text NOT NULL,
+    `source` text NOT NULL,
+    `updated_at` datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_field_provenance_laserdisc_field` ON `field_provenance`(`laser_disc_id`, `field`);
+
+CREATE TABLE IF NOT EXISTS `refresh_jobs` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `status` text NOT NULL,
+    `filter` text,
+    `total` integer,
+    `processed` integer,
+    `updated` integer,
+    `suggested` integer,
+    `failed` integer,
+    `errors` text,
+    `error` text,
+    `created_at` datetime,
+    `finished_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_refresh_jobs_status` ON `refresh_jobs`(`status`);
+
+CREATE TABLE IF NOT EXISTS `metadata_changes` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `job_id` integer NOT NULL,
+    `laser_disc_id` integer NOT NULL,
+    `field` text NOT NULL,
+    `old_value` blob NOT NULL,
+    `new_value` blob NOT NULL,
+    `source` text NOT NULL,
+    `status` text NOT NULL,
+    `created_at` datetime,
+    `resolved_at` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_metadata_changes_job_id` ON `metadata_changes`(`job_id`);
+CREATE INDEX IF NOT EXISTS `idx_metadata_changes_laser_disc_id` ON `metadata_changes`(`laser_disc_id`);
+CREATE INDEX IF NOT EXISTS `idx_metadata_changes_status` ON `metadata_changes`(`status`);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS `idx_laserdiscs_title`;
+DROP INDEX IF EXISTS `idx_laserdiscs_year`;
+DROP INDEX IF EXISTS `idx_laserdiscs_director`;
+DROP INDEX IF EXISTS `idx_laserdiscs_genre`;
+DROP INDEX IF EXISTS `idx_laserdiscs_watched`;
+DROP INDEX IF EXISTS `idx_laserdiscs_added_date`;
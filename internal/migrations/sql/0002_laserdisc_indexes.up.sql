@@ -0,0 +1,7 @@
+-- Columns the collection is commonly filtered and sorted by
+CREATE INDEX IF NOT EXISTS `idx_laserdiscs_title` ON `laserdiscs`(`title`);
+CREATE INDEX IF NOT EXISTS `idx_laserdiscs_year` ON `laserdiscs`(`year`);
+CREATE INDEX IF NOT EXISTS `idx_laserdiscs_director` ON `laserdiscs`(`director`);
+CREATE INDEX IF NOT EXISTS `idx_laserdiscs_genre` ON `laserdiscs`(`genre`);
+CREATE INDEX IF NOT EXISTS `idx_laserdiscs_watched` ON `laserdiscs`(`watched`);
+CREATE INDEX IF NOT EXISTS `idx_laserdiscs_added_date` ON `laserdiscs`(`added_date`);
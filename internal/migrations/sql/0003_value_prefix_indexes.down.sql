@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS `idx_laserdiscs_title_nocase`;
+DROP INDEX IF EXISTS `idx_laserdiscs_director_nocase`;
+DROP INDEX IF EXISTS `idx_laserdiscs_genre_nocase`;
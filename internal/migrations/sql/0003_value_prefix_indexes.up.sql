@@ -0,0 +1,4 @@
+-- Case-insensitive indexes, so completing a prefix with LIKE can use them
+CREATE INDEX IF NOT EXISTS `idx_laserdiscs_title_nocase` ON `laserdiscs`(`title` COLLATE NOCASE);
+CREATE INDEX IF NOT EXISTS `idx_laserdiscs_director_nocase` ON `laserdiscs`(`director` COLLATE NOCASE);
+CREATE INDEX IF NOT EXISTS `idx_laserdiscs_genre_nocase` ON `laserdiscs`(`genre` COLLATE NOCASE);
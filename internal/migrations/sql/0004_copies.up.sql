@@ -0,0 +1,21 @@
+-- Physical copies of each LaserDisc release, which keeps the metadata
+CREATE TABLE IF NOT EXISTS `copies` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `laser_disc_id` integer NOT NULL,
+    `sealed` numeric DEFAULT false,
+    `sleeve_grade` text,
+    `side_grades` text,
+    `rot_status` text NOT NULL DEFAULT "unknown",
+    `purchase_date` text,
+    `purchase_price` real,
+    `currency` text,
+    `seller` text,
+    `notes` text,
+    `added_date` datetime,
+    `updated_date` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_copies_laser_disc_id` ON `copies`(`laser_disc_id`);
+
+-- Every LaserDisc added so far is one copy
+INSERT INTO `copies` (`laser_disc_id`, `added_date`, `updated_date`)
+SELECT `id`, `added_date`, `added_date` FROM `laserdiscs`;
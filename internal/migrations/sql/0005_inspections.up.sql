@@ -0,0 +1,17 @@
+-- Playback and laser rot inspections of each side of a copy
+CREATE TABLE IF NOT EXISTS `inspections` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `copy_id` integer NOT NULL,
+    `laser_disc_id` integer NOT NULL,
+    `side` integer NOT NULL,
+    `inspected_date` text NOT NULL,
+    `player` text,
+    `defect` text NOT NULL,
+    `timecode` text,
+    `chapter` integer,
+    `severity` integer,
+    `notes` text,
+    `added_date` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_inspections_copy_id` ON `inspections`(`copy_id`, `side`, `inspected_date`);
+CREATE INDEX IF NOT EXISTS `idx_inspections_laser_disc_id` ON `inspections`(`laser_disc_id`);
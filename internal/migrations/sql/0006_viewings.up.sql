@@ -0,0 +1,19 @@
+-- Each time a LaserDisc was watched. The watched flag is kept in step with
+-- them, so it can still be filtered and sorted on.
+CREATE TABLE IF NOT EXISTS `viewings` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `laser_disc_id` integer NOT NULL,
+    `watched_at` datetime,
+    `viewers` text,
+    `rating` integer,
+    `review` text,
+    `copy_id` integer,
+    `player` text,
+    `added_date` datetime,
+    `updated_date` datetime
+);
+CREATE INDEX IF NOT EXISTS `idx_viewings_laser_disc_id` ON `viewings`(`laser_disc_id`, `watched_at`);
+
+-- LaserDiscs marked watched were watched at least once, when isn't known
+INSERT INTO `viewings` (`laser_disc_id`, `added_date`, `updated_date`)
+SELECT `id`, `updated_date`, `updated_date` FROM `laserdiscs` WHERE `watched` = true;
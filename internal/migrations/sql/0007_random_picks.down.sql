@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `random_picks`;
@@ -0,0 +1,9 @@
+-- LaserDiscs suggested by the movie night picker, so they aren't suggested
+-- again straight away
+CREATE TABLE IF NOT EXISTS `random_picks` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `laser_disc_id` integer NOT NULL,
+    `seed` integer NOT NULL,
+    `picked_at` datetime NOT NULL
+);
+CREATE INDEX IF NOT EXISTS `idx_random_picks_picked_at` ON `random_picks`(`picked_at`);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS `custom_field_values`;
+DROP TABLE IF EXISTS `custom_fields`;
+DROP TABLE IF EXISTS `laserdisc_tags`;
+DROP TABLE IF EXISTS `tags`;
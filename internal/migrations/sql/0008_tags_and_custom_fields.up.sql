@@ -0,0 +1,33 @@
+-- User-defined tags, e.g. "Criterion" or "needs cleaning"
+CREATE TABLE IF NOT EXISTS `tags` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `name` text NOT NULL COLLATE NOCASE,
+    `color` text,
+    `created_at` datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_tags_name` ON `tags`(`name`);
+
+CREATE TABLE IF NOT EXISTS `laserdisc_tags` (
+    `laser_disc_id` integer NOT NULL,
+    `tag_id` integer NOT NULL,
+    PRIMARY KEY (`laser_disc_id`, `tag_id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_laserdisc_tags_tag_id` ON `laserdisc_tags`(`tag_id`);
+
+-- User-defined typed fields, and each LaserDisc's value for them as text
+CREATE TABLE IF NOT EXISTS `custom_fields` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `name` text NOT NULL COLLATE NOCASE,
+    `type` text NOT NULL,
+    `options` text,
+    `created_at` datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_custom_fields_name` ON `custom_fields`(`name`);
+
+CREATE TABLE IF NOT EXISTS `custom_field_values` (
+    `laser_disc_id` integer NOT NULL,
+    `field_id` integer NOT NULL,
+    `value` text NOT NULL,
+    PRIMARY KEY (`laser_disc_id`, `field_id`)
+);
+CREATE INDEX IF NOT EXISTS `idx_custom_field_values_field_id` ON `custom_field_values`(`field_id`, `value`);
@@ -0,0 +1,10 @@
+-- UPCs can only be unique again while at most one LaserDisc has none, so
+-- rolling back refuses until the others are given a UPC or deleted
+CREATE TEMP TABLE `upc_rollback_check` (
+    `missing` integer CONSTRAINT `several LaserDiscs have no UPC, give them one or delete them before rolling back` CHECK (`missing` <= 1)
+);
+INSERT INTO `upc_rollback_check` SELECT COUNT(*) FROM `laserdiscs` WHERE `upc` = '';
+DROP TABLE `upc_rollback_check`;
+
+DROP INDEX IF EXISTS `idx_laserdiscs_upc`;
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_laserdiscs_upc` ON `laserdiscs`(`upc`);
@@ -0,0 +1,4 @@
+-- LaserDiscs without a readable barcode, e.g. promos and some imports, are
+-- saved with an empty UPC, so only UPCs that are given must be unique
+DROP INDEX IF EXISTS `idx_laserdiscs_upc`;
+CREATE UNIQUE INDEX IF NOT EXISTS `idx_laserdiscs_upc` ON `laserdiscs`(`upc`) WHERE `upc` != '';
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Goldmine-style condition grades, best first
+const (
+	GradeMint         = "M"
+	GradeNearMint     = "NM"
+	GradeVeryGoodPlus = "VG+"
+	GradeVeryGood     = "VG"
+	GradeGood         = "G"
+)
+
+// Grades lists the valid condition grades, best first
+var Grades = []string{GradeMint, GradeNearMint, GradeVeryGoodPlus, GradeVeryGood, GradeGood}
+
+// ValidGrade reports whether grade is one of Grades
+func ValidGrade(grade string) bool {
+	for _, g := range Grades {
+		if g == grade {
+			return true
+		}
+	}
+	return false
+}
+
+// sideGradeSeparator separates the grades of each side, as in DiscModes
+const sideGradeSeparator = "/"
+
+// SplitSideGrades splits a copy's SideGrades into a grade per side, e.g.
+// "NM/VG+" into side 1 NM and side 2 VG+. Sides not graded are empty.
+func SplitSideGrades(sideGrades string) []string {
+	if sideGrades == "" {
+		return nil
+	}
+	grades := strings.Split(sideGrades, sideGradeSeparator)
+	for i, grade := range grades {
+		grades[i] = strings.TrimSpace(grade)
+	}
+	return grades
+}
+
+// JoinSideGrades is the reverse of SplitSideGrades
+func JoinSideGrades(grades []string) string {
+	return strings.Join(grades, sideGradeSeparator)
+}
+
+// Laser rot statuses, from none to severe
+const (
+	RotUnknown   = "unknown" // not checked yet
+	RotNone      = "none"
+	RotSuspected = "suspected"
+	RotMinor     = "minor"
+	RotSevere    = "severe"
+)
+
+// RotStatuses lists the valid laser rot statuses
+var RotStatuses = []string{RotUnknown, RotNone, RotSuspected, RotMinor, RotSevere}
+
+// ValidRotStatus reports whether status is one of RotStatuses
+func ValidRotStatus(status string) bool {
+	for _, s := range RotStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Copy is one physical copy of a LaserDisc release. The LaserDisc holds the
+// release's metadata, keyed by UPC, and a collector may own several copies
+// of it, e.g. one sealed and one to play.
+type Copy struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	LaserDiscID   uint      `json:"laserdisc_id" gorm:"index;not null"`
+	Sealed        bool      `json:"sealed" gorm:"default:false"`
+	SleeveGrade   string    `json:"sleeve_grade"`                               // one of Grades, empty when not graded
+	SideGrades    string    `json:"side_grades"`                                // grade per side, e.g. NM/VG+
+	RotStatus     string    `json:"rot_status" gorm:"not null;default:unknown"` // one of RotStatuses
+	PurchaseDate  string    `json:"purchase_date"`                              // YYYY-MM-DD
+	PurchasePrice float64   `json:"purchase_price"`
+	Currency      string    `json:"currency"` // ISO 4217, e.g. USD
+	Seller        string    `json:"seller"`
+	Notes         string    `json:"notes"`
+	AddedDate     time.Time `json:"added_date" gorm:"autoCreateTime"`
+	UpdatedDate   time.Time `json:"updated_date" gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for the Copy model
+func (Copy) TableName() string {
+	return "copies"
+}
+
+// CopyRequest represents the request payload for adding or updating a copy.
+// Fields left out keep their current value, or their default for a new copy.
+type CopyRequest struct {
+	Sealed        *bool    `json:"sealed"`
+	SleeveGrade   *string  `json:"sleeve_grade"`
+	SideGrades    *string  `json:"side_grades"`
+	RotStatus     *string  `json:"rot_status"`
+	PurchaseDate  *string  `json:"purchase_date"`
+	PurchasePrice *float64 `json:"purchase_price"`
+	Currency      *string  `json:"currency"`
+	Seller        *string  `json:"seller"`
+	Notes         *string  `json:"notes"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCopy_TableName(t *testing.T) {
+	copy := Copy{}
+	assert.Equal(t, "copies", copy.TableName())
+}
+
+func TestValidGrade(t *testing.T) {
+	for _, grade := range Grades {
+		assert.True(t, ValidGrade(grade), grade)
+	}
+	assert.False(t, ValidGrade(""))
+	assert.False(t, ValidGrade("vg+"))
+	assert.False(t, ValidGrade("EX"))
+}
+
+func TestValidRotStatus(t *testing.T) {
+	for _, status := range RotStatuses {
+		assert.True(t, ValidRotStatus(status), status)
+	}
+	assert.False(t, ValidRotStatus(""))
+	assert.False(t, ValidRotStatus("bad"))
+}
+
+func TestSplitSideGrades(t *testing.T) {
+	assert.Nil(t, SplitSideGrades(""))
+	assert.Equal(t, []string{"NM"}, SplitSideGrades("NM"))
+	assert.Equal(t, []string{"NM", "VG+", "", "G"}, SplitSideGrades("NM / VG+//G"))
+	assert.Equal(t, "NM/VG+//G", JoinSideGrades(SplitSideGrades("NM / VG+//G")))
+}
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Custom field types
+const (
+	FieldTypeText   = "text"
+	FieldTypeNumber = "number"
+	FieldTypeDate   = "date" // YYYY-MM-DD
+	FieldTypeEnum   = "enum" // one of the field's options
+)
+
+// FieldTypes lists the valid custom field types
+var FieldTypes = []string{FieldTypeText, FieldTypeNumber, FieldTypeDate, FieldTypeEnum}
+
+// ValidFieldType reports whether fieldType is one of FieldTypes
+func ValidFieldType(fieldType string) bool {
+	for _, t := range FieldTypes {
+		if t == fieldType {
+			return true
+		}
+	}
+	return false
+}
+
+// CustomField is a user-defined field any LaserDisc can have a value for,
+// e.g. "Region" or "Shelf"
+type CustomField struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null"`                     // unique, ignoring case
+	Type      string    `json:"type" gorm:"not null"`                     // one of FieldTypes
+	Options   []string  `json:"options,omitempty" gorm:"serializer:json"` // the values of an enum, in order
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName returns the table name for the CustomField model
+func (CustomField) TableName() string {
+	return "custom_fields"
+}
+
+// CustomFieldValue is a LaserDisc's value for a custom field, stored as text:
+// numbers in decimal, dates as YYYY-MM-DD and enums as one of the options
+type CustomFieldValue struct {
+	LaserDiscID uint   `gorm:"primaryKey;autoIncrement:false"`
+	FieldID     uint   `gorm:"primaryKey;autoIncrement:false;index"`
+	Value       string `gorm:"not null"`
+}
+
+// TableName returns the table name for the CustomFieldValue model
+func (CustomFieldValue) TableName() string {
+	return "custom_field_values"
+}
+
+// CustomFieldRequest represents the request payload for creating a custom
+// field, or renaming one or changing its options. A field's type can't be
+// changed once it's created.
+type CustomFieldRequest struct {
+	Name    *string   `json:"name"`
+	Type    *string   `json:"type"`
+	Options *[]string `json:"options"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidFieldType(t *testing.T) {
+	for _, fieldType := range FieldTypes {
+		assert.True(t, ValidFieldType(fieldType), fieldType)
+	}
+	assert.False(t, ValidFieldType(""))
+	assert.False(t, ValidFieldType("Number"))
+	assert.False(t, ValidFieldType("boolean"))
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+)
+
+// Image kinds, describing what a photo shows
+const (
+	ImageKindFront  = "front"
+	ImageKindBack   = "back"
+	ImageKindLabel  = "label"
+	ImageKindDamage = "damage"
+	ImageKindOther  = "other"
+)
+
+// ImageKinds lists the valid image kinds
+var ImageKinds = []string{ImageKindFront, ImageKindBack, ImageKindLabel, ImageKindDamage, ImageKindOther}
+
+// LaserDiscImage is a photo the user uploaded of their copy of a LaserDisc
+type LaserDiscImage struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	LaserDiscID uint      `json:"laserdisc_id" gorm:"index;not null"`
+	Kind        string    `json:"kind" gorm:"not null;default:other"` // front, back, label, damage or other
+	Caption     string    `json:"caption"`
+	File        string    `json:"file" gorm:"not null"` // file in the image store, named by content hash
+	ContentType string    `json:"content_type"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Size        int64     `json:"size"`                                           // bytes
+	Position    int       `json:"position"`                                       // order shown, lowest first
+	Primary     bool      `json:"primary" gorm:"column:is_primary;default:false"` // used as the LaserDisc's cover
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName returns the table name for the LaserDiscImage model
+func (LaserDiscImage) TableName() string {
+	return "laserdisc_images"
+}
+
+// ValidImageKind reports whether kind is one of ImageKinds
+func ValidImageKind(kind string) bool {
+	for _, k := range ImageKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
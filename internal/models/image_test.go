@@ -0,0 +1,21 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLaserDiscImage_TableName(t *testing.T) {
+	image := LaserDiscImage{}
+	assert.Equal(t, "laserdisc_images", image.TableName())
+}
+
+func TestValidImageKind(t *testing.T) {
+	for _, kind := range ImageKinds {
+		assert.True(t, ValidImageKind(kind), kind)
+	}
+	assert.False(t, ValidImageKind(""))
+	assert.False(t, ValidImageKind("Front"))
+	assert.False(t, ValidImageKind("poster"))
+}
@@ -0,0 +1,106 @@
+package models
+
+import "time"
+
+// Defects found when playing a side
+const (
+	DefectNone      = "none" // played through cleanly
+	DefectRot       = "rot"
+	DefectCrosstalk = "crosstalk"
+	DefectSkip      = "skip"
+	DefectHum       = "hum"
+	DefectBleed     = "bleed"
+)
+
+// Defects lists the valid defect types
+var Defects = []string{DefectNone, DefectRot, DefectCrosstalk, DefectSkip, DefectHum, DefectBleed}
+
+// ValidDefect reports whether defect is one of Defects
+func ValidDefect(defect string) bool {
+	for _, d := range Defects {
+		if d == defect {
+			return true
+		}
+	}
+	return false
+}
+
+// Defect severities. A clean inspection has no severity.
+const (
+	MinSeverity = 1 // barely noticeable
+	MaxSeverity = 5 // unwatchable
+)
+
+// Inspection records playing one side of a copy, and a defect found on it.
+// Several defects found in one session are several inspections on the same
+// date.
+type Inspection struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	CopyID        uint      `json:"copy_id" gorm:"index;not null"`
+	LaserDiscID   uint      `json:"laserdisc_id" gorm:"index;not null"`
+	Side          int       `json:"side" gorm:"not null"`
+	InspectedDate string    `json:"inspected_date" gorm:"not null"` // YYYY-MM-DD
+	Player        string    `json:"player"`
+	Defect        string    `json:"defect" gorm:"not null"` // one of Defects
+	Timecode      string    `json:"timecode"`               // H:MM:SS into the side
+	Chapter       int       `json:"chapter"`
+	Severity      int       `json:"severity"` // MinSeverity to MaxSeverity, 0 for DefectNone
+	Notes         string    `json:"notes"`
+	AddedDate     time.Time `json:"added_date" gorm:"autoCreateTime"`
+}
+
+// TableName returns the table name for the Inspection model
+func (Inspection) TableName() string {
+	return "inspections"
+}
+
+// CreateInspectionRequest represents the request payload for logging an
+// inspection. The date defaults to today.
+type CreateInspectionRequest struct {
+	Side          int    `json:"side" binding:"required"`
+	InspectedDate string `json:"inspected_date"`
+	Player        string `json:"player"`
+	Defect        string `json:"defect" binding:"required"`
+	Timecode      string `json:"timecode"`
+	Chapter       int    `json:"chapter"`
+	Severity      int    `json:"severity"`
+	Notes         string `json:"notes"`
+}
+
+// SideCondition is the condition of one side of a copy at its latest
+// inspection
+type SideCondition struct {
+	LaserDiscID   uint     `json:"laserdisc_id"`
+	Title         string   `json:"title"`
+	CopyID        uint     `json:"copy_id"`
+	Side          int      `json:"side"`
+	InspectedDate string   `json:"inspected_date"`
+	Severity      int      `json:"severity"` // the worst defect found then
+	Defects       []string `json:"defects"`
+}
+
+// Rot trends over repeated inspections of a side
+const (
+	RotTrendWorsening = "worsening"
+	RotTrendStable    = "stable"
+	RotTrendImproving = "improving" // usually a different player or a cleaner disc
+	RotTrendUnknown   = "unknown"   // inspected only once
+)
+
+// RotReading is how bad the rot on a side was at one inspection, 0 when
+// none was found
+type RotReading struct {
+	InspectedDate string `json:"inspected_date"`
+	Severity      int    `json:"severity"`
+}
+
+// RotProgression follows the rot on one side of a copy across its
+// inspections, oldest first
+type RotProgression struct {
+	LaserDiscID uint         `json:"laserdisc_id"`
+	Title       string       `json:"title"`
+	CopyID      uint         `json:"copy_id"`
+	Side        int          `json:"side"`
+	Trend       string       `json:"trend"`
+	Readings    []RotReading `json:"readings"`
+}
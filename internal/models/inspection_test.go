@@ -0,0 +1,20 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInspection_TableName(t *testing.T) {
+	inspection := Inspection{}
+	assert.Equal(t, "inspections", inspection.TableName())
+}
+
+func TestValidDefect(t *testing.T) {
+	for _, defect := range Defects {
+		assert.True(t, ValidDefect(defect), defect)
+	}
+	assert.False(t, ValidDefect(""))
+	assert.False(t, ValidDefect("Rot"))
+}
@@ -7,20 +7,52 @@ import (
 // LaserDisc represents a LaserDisc in the collection
 type LaserDisc struct {
 	ID            uint      `json:"id" gorm:"primaryKey"`
-	UPC           string    `json:"upc" gorm:"uniqueIndex;not null" binding:"required"`
+	UPC           string    `json:"upc" gorm:"uniqueIndex:idx_laserdiscs_upc,where:upc != '';not null"` // GTIN-14, empty when the disc has no readable barcode
 	Title         string    `json:"title" gorm:"not null" binding:"required"`
 	Year          int       `json:"year"`
 	Director      string    `json:"director"`
 	Genre         string    `json:"genre"`
-	Format        string    `json:"format"`        // CLV, CAV, etc.
-	Sides         int       `json:"sides"`         // 1 or 2
-	Runtime       int       `json:"runtime"`       // minutes
+	Format        string    `json:"format"`  // CLV, CAV, etc.
+	Sides         int       `json:"sides"`   // 1 or 2
+	Runtime       int       `json:"runtime"` // minutes
 	CoverImageURL string    `json:"cover_image_url"`
+	CoverFile     string    `json:"cover_file"`  // mirrored cover in the cover store, named by content hash
+	CoverThumb    string    `json:"cover_thumb"` // mirrored LDDB thumbnail
+	CoverSource   string    `json:"-"`           // CoverImageURL the mirrored files were downloaded from
 	LDDBUrl       string    `json:"lddb_url"`
+	Reference     string    `json:"reference"` // catalog number, e.g. SF098-1117
+	Label         string    `json:"label"`
+	ReleaseDate   string    `json:"release_date"` // as listed by LDDB, may be partial
+	Country       string    `json:"country"`
+	VideoStandard string    `json:"video_standard"` // NTSC, PAL
+	PictureFormat string    `json:"picture_format"` // LBX, P&S
+	AspectRatio   string    `json:"aspect_ratio"`   // e.g. 2.35:1
+	Sound         string    `json:"sound"`          // e.g. AC3/Digital/Analog
+	Chapters      int       `json:"chapters"`
+	Price         string    `json:"price"`
+	DiscModes     string    `json:"disc_modes"` // mode per side, e.g. CAV/CLV
+	Cast          string    `json:"cast"`
+	Producer      string    `json:"producer"`
 	Watched       bool      `json:"watched" gorm:"default:false"`
 	Notes         string    `json:"notes"`
 	AddedDate     time.Time `json:"added_date" gorm:"autoCreateTime"`
 	UpdatedDate   time.Time `json:"updated_date" gorm:"autoUpdateTime"`
+
+	// Where each metadata field's value came from, keyed by JSON field name
+	Provenance map[string]FieldProvenance `json:"provenance,omitempty" gorm:"-"`
+
+	// How the LaserDisc matched a search, when it was found by one
+	Match *SearchMatch `json:"match,omitempty" gorm:"-"`
+
+	// The physical copies owned of this release
+	Copies []Copy `json:"copies" gorm:"-"`
+
+	// User-defined tags, by name
+	Tags []Tag `json:"tags" gorm:"-"`
+
+	// Values of the custom fields set, keyed by field name. Numbers are
+	// float64 and the others strings.
+	CustomFields map[string]interface{} `json:"custom_fields" gorm:"-"`
 }
 
 // TableName returns the table name for the LaserDisc model
@@ -30,7 +62,7 @@ func (LaserDisc) TableName() string {
 
 // CreateLaserDiscRequest represents the request payload for creating a LaserDisc
 type CreateLaserDiscRequest struct {
-	UPC           string `json:"upc" binding:"required"`
+	UPC           string `json:"upc"` // optional, validated when given
 	Title         string `json:"title" binding:"required"`
 	Year          int    `json:"year"`
 	Director      string `json:"director"`
@@ -40,7 +72,33 @@ type CreateLaserDiscRequest struct {
 	Runtime       int    `json:"runtime"`
 	CoverImageURL string `json:"cover_image_url"`
 	LDDBUrl       string `json:"lddb_url"`
+	Reference     string `json:"reference"`
+	Label         string `json:"label"`
+	ReleaseDate   string `json:"release_date"`
+	Country       string `json:"country"`
+	VideoStandard string `json:"video_standard"`
+	PictureFormat string `json:"picture_format"`
+	AspectRatio   string `json:"aspect_ratio"`
+	Sound         string `json:"sound"`
+	Chapters      int    `json:"chapters"`
+	Price         string `json:"price"`
+	DiscModes     string `json:"disc_modes"`
+	Cast          string `json:"cast"`
+	Producer      string `json:"producer"`
 	Notes         string `json:"notes"`
+
+	// Metadata provider each field was looked up from, as returned by the
+	// lookup. Other fields filled in are recorded as entered by hand.
+	Sources map[string]string `json:"sources"`
+
+	// Condition and purchase details of the first copy
+	Copy *CopyRequest `json:"copy"`
+
+	// Tag names, creating any tags that don't exist yet
+	Tags []string `json:"tags"`
+
+	// Custom field values, keyed by field name
+	CustomFields map[string]interface{} `json:"custom_fields"`
 }
 
 // UpdateLaserDiscRequest represents the request payload for updating a LaserDisc
@@ -54,8 +112,27 @@ type UpdateLaserDiscRequest struct {
 	Runtime       *int    `json:"runtime"`
 	CoverImageURL *string `json:"cover_image_url"`
 	LDDBUrl       *string `json:"lddb_url"`
+	Reference     *string `json:"reference"`
+	Label         *string `json:"label"`
+	ReleaseDate   *string `json:"release_date"`
+	Country       *string `json:"country"`
+	VideoStandard *string `json:"video_standard"`
+	PictureFormat *string `json:"picture_format"`
+	AspectRatio   *string `json:"aspect_ratio"`
+	Sound         *string `json:"sound"`
+	Chapters      *int    `json:"chapters"`
+	Price         *string `json:"price"`
+	DiscModes     *string `json:"disc_modes"`
+	Cast          *string `json:"cast"`
+	Producer      *string `json:"producer"`
 	Watched       *bool   `json:"watched"`
 	Notes         *string `json:"notes"`
+
+	// Replaces all the tags, creating any that don't exist yet
+	Tags *[]string `json:"tags"`
+
+	// Sets the custom fields given, keyed by field name. Null clears one.
+	CustomFields map[string]interface{} `json:"custom_fields"`
 }
 
 // LookupResult represents the result of a UPC lookup from lddb.com
@@ -70,6 +147,60 @@ type LookupResult struct {
 	Runtime       int    `json:"runtime"`
 	CoverImageURL string `json:"cover_image_url"`
 	LDDBUrl       string `json:"lddb_url"`
+	Reference     string `json:"reference"`
+	Label         string `json:"label"`
+	ReleaseDate   string `json:"release_date"`
+	Country       string `json:"country"`
+	VideoStandard string `json:"video_standard"`
+	PictureFormat string `json:"picture_format"`
+	AspectRatio   string `json:"aspect_ratio"`
+	Sound         string `json:"sound"`
+	Chapters      int    `json:"chapters"`
+	Price         string `json:"price"`
+	DiscModes     string `json:"disc_modes"`
+	Cast          string `json:"cast"`
+	Producer      string `json:"producer"`
 	Found         bool   `json:"found"`
 	Error         string `json:"error,omitempty"`
-}
\ No newline at end of file
+	LDDBID        int    `json:"lddb_id,omitempty"`
+	DetailHTML    string `json:"-"` // raw LDDB detail page, kept for the lookup cache
+
+	// Set instead of the fields above when a search matches several LaserDiscs
+	Candidates []LookupCandidate `json:"candidates,omitempty"`
+
+	// Metadata provider that supplied each field, keyed by JSON field name
+	Sources map[string]string `json:"sources,omitempty"`
+
+	Diagnostics *LookupDiagnostics `json:"diagnostics,omitempty"`
+}
+
+// LookupDiagnostics reports the requests a lookup made against lddb.com
+type LookupDiagnostics struct {
+	Requests       int   `json:"requests"`         // HTTP attempts, including retries
+	Retries        int   `json:"retries"`          // attempts repeated after a 5xx or timeout
+	Throttled      int   `json:"throttled"`        // requests delayed by the rate limit
+	ThrottleWaitMS int64 `json:"throttle_wait_ms"` // total time spent waiting on the rate limit
+}
+
+// LookupCandidate is one of several LaserDiscs matching a lookup, for the user to choose from
+type LookupCandidate struct {
+	LDDBID       int    `json:"lddb_id"`
+	Title        string `json:"title"`
+	Year         int    `json:"year"`
+	Reference    string `json:"reference"`
+	Country      string `json:"country"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	LDDBUrl      string `json:"lddb_url"`
+}
+
+// TitleSearchResult is one page of LDDB search results for a title or keyword
+type TitleSearchResult struct {
+	Query       string             `json:"query"`
+	Year        int                `json:"year,omitempty"`
+	Country     string             `json:"country,omitempty"`
+	Page        int                `json:"page"`
+	HasMore     bool               `json:"has_more"`
+	Candidates  []LookupCandidate  `json:"candidates"`
+	Error       string             `json:"error,omitempty"`
+	Diagnostics *LookupDiagnostics `json:"diagnostics,omitempty"`
+}
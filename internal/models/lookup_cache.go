@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+)
+
+// Lookup cache kinds
+const (
+	LookupKindUPC       = "upc"
+	LookupKindReference = "reference"
+	LookupKindLDDBID    = "lddb_id"
+)
+
+// LookupCacheEntry stores a previous LDDB lookup so repeat scans don't hit lddb.com
+type LookupCacheEntry struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	Kind       string    `json:"kind" gorm:"uniqueIndex:idx_lookup_cache_kind_key;not null"` // upc, reference or lddb_id
+	Key        string    `json:"key" gorm:"uniqueIndex:idx_lookup_cache_kind_key;not null"`  // normalized UPC or reference
+	Result     string    `json:"-" gorm:"not null"`                                          // JSON encoded LookupResult
+	DetailHTML string    `json:"-"`                                                          // raw detail page, empty for negative results
+	Found      bool      `json:"found"`
+	FetchedAt  time.Time `json:"fetched_at" gorm:"not null"`
+}
+
+// TableName returns the table name for the LookupCacheEntry model
+func (LookupCacheEntry) TableName() string {
+	return "lookup_cache"
+}
+
+// IsFresh reports whether the entry is still within its TTL at the given time.
+// Negative results use their own, usually shorter, TTL.
+func (e *LookupCacheEntry) IsFresh(ttl, negativeTTL time.Duration, now time.Time) bool {
+	if !e.Found {
+		ttl = negativeTTL
+	}
+	return now.Sub(e.FetchedAt) < ttl
+}
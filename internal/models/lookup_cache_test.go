@@ -0,0 +1,37 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookupCacheEntry_TableName(t *testing.T) {
+	entry := LookupCacheEntry{}
+	assert.Equal(t, "lookup_cache", entry.TableName())
+}
+
+func TestLookupCacheEntry_IsFresh(t *testing.T) {
+	now := time.Date(2025, 9, 1, 12, 0, 0, 0, time.UTC)
+	ttl := 24 * time.Hour
+	negativeTTL := time.Hour
+
+	tests := []struct {
+		name     string
+		found    bool
+		age      time.Duration
+		expected bool
+	}{
+		{"found, just fetched", true, time.Minute, true},
+		{"found, within ttl", true, 23 * time.Hour, true},
+		{"found, expired", true, 25 * time.Hour, false},
+		{"not found, within negative ttl", false, 30 * time.Minute, true},
+		{"not found, past negative ttl", false, 2 * time.Hour, false},
+	}
+
+	for _, tt := range tests {
+		entry := LookupCacheEntry{Found: tt.found, FetchedAt: now.Add(-tt.age)}
+		assert.Equal(t, tt.expected, entry.IsFresh(ttl, negativeTTL, now), tt.name)
+	}
+}
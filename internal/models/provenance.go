@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+// FieldSourceManual marks a field the user edited by hand. Other sources are
+// the name of the metadata provider the value came from, e.g. "lddb".
+const FieldSourceManual = "manual"
+
+// ScrapedFields lists the LaserDisc fields, by JSON name, that metadata
+// lookups fill in. The JSON names match the column names.
+var ScrapedFields = []string{
+	"title", "year", "director", "genre", "format", "sides", "runtime",
+	"cover_image_url", "reference", "label", "release_date", "country",
+	"video_standard", "picture_format", "aspect_ratio", "sound", "chapters",
+	"price", "disc_modes", "cast", "producer",
+}
+
+// IsScrapedField reports whether field is one of ScrapedFields
+func IsScrapedField(field string) bool {
+	for _, f := range ScrapedFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldProvenance records where the current value of one LaserDisc field came from
+type FieldProvenance struct {
+	ID          uint      `json:"-" gorm:"primaryKey"`
+	LaserDiscID uint      `json:"-" gorm:"uniqueIndex:idx_field_provenance_laserdisc_field;not null"`
+	Field       string    `json:"field" gorm:"uniqueIndex:idx_field_provenance_laserdisc_field;not null"` // JSON name, e.g. genre
+	Source      string    `json:"source" gorm:"not null"`                                                 // manual, or a metadata provider
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for the FieldProvenance model
+func (FieldProvenance) TableName() string {
+	return "field_provenance"
+}
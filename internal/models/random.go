@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// RandomPick records a LaserDisc suggested by the movie night picker
+type RandomPick struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	LaserDiscID uint      `json:"laserdisc_id" gorm:"not null"`
+	Seed        int64     `json:"seed" gorm:"not null"`
+	PickedAt    time.Time `json:"picked_at" gorm:"index;not null"`
+}
+
+// TableName returns the table name for the RandomPick model
+func (RandomPick) TableName() string {
+	return "random_picks"
+}
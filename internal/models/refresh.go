@@ -0,0 +1,79 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Refresh job statuses
+const (
+	RefreshJobRunning   = "running"
+	RefreshJobCompleted = "completed"
+	RefreshJobCancelled = "cancelled"
+	RefreshJobFailed    = "failed"
+)
+
+// Metadata change statuses
+const (
+	ChangeApplied    = "applied"    // written automatically, the field was never edited by hand
+	ChangePending    = "pending"    // suggested, waiting for the user to accept or reject it
+	ChangeAccepted   = "accepted"   // suggested and accepted
+	ChangeRejected   = "rejected"   // suggested and rejected
+	ChangeSuperseded = "superseded" // suggested, then replaced by a later refresh's suggestion
+)
+
+// RefreshFilter picks the LaserDiscs a refresh job re-checks. Only discs
+// with an LDDB URL can be refreshed. An empty filter refreshes all of them.
+// Fields the user edited are only suggested unless OverwriteManual is set.
+type RefreshFilter struct {
+	IDs             []uint   `json:"ids,omitempty"`              // only these LaserDiscs
+	Missing         []string `json:"missing,omitempty"`          // only discs with any of these fields empty, e.g. genre
+	OverwriteManual bool     `json:"overwrite_manual,omitempty"` // apply new values to edited fields too
+}
+
+// RefreshError is a LaserDisc a refresh job couldn't re-check
+type RefreshError struct {
+	LaserDiscID uint   `json:"laserdisc_id"`
+	Error       string `json:"error"`
+}
+
+// RefreshJob re-scrapes LaserDiscs from LDDB in the background
+type RefreshJob struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	Status     string         `json:"status" gorm:"not null;index"`
+	Filter     RefreshFilter  `json:"filter" gorm:"serializer:json"`
+	Total      int            `json:"total"`     // LaserDiscs matching the filter
+	Processed  int            `json:"processed"` // LaserDiscs re-checked so far
+	Updated    int            `json:"updated"`   // LaserDiscs with changes applied automatically
+	Suggested  int            `json:"suggested"` // changes queued for review
+	Failed     int            `json:"failed"`
+	Errors     []RefreshError `json:"errors" gorm:"serializer:json"`
+	Error      string         `json:"error,omitempty"` // why the whole job failed
+	CreatedAt  time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	FinishedAt *time.Time     `json:"finished_at"`
+}
+
+// TableName returns the table name for the RefreshJob model
+func (RefreshJob) TableName() string {
+	return "refresh_jobs"
+}
+
+// MetadataChange is one field a refresh found to differ from LDDB, either
+// applied automatically or suggested for review
+type MetadataChange struct {
+	ID          uint            `json:"id" gorm:"primaryKey"`
+	JobID       uint            `json:"job_id" gorm:"index;not null"`
+	LaserDiscID uint            `json:"laserdisc_id" gorm:"index;not null"`
+	Field       string          `json:"field" gorm:"not null"`     // JSON name, e.g. genre
+	OldValue    json.RawMessage `json:"old_value" gorm:"not null"` // JSON encoded
+	NewValue    json.RawMessage `json:"new_value" gorm:"not null"` // JSON encoded
+	Source      string          `json:"source" gorm:"not null"`    // metadata provider, e.g. lddb
+	Status      string          `json:"status" gorm:"not null;index"`
+	CreatedAt   time.Time       `json:"created_at" gorm:"autoCreateTime"`
+	ResolvedAt  *time.Time      `json:"resolved_at"`
+}
+
+// TableName returns the table name for the MetadataChange model
+func (MetadataChange) TableName() string {
+	return "metadata_changes"
+}
@@ -0,0 +1,18 @@
+package models
+
+// SearchMatch describes how a LaserDisc matched a search. Title and Snippet
+// are HTML, with the matched words of a full-text search wrapped in <mark>
+// elements. Fuzzy matches aren't highlighted and have no snippet.
+type SearchMatch struct {
+	Score   float64 `json:"score"`   // higher is a better match
+	Title   string  `json:"title"`   // the title with its matches highlighted
+	Snippet string  `json:"snippet"` // a few words around the best match
+}
+
+// TitleMatch is a LaserDisc with a title like another one
+type TitleMatch struct {
+	ID    uint    `json:"id"`
+	Title string  `json:"title"`
+	Year  int     `json:"year"`
+	Score float64 `json:"score"` // 1 for titles that are the same once normalized
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Tag is a user-defined label for LaserDiscs, e.g. "Criterion" or "needs
+// cleaning"
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null"` // unique, ignoring case
+	Color     string    `json:"color"`                // #rrggbb
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+
+	// How many LaserDiscs have the tag, when listing tags
+	Count *int64 `json:"count,omitempty" gorm:"-"`
+}
+
+// TableName returns the table name for the Tag model
+func (Tag) TableName() string {
+	return "tags"
+}
+
+// LaserDiscTag tags a LaserDisc
+type LaserDiscTag struct {
+	LaserDiscID uint `gorm:"primaryKey;autoIncrement:false"`
+	TagID       uint `gorm:"primaryKey;autoIncrement:false;index"`
+}
+
+// TableName returns the table name for the LaserDiscTag model
+func (LaserDiscTag) TableName() string {
+	return "laserdisc_tags"
+}
+
+// TagRequest represents the request payload for creating a tag, or renaming
+// or recolouring one. A new tag without a colour is given one.
+type TagRequest struct {
+	Name  *string `json:"name"`
+	Color *string `json:"color"`
+}
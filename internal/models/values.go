@@ -0,0 +1,15 @@
+package models
+
+// ValueCount is a value in use for a field, and how many LaserDiscs have it
+type ValueCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// MergeValuesRequest represents the request payload for replacing one value
+// of a field with another on every LaserDisc, e.g. SciFi with Sci-Fi
+type MergeValuesRequest struct {
+	Field string `json:"field" binding:"required"`
+	From  string `json:"from" binding:"required"`
+	Into  string `json:"into" binding:"required"`
+}
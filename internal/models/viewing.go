@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Ratings are out of 10
+const (
+	MinRating = 1
+	MaxRating = 10
+)
+
+// Viewing is one time a LaserDisc was watched. A LaserDisc is Watched when
+// it has any viewings.
+type Viewing struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	LaserDiscID uint       `json:"laserdisc_id" gorm:"index;not null"`
+	WatchedAt   *time.Time `json:"watched_at"`                     // nil when it isn't known
+	Viewers     []string   `json:"viewers" gorm:"serializer:json"` // household members who watched
+	Rating      int        `json:"rating"`                         // MinRating to MaxRating, 0 when not rated
+	Review      string     `json:"review"`
+	CopyID      uint       `json:"copy_id,omitempty"` // the copy played, 0 when not recorded
+	Player      string     `json:"player"`
+	AddedDate   time.Time  `json:"added_date" gorm:"autoCreateTime"`
+	UpdatedDate time.Time  `json:"updated_date" gorm:"autoUpdateTime"`
+}
+
+// TableName returns the table name for the Viewing model
+func (Viewing) TableName() string {
+	return "viewings"
+}
+
+// ViewingRequest represents the request payload for adding or updating a
+// viewing. Fields left out keep their current value. A new viewing is dated
+// now unless watched_at is given, and an empty watched_at leaves it undated.
+type ViewingRequest struct {
+	WatchedAt *string   `json:"watched_at"` // YYYY-MM-DD or RFC 3339
+	Viewers   *[]string `json:"viewers"`
+	Rating    *int      `json:"rating"`
+	Review    *string   `json:"review"`
+	CopyID    *uint     `json:"copy_id"`
+	Player    *string   `json:"player"`
+}
+
+// ViewingTimeline is a LaserDisc's viewing history, undated viewings first
+// and then oldest first
+type ViewingTimeline struct {
+	LaserDiscID   uint       `json:"laserdisc_id"`
+	Title         string     `json:"title"`
+	Count         int        `json:"count"`
+	AverageRating float64    `json:"average_rating,omitempty"` // of the rated viewings
+	LastWatched   *time.Time `json:"last_watched,omitempty"`
+	Viewings      []Viewing  `json:"viewings"`
+}
@@ -0,0 +1,12 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestViewing_TableName(t *testing.T) {
+	viewing := Viewing{}
+	assert.Equal(t, "viewings", viewing.TableName())
+}
@@ -0,0 +1,121 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/paran01d/lddb/internal/barcode"
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// Dataset is an offline copy of LaserDisc records, e.g. an export of LDDB,
+// loaded from a JSON array of lookup results
+type Dataset struct {
+	records recordIndex
+}
+
+// LoadDataset reads an offline dataset from a JSON file
+func LoadDataset(path string) (*Dataset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []models.LookupResult
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse dataset %s: %w", path, err)
+	}
+
+	dataset := &Dataset{records: newRecordIndex()}
+	for _, record := range records {
+		dataset.records.add(record.UPC, record.Reference, record.LDDBID, record)
+	}
+	return dataset, nil
+}
+
+// Name identifies the dataset as a metadata provider
+func (d *Dataset) Name() string {
+	return "dataset"
+}
+
+// LookupByUPC finds the dataset record with the UPC
+func (d *Dataset) LookupByUPC(upc string) (*models.LookupResult, error) {
+	return d.records.byUPC[normalizeUPC(upc)].result(), nil
+}
+
+// LookupByReference finds the dataset record with the catalog reference
+func (d *Dataset) LookupByReference(reference string) (*models.LookupResult, error) {
+	return d.records.byReference[normalizeReference(reference)].result(), nil
+}
+
+// LookupByID finds the dataset record with the LDDB ID
+func (d *Dataset) LookupByID(id int) (*models.LookupResult, error) {
+	return d.records.byID[id].result(), nil
+}
+
+// recordIndex finds records by UPC, catalog reference or LDDB ID
+type recordIndex struct {
+	byUPC       map[string]*record
+	byReference map[string]*record
+	byID        map[int]*record
+}
+
+// record is a stored lookup result
+type record struct {
+	models.LookupResult
+}
+
+func newRecordIndex() recordIndex {
+	return recordIndex{
+		byUPC:       make(map[string]*record),
+		byReference: make(map[string]*record),
+		byID:        make(map[int]*record),
+	}
+}
+
+// add indexes a record under each key it has. The first record for a key wins.
+func (idx recordIndex) add(upc, reference string, id int, result models.LookupResult) {
+	r := &record{LookupResult: result}
+	if key := normalizeUPC(upc); key != "" && idx.byUPC[key] == nil {
+		idx.byUPC[key] = r
+	}
+	if key := normalizeReference(reference); key != "" && idx.byReference[key] == nil {
+		idx.byReference[key] = r
+	}
+	if id > 0 && idx.byID[id] == nil {
+		idx.byID[id] = r
+	}
+}
+
+// result returns a found copy of the record, or a not found result for a nil record
+func (r *record) result() *models.LookupResult {
+	if r == nil {
+		return &models.LookupResult{Found: false}
+	}
+	result := r.LookupResult
+	result.Found = true
+	result.Error = ""
+	result.Candidates = nil
+	result.Sources = nil
+	return &result
+}
+
+// nonDigitPattern matches everything that isn't part of a UPC
+var nonDigitPattern = regexp.MustCompile(`\D`)
+
+// normalizeUPC converts a UPC to its GTIN-14, or reduces it to its digits
+// if it isn't a valid barcode
+func normalizeUPC(upc string) string {
+	if gtin, err := barcode.Normalize(upc); err == nil {
+		return gtin
+	}
+	return nonDigitPattern.ReplaceAllString(upc, "")
+}
+
+// normalizeReference upper cases a catalog reference and collapses its whitespace
+func normalizeReference(reference string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(reference), " "))
+}
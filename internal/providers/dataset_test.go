@@ -0,0 +1,83 @@
+package providers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFile writes content to a file in a temporary directory and returns its path
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadDataset(t *testing.T) {
+	path := writeFile(t, "dataset.json", `[
+		{"upc": "0-13023-50896-5", "reference": "pilf-2650", "lddb_id": 7007, "title": "Blade Runner", "year": 1982},
+		{"upc": "013023508965", "title": "Duplicate UPC"},
+		{"reference": "DV-10-001", "title": "Jaws", "found": false, "error": "ignored"}
+	]`)
+
+	dataset, err := LoadDataset(path)
+	require.NoError(t, err)
+	assert.Equal(t, "dataset", dataset.Name())
+
+	// UPCs and references are matched after normalizing them
+	result, err := dataset.LookupByUPC("013023508965")
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+	assert.Equal(t, "Blade Runner", result.Title)
+	assert.Equal(t, 1982, result.Year)
+
+	result, err = dataset.LookupByReference(" PILF-2650 ")
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+	assert.Equal(t, "Blade Runner", result.Title)
+
+	result, err = dataset.LookupByID(7007)
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+	assert.Equal(t, "Blade Runner", result.Title)
+
+	// Records are always returned as found, whatever the file says
+	result, err = dataset.LookupByReference("dv-10-001")
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+	assert.Empty(t, result.Error)
+	assert.Equal(t, "Jaws", result.Title)
+
+	result, err = dataset.LookupByUPC("999999999999")
+	require.NoError(t, err)
+	assert.False(t, result.Found)
+
+	result, err = dataset.LookupByID(1)
+	require.NoError(t, err)
+	assert.False(t, result.Found)
+}
+
+func TestLoadDataset_Errors(t *testing.T) {
+	_, err := LoadDataset(filepath.Join(t.TempDir(), "missing.json"))
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = LoadDataset(writeFile(t, "dataset.json", `{"not": "an array"}`))
+	assert.Error(t, err)
+}
+
+func TestDataset_ResultsAreCopies(t *testing.T) {
+	dataset, err := LoadDataset(writeFile(t, "dataset.json", `[{"upc": "123", "title": "Alien"}]`))
+	require.NoError(t, err)
+
+	result, err := dataset.LookupByUPC("123")
+	require.NoError(t, err)
+	result.Title = "Changed"
+
+	result, err = dataset.LookupByUPC("123")
+	require.NoError(t, err)
+	assert.Equal(t, "Alien", result.Title)
+}
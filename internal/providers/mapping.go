@@ -0,0 +1,78 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// Mapping holds user-defined metadata keyed by UPC, catalog reference or
+// LDDB ID, e.g. corrections to LDDB or discs LDDB doesn't list:
+//
+//	{
+//	  "upc":       {"013023508965": {"title": "...", "genre": "..."}},
+//	  "reference": {"PILF-2650": {"title": "..."}},
+//	  "lddb_id":   {"31738": {"director": "..."}}
+//	}
+type Mapping struct {
+	records recordIndex
+}
+
+// mappingFile is the JSON layout of a mapping file
+type mappingFile struct {
+	UPC       map[string]models.LookupResult `json:"upc"`
+	Reference map[string]models.LookupResult `json:"reference"`
+	LDDBID    map[string]models.LookupResult `json:"lddb_id"`
+}
+
+// LoadMapping reads a user-defined mapping from a JSON file
+func LoadMapping(path string) (*Mapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file mappingFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse mapping %s: %w", path, err)
+	}
+
+	mapping := &Mapping{records: newRecordIndex()}
+	for upc, result := range file.UPC {
+		mapping.records.add(upc, "", 0, result)
+	}
+	for reference, result := range file.Reference {
+		mapping.records.add("", reference, 0, result)
+	}
+	for key, result := range file.LDDBID {
+		id, err := strconv.Atoi(key)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid LDDB ID %q in mapping %s", key, path)
+		}
+		mapping.records.add("", "", id, result)
+	}
+	return mapping, nil
+}
+
+// Name identifies the mapping as a metadata provider
+func (m *Mapping) Name() string {
+	return "mapping"
+}
+
+// LookupByUPC returns the metadata mapped to the UPC
+func (m *Mapping) LookupByUPC(upc string) (*models.LookupResult, error) {
+	return m.records.byUPC[normalizeUPC(upc)].result(), nil
+}
+
+// LookupByReference returns the metadata mapped to the catalog reference
+func (m *Mapping) LookupByReference(reference string) (*models.LookupResult, error) {
+	return m.records.byReference[normalizeReference(reference)].result(), nil
+}
+
+// LookupByID returns the metadata mapped to the LDDB ID
+func (m *Mapping) LookupByID(id int) (*models.LookupResult, error) {
+	return m.records.byID[id].result(), nil
+}
@@ -0,0 +1,63 @@
+package providers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMapping(t *testing.T) {
+	path := writeFile(t, "mappings.json", `{
+		"upc": {"013023508965": {"genre": "Sci-Fi"}},
+		"reference": {"pilf-2650": {"title": "Blade Runner (Japan)"}},
+		"lddb_id": {"31738": {"director": "Irvin Kershner"}}
+	}`)
+
+	mapping, err := LoadMapping(path)
+	require.NoError(t, err)
+	assert.Equal(t, "mapping", mapping.Name())
+
+	result, err := mapping.LookupByUPC("0-13023-50896-5")
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+	assert.Equal(t, "Sci-Fi", result.Genre)
+
+	result, err = mapping.LookupByReference("PILF-2650")
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+	assert.Equal(t, "Blade Runner (Japan)", result.Title)
+
+	result, err = mapping.LookupByID(31738)
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+	assert.Equal(t, "Irvin Kershner", result.Director)
+
+	// Keys only match lookups of their own kind
+	result, err = mapping.LookupByReference("013023508965")
+	require.NoError(t, err)
+	assert.False(t, result.Found)
+}
+
+func TestLoadMapping_Errors(t *testing.T) {
+	_, err := LoadMapping(writeFile(t, "mappings.json", `{"lddb_id": {"abc": {"title": "Bad"}}}`))
+	assert.Error(t, err)
+
+	_, err = LoadMapping(writeFile(t, "mappings.json", `[]`))
+	assert.Error(t, err)
+}
+
+func TestRegistry_MappingOverridesDataset(t *testing.T) {
+	mapping, err := LoadMapping(writeFile(t, "mappings.json", `{"upc": {"123": {"genre": "Horror"}}}`))
+	require.NoError(t, err)
+	dataset, err := LoadDataset(writeFile(t, "dataset.json", `[{"upc": "123", "title": "Alien", "genre": "Sci-Fi"}]`))
+	require.NoError(t, err)
+
+	result, err := NewRegistry(mapping, dataset).LookupByUPC("123")
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+	assert.Equal(t, "Alien", result.Title)
+	assert.Equal(t, "Horror", result.Genre)
+	assert.Equal(t, "123", result.UPC)
+	assert.Equal(t, map[string]string{"title": "dataset", "genre": "mapping", "upc": "dataset"}, result.Sources)
+}
@@ -0,0 +1,152 @@
+package providers
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// MetadataProvider looks up LaserDisc metadata from a single source
+type MetadataProvider interface {
+	Name() string
+	LookupByUPC(upc string) (*models.LookupResult, error)
+	LookupByReference(reference string) (*models.LookupResult, error)
+	LookupByID(id int) (*models.LookupResult, error) // LDDB ID
+}
+
+// Registry tries its providers in order and merges what they find
+type Registry struct {
+	providers []MetadataProvider
+}
+
+// NewRegistry creates a registry that consults providers in the given order
+func NewRegistry(providers ...MetadataProvider) *Registry {
+	return &Registry{providers: providers}
+}
+
+// Names returns the provider names in the order they are consulted
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for _, provider := range r.providers {
+		names = append(names, provider.Name())
+	}
+	return names
+}
+
+// LookupByUPC asks every provider for the UPC and merges the results
+func (r *Registry) LookupByUPC(upc string) (*models.LookupResult, error) {
+	return r.lookup(func(p MetadataProvider) (*models.LookupResult, error) {
+		return p.LookupByUPC(upc)
+	})
+}
+
+// LookupByReference asks every provider for the catalog reference and merges the results
+func (r *Registry) LookupByReference(reference string) (*models.LookupResult, error) {
+	return r.lookup(func(p MetadataProvider) (*models.LookupResult, error) {
+		return p.LookupByReference(reference)
+	})
+}
+
+// LookupByID asks every provider for the LDDB ID and merges the results
+func (r *Registry) LookupByID(id int) (*models.LookupResult, error) {
+	return r.lookup(func(p MetadataProvider) (*models.LookupResult, error) {
+		return p.LookupByID(id)
+	})
+}
+
+// lookup runs one lookup against each provider in order. A field is taken
+// from the first provider that has a value for it. Candidates are only
+// returned when no provider found the LaserDisc outright, and a provider
+// failing just means the next one gets its turn.
+func (r *Registry) lookup(fn func(MetadataProvider) (*models.LookupResult, error)) (*models.LookupResult, error) {
+	merged := &models.LookupResult{}
+	var candidates []models.LookupCandidate
+	var errs []string
+
+	for _, provider := range r.providers {
+		result, err := fn(provider)
+		if err != nil {
+			log.Printf("Warning: %s lookup failed: %v", provider.Name(), err)
+			errs = append(errs, fmt.Sprintf("%s: %v", provider.Name(), err))
+			continue
+		}
+		if result == nil {
+			continue
+		}
+		if merged.Diagnostics == nil {
+			merged.Diagnostics = result.Diagnostics
+		}
+		if result.Error != "" {
+			errs = append(errs, fmt.Sprintf("%s: %s", provider.Name(), result.Error))
+		}
+		if len(result.Candidates) > 0 && candidates == nil {
+			candidates = result.Candidates
+		}
+		if !result.Found {
+			continue
+		}
+
+		mergeFields(merged, result, provider.Name())
+		merged.Found = true
+		if merged.DetailHTML == "" {
+			merged.DetailHTML = result.DetailHTML
+		}
+	}
+
+	if !merged.Found {
+		merged.Candidates = candidates
+		// Only report errors when they may have hidden a match
+		if len(candidates) == 0 {
+			merged.Error = strings.Join(errs, "; ")
+		}
+	}
+	return merged, nil
+}
+
+// nonMergedFields are LookupResult fields describing the lookup itself
+// rather than the LaserDisc
+var nonMergedFields = map[string]bool{
+	"Found":       true,
+	"Error":       true,
+	"DetailHTML":  true,
+	"Candidates":  true,
+	"Diagnostics": true,
+	"Sources":     true,
+}
+
+// mergeFields copies every field set in src but still empty in dst, and
+// records the provider under the field's JSON name in dst.Sources
+func mergeFields(dst, src *models.LookupResult, provider string) {
+	dstValue := reflect.ValueOf(dst).Elem()
+	srcValue := reflect.ValueOf(src).Elem()
+	fields := dstValue.Type()
+
+	for i := 0; i < fields.NumField(); i++ {
+		field := fields.Field(i)
+		if nonMergedFields[field.Name] {
+			continue
+		}
+		from, to := srcValue.Field(i), dstValue.Field(i)
+		if from.IsZero() || !to.IsZero() {
+			continue
+		}
+		to.Set(from)
+
+		if dst.Sources == nil {
+			dst.Sources = make(map[string]string)
+		}
+		dst.Sources[jsonName(field)] = provider
+	}
+}
+
+// jsonName returns the name a struct field is encoded under
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,132 @@
+package providers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// fakeProvider answers every lookup with the same result
+type fakeProvider struct {
+	name   string
+	result *models.LookupResult
+	err    error
+	calls  int
+}
+
+func (f *fakeProvider) Name() string { return f.name }
+
+func (f *fakeProvider) LookupByUPC(upc string) (*models.LookupResult, error) {
+	return f.lookup()
+}
+
+func (f *fakeProvider) LookupByReference(reference string) (*models.LookupResult, error) {
+	return f.lookup()
+}
+
+func (f *fakeProvider) LookupByID(id int) (*models.LookupResult, error) {
+	return f.lookup()
+}
+
+func (f *fakeProvider) lookup() (*models.LookupResult, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	result := *f.result
+	return &result, nil
+}
+
+func TestRegistry_MergesFieldsInOrder(t *testing.T) {
+	mapping := &fakeProvider{name: "mapping", result: &models.LookupResult{Found: true, Genre: "Sci-Fi"}}
+	lddb := &fakeProvider{name: "lddb", result: &models.LookupResult{
+		Found:      true,
+		Title:      "Blade Runner",
+		Genre:      "Drama",
+		LDDBID:     42,
+		DetailHTML: "<html></html>",
+		Diagnostics: &models.LookupDiagnostics{
+			Requests: 2,
+		},
+	}}
+	dataset := &fakeProvider{name: "dataset", result: &models.LookupResult{Found: true, Title: "Blade Runner (Dataset)", Runtime: 117}}
+
+	registry := NewRegistry(mapping, lddb, dataset)
+	assert.Equal(t, []string{"mapping", "lddb", "dataset"}, registry.Names())
+
+	result, err := registry.LookupByUPC("013023508965")
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+	assert.Empty(t, result.Error)
+
+	// Earlier providers win each field, later ones fill the gaps
+	assert.Equal(t, "Sci-Fi", result.Genre)
+	assert.Equal(t, "Blade Runner", result.Title)
+	assert.Equal(t, 117, result.Runtime)
+	assert.Equal(t, 42, result.LDDBID)
+	assert.Equal(t, "<html></html>", result.DetailHTML)
+	require.NotNil(t, result.Diagnostics)
+	assert.Equal(t, 2, result.Diagnostics.Requests)
+
+	assert.Equal(t, map[string]string{
+		"genre":   "mapping",
+		"title":   "lddb",
+		"lddb_id": "lddb",
+		"runtime": "dataset",
+	}, result.Sources)
+}
+
+func TestRegistry_FallsBackPastFailures(t *testing.T) {
+	lddb := &fakeProvider{name: "lddb", err: errors.New("connection refused")}
+	missing := &fakeProvider{name: "mapping", result: &models.LookupResult{Found: false}}
+	dataset := &fakeProvider{name: "dataset", result: &models.LookupResult{Found: true, Title: "Akira"}}
+
+	result, err := NewRegistry(lddb, missing, dataset).LookupByReference("PILF-2650")
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+	assert.Empty(t, result.Error)
+	assert.Equal(t, "Akira", result.Title)
+	assert.Equal(t, map[string]string{"title": "dataset"}, result.Sources)
+	assert.Equal(t, 1, lddb.calls)
+}
+
+func TestRegistry_NotFound(t *testing.T) {
+	lddb := &fakeProvider{name: "lddb", result: &models.LookupResult{Found: false, Error: "Failed to fetch search results: timeout"}}
+	dataset := &fakeProvider{name: "dataset", err: errors.New("broken")}
+
+	result, err := NewRegistry(lddb, dataset).LookupByID(31738)
+	require.NoError(t, err)
+	assert.False(t, result.Found)
+	assert.Equal(t, "lddb: Failed to fetch search results: timeout; dataset: broken", result.Error)
+	assert.Nil(t, result.Sources)
+
+	// An empty registry finds nothing
+	result, err = NewRegistry().LookupByUPC("123")
+	require.NoError(t, err)
+	assert.False(t, result.Found)
+	assert.Empty(t, result.Error)
+}
+
+func TestRegistry_Candidates(t *testing.T) {
+	candidates := []models.LookupCandidate{{LDDBID: 42}, {LDDBID: 5150}}
+	lddb := &fakeProvider{name: "lddb", result: &models.LookupResult{Found: false, Candidates: candidates}}
+	dataset := &fakeProvider{name: "dataset", result: &models.LookupResult{Found: false}}
+
+	// Candidates are offered when no provider has an outright match
+	result, err := NewRegistry(lddb, dataset).LookupByUPC("085391163824")
+	require.NoError(t, err)
+	assert.False(t, result.Found)
+	assert.Equal(t, candidates, result.Candidates)
+
+	// A match from another provider takes precedence over candidates
+	dataset.result = &models.LookupResult{Found: true, Title: "Blade Runner"}
+	result, err = NewRegistry(lddb, dataset).LookupByUPC("085391163824")
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+	assert.Empty(t, result.Candidates)
+	assert.Equal(t, "Blade Runner", result.Title)
+}
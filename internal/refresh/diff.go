@@ -0,0 +1,76 @@
+// Package refresh re-checks saved LaserDiscs against LDDB in the background,
+// filling in what early scrapes missed without overwriting the user's edits.
+package refresh
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// emptyValues are the JSON encodings of values a lookup didn't find
+var emptyValues = [][]byte{[]byte(`""`), []byte(`0`), []byte(`null`)}
+
+// Diff lists the scraped fields where a fresh lookup differs from the stored
+// LaserDisc, as changes with JSON encoded old and new values. Fields the
+// lookup left empty are skipped, so a patchy scrape never blanks out data.
+func Diff(laserdisc *models.LaserDisc, result *models.LookupResult) ([]models.MetadataChange, error) {
+	current, err := jsonFields(laserdisc)
+	if err != nil {
+		return nil, err
+	}
+	fresh, err := jsonFields(result)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []models.MetadataChange
+	for _, field := range models.ScrapedFields {
+		value, ok := fresh[field]
+		if !ok || isEmpty(value) || bytes.Equal(value, current[field]) {
+			continue
+		}
+		if field == "cover_image_url" && bytes.Contains(value, []byte("loading.gif")) {
+			continue // LDDB's placeholder while the cover loads
+		}
+		changes = append(changes, models.MetadataChange{
+			LaserDiscID: laserdisc.ID,
+			Field:       field,
+			OldValue:    current[field],
+			NewValue:    value,
+		})
+	}
+	return changes, nil
+}
+
+// jsonFields encodes a struct and splits it into its JSON encoded fields
+func jsonFields(v interface{}) (map[string]json.RawMessage, error) {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(encoded, &fields)
+	return fields, err
+}
+
+// containsValue reports whether values has a JSON encoded value
+func containsValue(values []json.RawMessage, value json.RawMessage) bool {
+	for _, v := range values {
+		if bytes.Equal(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmpty reports whether a JSON encoded value is a zero value
+func isEmpty(value json.RawMessage) bool {
+	for _, empty := range emptyValues {
+		if bytes.Equal(value, empty) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package refresh
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+func TestDiff(t *testing.T) {
+	laserdisc := &models.LaserDisc{
+		ID:            7,
+		UPC:           "00012345678905",
+		Title:         "Blade Runner",
+		Year:          1982,
+		Director:      "Ridley Scott",
+		CoverImageURL: "https://www.lddb.com/cover/1.jpg",
+		Notes:         "my notes",
+	}
+	result := &models.LookupResult{
+		UPC:           "012345678905",
+		Title:         "Blade Runner",
+		Year:          1982,
+		Director:      "",       // missed by the scrape, kept
+		Genre:         "Sci-Fi", // newly found
+		Runtime:       117,      // newly found
+		CoverImageURL: "https://www.lddb.com/images/visual/loading.gif",
+		Found:         true,
+	}
+
+	changes, err := Diff(laserdisc, result)
+	require.NoError(t, err)
+	require.Len(t, changes, 2)
+
+	assert.Equal(t, "genre", changes[0].Field)
+	assert.Equal(t, uint(7), changes[0].LaserDiscID)
+	assert.JSONEq(t, `""`, string(changes[0].OldValue))
+	assert.JSONEq(t, `"Sci-Fi"`, string(changes[0].NewValue))
+
+	assert.Equal(t, "runtime", changes[1].Field)
+	assert.JSONEq(t, `0`, string(changes[1].OldValue))
+	assert.JSONEq(t, `117`, string(changes[1].NewValue))
+}
+
+func TestDiff_NoChanges(t *testing.T) {
+	laserdisc := &models.LaserDisc{Title: "Alien", Year: 1979}
+	changes, err := Diff(laserdisc, &models.LookupResult{Title: "Alien", Year: 1979, Found: true})
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
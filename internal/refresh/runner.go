@@ -0,0 +1,224 @@
+package refresh
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+var (
+	// ErrJobRunning is returned when starting a job while another is running
+	ErrJobRunning = errors.New("a refresh job is already running")
+
+	// ErrJobNotRunning is returned when cancelling a job that isn't running
+	ErrJobNotRunning = errors.New("refresh job is not running")
+)
+
+// Fetcher re-scrapes a LaserDisc from its lddb.com URL
+type Fetcher interface {
+	Name() string
+	LookupByURL(rawURL string) (*models.LookupResult, error)
+}
+
+// Repository is the database access the runner needs
+type Repository interface {
+	RefreshCandidates(filter models.RefreshFilter) ([]uint, error)
+	GetLaserDiscByID(id uint) (*models.LaserDisc, error)
+	ManualFields(laserdiscID uint) (map[string]bool, error)
+	RejectedValues(laserdiscID uint) (map[string][]json.RawMessage, error)
+	ApplyMetadataChanges(laserdiscID uint, changes []models.MetadataChange) error
+	CreateRefreshJob(job *models.RefreshJob) error
+	SaveRefreshJob(job *models.RefreshJob) error
+}
+
+// Runner runs refresh jobs one at a time, since they all share LDDB's rate limit
+type Runner struct {
+	repo     Repository
+	fetcher  Fetcher
+	onUpdate func(laserdiscID uint)
+
+	mu      sync.Mutex
+	running uint // ID of the running job, zero when idle
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewRunner creates a runner re-scraping with fetcher. onUpdate, if given, is
+// called for each LaserDisc that had changes applied, e.g. to mirror a new cover.
+func NewRunner(repo Repository, fetcher Fetcher, onUpdate func(laserdiscID uint)) *Runner {
+	return &Runner{
+		repo:     repo,
+		fetcher:  fetcher,
+		onUpdate: onUpdate,
+	}
+}
+
+// Start begins a refresh job over the LaserDiscs matching filter and returns
+// it straight away. The job's progress is saved as it runs.
+func (r *Runner) Start(filter models.RefreshFilter) (*models.RefreshJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running != 0 {
+		return nil, ErrJobRunning
+	}
+
+	ids, err := r.repo.RefreshCandidates(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.RefreshJob{
+		Status: models.RefreshJobRunning,
+		Filter: filter,
+		Total:  len(ids),
+		Errors: []models.RefreshError{},
+	}
+	if err := r.repo.CreateRefreshJob(job); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.running, r.cancel, r.done = job.ID, cancel, make(chan struct{})
+
+	// The goroutine gets its own copy so callers can read the returned job safely
+	started := *job
+	go r.run(ctx, job, ids, r.done)
+	return &started, nil
+}
+
+// Running returns the ID of the running job, or zero
+func (r *Runner) Running() uint {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.running
+}
+
+// Cancel stops a running job after the LaserDisc it's working on
+func (r *Runner) Cancel(jobID uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running == 0 || r.running != jobID {
+		return ErrJobNotRunning
+	}
+	r.cancel()
+	return nil
+}
+
+// Wait blocks until the running job, if any, has finished
+func (r *Runner) Wait() {
+	r.mu.Lock()
+	done := r.done
+	r.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+}
+
+// run works through a job's LaserDiscs, saving progress after each one
+func (r *Runner) run(ctx context.Context, job *models.RefreshJob, ids []uint, done chan struct{}) {
+	defer func() {
+		r.mu.Lock()
+		r.running, r.cancel = 0, nil
+		r.mu.Unlock()
+		close(done)
+	}()
+
+	log.Printf("Refresh job %d started for %d LaserDiscs", job.ID, len(ids))
+	job.Status = models.RefreshJobCompleted
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			job.Status = models.RefreshJobCancelled
+			break
+		}
+
+		applied, suggested, err := r.RefreshLaserDisc(job.ID, id, job.Filter.OverwriteManual)
+		job.Processed++
+		if err != nil {
+			job.Failed++
+			job.Errors = append(job.Errors, models.RefreshError{LaserDiscID: id, Error: err.Error()})
+		}
+		if applied > 0 {
+			job.Updated++
+		}
+		job.Suggested += suggested
+
+		if err := r.repo.SaveRefreshJob(job); err != nil {
+			log.Printf("Warning: Could not save progress of refresh job %d: %v", job.ID, err)
+		}
+	}
+
+	now := time.Now()
+	job.FinishedAt = &now
+	if err := r.repo.SaveRefreshJob(job); err != nil {
+		log.Printf("Warning: Could not save refresh job %d: %v", job.ID, err)
+	}
+	log.Printf("Refresh job %d %s: %d updated, %d suggestions, %d failed", job.ID, job.Status, job.Updated, job.Suggested, job.Failed)
+}
+
+// RefreshLaserDisc re-scrapes one LaserDisc and records the differences.
+// Fields the user edited become suggestions unless overwriteManual is set,
+// the rest are applied. Values the user already rejected aren't suggested
+// again. It returns how many changes were applied and suggested.
+func (r *Runner) RefreshLaserDisc(jobID, id uint, overwriteManual bool) (int, int, error) {
+	laserdisc, err := r.repo.GetLaserDiscByID(id)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	result, err := r.fetcher.LookupByURL(laserdisc.LDDBUrl)
+	if err != nil {
+		return 0, 0, err
+	}
+	if result.Error != "" {
+		return 0, 0, errors.New(result.Error)
+	}
+	if !result.Found {
+		return 0, 0, fmt.Errorf("%s not found on LDDB", laserdisc.LDDBUrl)
+	}
+
+	changes, err := Diff(laserdisc, result)
+	if err != nil || len(changes) == 0 {
+		return 0, 0, err
+	}
+	manual, err := r.repo.ManualFields(id)
+	if err != nil {
+		return 0, 0, err
+	}
+	rejected, err := r.repo.RejectedValues(id)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	applied, suggested := 0, 0
+	kept := changes[:0]
+	for _, change := range changes {
+		change.JobID = jobID
+		change.Source = r.fetcher.Name()
+		if manual[change.Field] && !overwriteManual {
+			if containsValue(rejected[change.Field], change.NewValue) {
+				continue
+			}
+			change.Status = models.ChangePending
+			suggested++
+		} else {
+			change.Status = models.ChangeApplied
+			applied++
+		}
+		kept = append(kept, change)
+	}
+	changes = kept
+
+	if err := r.repo.ApplyMetadataChanges(id, changes); err != nil {
+		return 0, 0, err
+	}
+	if applied > 0 && r.onUpdate != nil {
+		r.onUpdate(id)
+	}
+	return applied, suggested, nil
+}
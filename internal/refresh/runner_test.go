@@ -0,0 +1,262 @@
+package refresh
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// fakeFetcher serves lookup results by URL
+type fakeFetcher struct {
+	results map[string]*models.LookupResult
+	started chan struct{} // when set, each lookup announces itself
+	block   chan struct{} // when set, each lookup waits for a value
+}
+
+func (f *fakeFetcher) Name() string { return "lddb" }
+
+func (f *fakeFetcher) LookupByURL(rawURL string) (*models.LookupResult, error) {
+	if f.started != nil {
+		f.started <- struct{}{}
+	}
+	if f.block != nil {
+		<-f.block
+	}
+	result, ok := f.results[rawURL]
+	if !ok {
+		return nil, errors.New("connection refused")
+	}
+	copied := *result
+	return &copied, nil
+}
+
+// fakeRepository keeps LaserDiscs, jobs and changes in memory
+type fakeRepository struct {
+	mu         sync.Mutex
+	laserdiscs map[uint]*models.LaserDisc
+	manual     map[uint]map[string]bool
+	jobs       map[uint]models.RefreshJob
+	changes    []models.MetadataChange
+}
+
+func newFakeRepository(laserdiscs ...models.LaserDisc) *fakeRepository {
+	repo := &fakeRepository{
+		laserdiscs: make(map[uint]*models.LaserDisc),
+		manual:     make(map[uint]map[string]bool),
+		jobs:       make(map[uint]models.RefreshJob),
+	}
+	for i := range laserdiscs {
+		repo.laserdiscs[laserdiscs[i].ID] = &laserdiscs[i]
+	}
+	return repo
+}
+
+func (r *fakeRepository) RefreshCandidates(filter models.RefreshFilter) ([]uint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []uint
+	for id := uint(1); id <= uint(len(r.laserdiscs)); id++ {
+		if ld, ok := r.laserdiscs[id]; ok && ld.LDDBUrl != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (r *fakeRepository) GetLaserDiscByID(id uint) (*models.LaserDisc, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ld, ok := r.laserdiscs[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	result := *ld
+	return &result, nil
+}
+
+func (r *fakeRepository) ManualFields(id uint) (map[string]bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.manual[id], nil
+}
+
+func (r *fakeRepository) RejectedValues(id uint) (map[string][]json.RawMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rejected := make(map[string][]json.RawMessage)
+	for _, change := range r.changes {
+		if change.LaserDiscID == id && change.Status == models.ChangeRejected {
+			rejected[change.Field] = append(rejected[change.Field], change.NewValue)
+		}
+	}
+	return rejected, nil
+}
+
+func (r *fakeRepository) ApplyMetadataChanges(id uint, changes []models.MetadataChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.changes = append(r.changes, changes...)
+	return nil
+}
+
+func (r *fakeRepository) CreateRefreshJob(job *models.RefreshJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job.ID = uint(len(r.jobs) + 1)
+	r.jobs[job.ID] = *job
+	return nil
+}
+
+func (r *fakeRepository) SaveRefreshJob(job *models.RefreshJob) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs[job.ID] = *job
+	return nil
+}
+
+func (r *fakeRepository) job(id uint) models.RefreshJob {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.jobs[id]
+}
+
+func TestRunner_RefreshLaserDisc(t *testing.T) {
+	repo := newFakeRepository(models.LaserDisc{ID: 1, Title: "Alien", Genre: "Horror", LDDBUrl: "https://www.lddb.com/laserdisc/1/"})
+	repo.manual[1] = map[string]bool{"genre": true}
+	fetcher := &fakeFetcher{results: map[string]*models.LookupResult{
+		"https://www.lddb.com/laserdisc/1/": {Title: "Alien", Genre: "Sci-Fi", Runtime: 117, Found: true},
+	}}
+
+	var updated []uint
+	runner := NewRunner(repo, fetcher, func(id uint) { updated = append(updated, id) })
+
+	applied, suggested, err := runner.RefreshLaserDisc(5, 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, applied)
+	assert.Equal(t, 1, suggested)
+	assert.Equal(t, []uint{1}, updated)
+
+	// The user edited the genre, so LDDB's is only suggested
+	require.Len(t, repo.changes, 2)
+	byField := map[string]models.MetadataChange{}
+	for _, change := range repo.changes {
+		byField[change.Field] = change
+		assert.Equal(t, uint(5), change.JobID)
+		assert.Equal(t, "lddb", change.Source)
+	}
+	assert.Equal(t, models.ChangePending, byField["genre"].Status)
+	assert.Equal(t, models.ChangeApplied, byField["runtime"].Status)
+
+	// A rejected suggestion isn't made again, but a different value is
+	repo.changes = []models.MetadataChange{byField["genre"]}
+	repo.changes[0].Status = models.ChangeRejected
+	applied, suggested, err = runner.RefreshLaserDisc(6, 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, applied)
+	assert.Zero(t, suggested)
+	require.Len(t, repo.changes, 2)
+	assert.Equal(t, "runtime", repo.changes[1].Field)
+
+	fetcher.results["https://www.lddb.com/laserdisc/1/"].Genre = "Science Fiction"
+	_, suggested, err = runner.RefreshLaserDisc(6, 1, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, suggested)
+
+	// Unless asked to overwrite edits
+	repo.changes = nil
+	applied, suggested, err = runner.RefreshLaserDisc(5, 1, true)
+	require.NoError(t, err)
+	assert.Equal(t, 2, applied)
+	assert.Zero(t, suggested)
+	for _, change := range repo.changes {
+		assert.Equal(t, models.ChangeApplied, change.Status, change.Field)
+	}
+}
+
+func TestRunner_RefreshLaserDiscErrors(t *testing.T) {
+	repo := newFakeRepository(
+		models.LaserDisc{ID: 1, LDDBUrl: "https://www.lddb.com/laserdisc/1/"},
+		models.LaserDisc{ID: 2, LDDBUrl: "https://www.lddb.com/laserdisc/2/"},
+		models.LaserDisc{ID: 3, LDDBUrl: "https://www.lddb.com/laserdisc/3/"},
+	)
+	fetcher := &fakeFetcher{results: map[string]*models.LookupResult{
+		"https://www.lddb.com/laserdisc/1/": {Found: false},
+		"https://www.lddb.com/laserdisc/2/": {Error: "Failed to fetch detail page: 503"},
+	}}
+	runner := NewRunner(repo, fetcher, nil)
+
+	for id := uint(1); id <= 4; id++ {
+		_, _, err := runner.RefreshLaserDisc(1, id, false)
+		assert.Error(t, err, "LaserDisc %d", id)
+	}
+	assert.Empty(t, repo.changes)
+}
+
+func TestRunner_Start(t *testing.T) {
+	repo := newFakeRepository(
+		models.LaserDisc{ID: 1, Title: "Alien", LDDBUrl: "https://www.lddb.com/laserdisc/1/"},
+		models.LaserDisc{ID: 2, Title: "Aliens", LDDBUrl: "https://www.lddb.com/laserdisc/2/"},
+		models.LaserDisc{ID: 3, Title: "No URL"},
+	)
+	fetcher := &fakeFetcher{results: map[string]*models.LookupResult{
+		"https://www.lddb.com/laserdisc/1/": {Title: "Alien", Year: 1979, Found: true},
+	}}
+	runner := NewRunner(repo, fetcher, nil)
+
+	job, err := runner.Start(models.RefreshFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, models.RefreshJobRunning, job.Status)
+	assert.Equal(t, 2, job.Total)
+	runner.Wait()
+
+	saved := repo.job(job.ID)
+	assert.Equal(t, models.RefreshJobCompleted, saved.Status)
+	assert.Equal(t, 2, saved.Processed)
+	assert.Equal(t, 1, saved.Updated)
+	assert.Equal(t, 1, saved.Failed)
+	require.Len(t, saved.Errors, 1)
+	assert.Equal(t, uint(2), saved.Errors[0].LaserDiscID)
+	assert.NotNil(t, saved.FinishedAt)
+	assert.Zero(t, runner.Running())
+}
+
+func TestRunner_OneJobAtATimeAndCancel(t *testing.T) {
+	repo := newFakeRepository(
+		models.LaserDisc{ID: 1, LDDBUrl: "https://www.lddb.com/laserdisc/1/"},
+		models.LaserDisc{ID: 2, LDDBUrl: "https://www.lddb.com/laserdisc/2/"},
+	)
+	fetcher := &fakeFetcher{
+		results: map[string]*models.LookupResult{
+			"https://www.lddb.com/laserdisc/1/": {Title: "One", Found: true},
+			"https://www.lddb.com/laserdisc/2/": {Title: "Two", Found: true},
+		},
+		started: make(chan struct{}),
+		block:   make(chan struct{}),
+	}
+	runner := NewRunner(repo, fetcher, nil)
+
+	job, err := runner.Start(models.RefreshFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, job.ID, runner.Running())
+
+	_, err = runner.Start(models.RefreshFilter{})
+	assert.ErrorIs(t, err, ErrJobRunning)
+	assert.ErrorIs(t, runner.Cancel(job.ID+1), ErrJobNotRunning)
+
+	// Cancelling lets the current LaserDisc finish, then stops
+	<-fetcher.started
+	require.NoError(t, runner.Cancel(job.ID))
+	fetcher.block <- struct{}{}
+	runner.Wait()
+
+	saved := repo.job(job.ID)
+	assert.Equal(t, models.RefreshJobCancelled, saved.Status)
+	assert.Equal(t, 1, saved.Processed)
+	assert.ErrorIs(t, runner.Cancel(job.ID), ErrJobNotRunning)
+}
@@ -0,0 +1,59 @@
+package scraper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newFixtureServer serves saved LDDB pages from testdata. Routes map a
+// request URI (path plus query) to a fixture file name; anything else 404s.
+func newFixtureServer(t *testing.T, routes map[string]string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, ok := routes[r.URL.RequestURI()]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		body, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(body)
+	}))
+
+	t.Cleanup(server.Close)
+	return server
+}
+
+// lddbFixtureRoutes covers the saved search and detail pages in testdata
+var lddbFixtureRoutes = map[string]string{
+	"/search.php?UPC=4988104006479":                                  "search_upc_found.html",
+	"/search.php?reference=SF098-1117":                               "search_upc_found.html",
+	"/search.php?UPC=111111111117":                                   "search_no_results.html",
+	"/search.php?reference=LV323503WS":                               "search_listing_fallback.html",
+	"/search.php?UPC=085391163824":                                   "search_multiple.html",
+	"/search.php?title=blade+runner":                                 "search_title_page1.html",
+	"/search.php?page=2&title=blade+runner":                          "search_title_page2.html",
+	"/search.php?country=japan&title=blade+runner":                   "search_title_page1.html",
+	"/search.php?title=zzzz":                                         "search_no_results.html",
+	"/laserdisc/31738/":                                              "laserdisc_31738.html",
+	"/laserdisc/31738/SF098-1117/Star-Wars:-The-Empire-Strikes-Back": "laserdisc_31738.html",
+	"/laserdisc/12345/PLFEB-30581/Terminator-2:-Judgment-Day":        "laserdisc_12345.html",
+}
+
+// newFixtureScraper returns a scraper pointed at the saved LDDB pages
+func newFixtureScraper(t *testing.T) (*LDDBScraper, *httptest.Server) {
+	t.Helper()
+
+	server := newFixtureServer(t, lddbFixtureRoutes)
+	return NewLDDBScraper(WithBaseURL(server.URL), WithRateLimit(0)), server
+}
@@ -3,38 +3,174 @@ package scraper
 import (
 	"fmt"
 	"log"
+	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/PuerkitoBio/goquery"
 	"github.com/gocolly/colly/v2"
 
 	"github.com/paran01d/lddb/internal/models"
 )
 
-// LDDBScraper handles scraping LaserDisc information from lddb.com
+const (
+	// defaultBaseURL is the LDDB site used when no other base URL is configured
+	defaultBaseURL = "https://www.lddb.com"
+
+	// defaultUserAgent identifies the scraper honestly to lddb.com
+	defaultUserAgent = "lddb-collection-manager/1.0 (+https://github.com/paran01d/lddb)"
+
+	// Polite crawling defaults
+	defaultRateLimit      = time.Second
+	defaultMaxRetries     = 3
+	defaultRetryBackoff   = 500 * time.Millisecond
+	defaultRequestTimeout = 10 * time.Second
+)
+
+// lddbIDPattern extracts the numeric ID from a detail page URL: /laserdisc/31738/SF098-1117/Star-Wars...
+var lddbIDPattern = regexp.MustCompile(`/laserdisc/(\d+)/`)
+
+// LDDBScraper handles scraping LaserDisc information from lddb.com.
+// The scraper only holds configuration: every lookup runs on its own clone
+// of the template collector, so lookups can safely run in parallel. Clones
+// share one transport, so the rate limit applies across all lookups.
 type LDDBScraper struct {
 	collector *colly.Collector
+	polite    *politeTransport
+	baseURL   string
+	userAgent string
+	transport http.RoundTripper
+
+	rateLimit      time.Duration
+	maxRetries     int
+	retryBackoff   time.Duration
+	requestTimeout time.Duration
+	respectRobots  bool
+}
+
+// Option configures an LDDBScraper
+type Option func(*LDDBScraper)
+
+// WithBaseURL points the scraper at another LDDB host, such as a local fixture server
+func WithBaseURL(baseURL string) Option {
+	return func(s *LDDBScraper) {
+		s.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithTransport sets the HTTP transport used for all requests
+func WithTransport(transport http.RoundTripper) Option {
+	return func(s *LDDBScraper) {
+		s.transport = transport
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request
+func WithUserAgent(userAgent string) Option {
+	return func(s *LDDBScraper) {
+		s.userAgent = userAgent
+	}
+}
+
+// WithRateLimit sets the minimum gap between requests to the same host; 0 disables it
+func WithRateLimit(interval time.Duration) Option {
+	return func(s *LDDBScraper) {
+		s.rateLimit = interval
+	}
+}
+
+// WithRetries sets how many times a request is retried after a 5xx response
+// or timeout, and the backoff before the first retry
+func WithRetries(maxRetries int, backoff time.Duration) Option {
+	return func(s *LDDBScraper) {
+		s.maxRetries = maxRetries
+		s.retryBackoff = backoff
+	}
+}
+
+// WithRequestTimeout sets the timeout for each individual request attempt
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(s *LDDBScraper) {
+		s.requestTimeout = timeout
+	}
+}
+
+// WithRobotsTxt makes the scraper honor the host's robots.txt
+func WithRobotsTxt(respect bool) Option {
+	return func(s *LDDBScraper) {
+		s.respectRobots = respect
+	}
 }
 
 // NewLDDBScraper creates a new LDDB scraper
-func NewLDDBScraper() *LDDBScraper {
-	c := colly.NewCollector()
+func NewLDDBScraper(opts ...Option) *LDDBScraper {
+	s := &LDDBScraper{
+		baseURL:        defaultBaseURL,
+		userAgent:      defaultUserAgent,
+		transport:      http.DefaultTransport,
+		rateLimit:      defaultRateLimit,
+		maxRetries:     defaultMaxRetries,
+		retryBackoff:   defaultRetryBackoff,
+		requestTimeout: defaultRequestTimeout,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
 
-	// Set user agent to avoid being blocked
-	c.UserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36"
+	c := colly.NewCollector()
+	c.UserAgent = s.userAgent
+	c.IgnoreRobotsTxt = !s.respectRobots
 
 	// Disable visit cache to allow repeated lookups of same UPC
 	c.AllowURLRevisit = true
 
+	// Timeouts are enforced per attempt by the transport so retries get a fresh budget
+	c.SetRequestTimeout(0)
+	s.polite = &politeTransport{
+		base:           s.transport,
+		interval:       s.rateLimit,
+		maxRetries:     s.maxRetries,
+		backoff:        s.retryBackoff,
+		attemptTimeout: s.requestTimeout,
+		next:           make(map[string]time.Time),
+	}
+	c.WithTransport(s.polite)
+
+	s.collector = c
+	return s
+}
+
+// newCollector returns an isolated collector for a single page visit.
+// Clones share the template's HTTP backend but start without any callbacks,
+// so handlers registered for one lookup never see another lookup's pages.
+// Requests made by the collector are counted into stats, if given.
+func (s *LDDBScraper) newCollector(stats *lookupStats) *colly.Collector {
+	c := s.collector.Clone()
+	if stats != nil {
+		c.Context = withLookupStats(c.Context, stats)
+	}
+
 	// Add some basic error handling
 	c.OnError(func(r *colly.Response, err error) {
 		log.Printf("Error scraping %s: %v", r.Request.URL, err)
 	})
 
-	return &LDDBScraper{
-		collector: c,
-	}
+	return c
+}
+
+// HTTPClient returns a client for other downloads from lddb.com, such as
+// cover images. It sends the scraper's User-Agent and shares its rate limit
+// and retries, so the downloads and lookups together stay polite.
+func (s *LDDBScraper) HTTPClient() *http.Client {
+	return &http.Client{Transport: &userAgentTransport{base: s.polite, userAgent: s.userAgent}}
+}
+
+// Name identifies the scraper as a metadata provider
+func (s *LDDBScraper) Name() string {
+	return "lddb"
 }
 
 // LookupByUPC searches lddb.com for LaserDisc information using UPC
@@ -51,55 +187,178 @@ func (s *LDDBScraper) LookupByUPC(upc string) (*models.LookupResult, error) {
 		return result, nil
 	}
 
-	// First, search for the UPC to get basic info and the detailed link
-	searchURL := fmt.Sprintf("https://www.lddb.com/search.php?UPC=%s", cleanUPC)
+	searchURL := fmt.Sprintf("%s/search.php?UPC=%s", s.baseURL, url.QueryEscape(cleanUPC))
+	s.search(searchURL, result)
+
+	return result, nil
+}
+
+// LookupByReference searches lddb.com for LaserDisc information using catalog reference
+func (s *LDDBScraper) LookupByReference(reference string) (*models.LookupResult, error) {
+	result := &models.LookupResult{
+		UPC:   reference, // Store reference in UPC field for consistency
+		Found: false,
+	}
+
+	// Clean reference (remove extra spaces)
+	cleanReference := strings.TrimSpace(reference)
+	if len(cleanReference) == 0 {
+		result.Error = "Invalid reference format"
+		return result, nil
+	}
+
+	// Search by reference instead of UPC
+	searchURL := fmt.Sprintf("%s/search.php?reference=%s", s.baseURL, url.QueryEscape(cleanReference))
+	s.search(searchURL, result)
+
+	return result, nil
+}
+
+// TitleSearch narrows a title or keyword search. Zero values are ignored.
+type TitleSearch struct {
+	Year    int
+	Country string
+	Page    int // 1-based page of LDDB's results listing
+}
+
+// LookupByTitle searches lddb.com by title or keyword, for discs without a
+// usable barcode, and returns one page of matching candidates
+func (s *LDDBScraper) LookupByTitle(query string, filter TitleSearch) (*models.TitleSearchResult, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	result := &models.TitleSearchResult{
+		Query:      strings.TrimSpace(query),
+		Year:       filter.Year,
+		Country:    strings.TrimSpace(filter.Country),
+		Page:       filter.Page,
+		Candidates: []models.LookupCandidate{},
+	}
+	if result.Query == "" {
+		result.Error = "Invalid search query"
+		return result, nil
+	}
+
+	params := url.Values{}
+	params.Set("title", result.Query)
+	if result.Year > 0 {
+		params.Set("year", strconv.Itoa(result.Year))
+	}
+	if result.Country != "" {
+		params.Set("country", result.Country)
+	}
+	if result.Page > 1 {
+		params.Set("page", strconv.Itoa(result.Page))
+	}
+	searchURL := fmt.Sprintf("%s/search.php?%s", s.baseURL, params.Encode())
+
+	stats := &lookupStats{}
+	defer func() {
+		result.Diagnostics = stats.diagnostics()
+	}()
+
+	c := s.newCollector(stats)
+	c.OnHTML("html", func(e *colly.HTMLElement) {
+		for _, candidate := range s.extractCandidates(e) {
+			// LDDB may ignore the filters, so apply them to the listing too
+			if result.Year > 0 && candidate.Year != 0 && candidate.Year != result.Year {
+				continue
+			}
+			if result.Country != "" && candidate.Country != "" && !strings.EqualFold(candidate.Country, result.Country) {
+				continue
+			}
+			result.Candidates = append(result.Candidates, candidate)
+		}
+		result.HasMore = hasNextPage(e, result.Page)
+	})
+
+	if err := c.Visit(searchURL); err != nil {
+		result.Error = fmt.Sprintf("Failed to fetch search results: %v", err)
+		return result, nil
+	}
+	c.Wait()
+
+	log.Printf("Title search %q page %d found %d candidates", result.Query, result.Page, len(result.Candidates))
+	return result, nil
+}
+
+// hasNextPage reports whether a results listing links to the page after page
+func hasNextPage(e *colly.HTMLElement, page int) bool {
+	next := strconv.Itoa(page + 1)
+	found := false
+	e.ForEach("a[href*='search.php']", func(_ int, link *colly.HTMLElement) {
+		href, err := url.Parse(link.Attr("href"))
+		if err != nil {
+			return
+		}
+		if href.Query().Get("page") == next || strings.HasPrefix(strings.ToLower(cleanText(link.Text)), "next") {
+			found = true
+		}
+	})
+	return found
+}
+
+// search visits an LDDB search page. If it lists several LaserDiscs the
+// result only carries the candidates; otherwise the single detail page it
+// links to is followed to fill in the result. All parse state lives in this
+// call so concurrent searches never share a result or a detail URL.
+func (s *LDDBScraper) search(searchURL string, result *models.LookupResult) {
 	var detailURL string
 
-	// Set up scraping rules for search results
-	s.collector.OnHTML("html", func(e *colly.HTMLElement) {
+	stats := &lookupStats{}
+	defer func() {
+		result.Diagnostics = stats.diagnostics()
+	}()
+
+	c := s.newCollector(stats)
+	c.OnHTML("html", func(e *colly.HTMLElement) {
 		// Check if we got search results or a direct hit
 		pageText := strings.ToLower(e.Text)
-		
+
 		// If page contains "no results" or similar, return not found
-		if strings.Contains(pageText, "no results") || 
-		   strings.Contains(pageText, "not found") ||
-		   strings.Contains(pageText, "0 results") {
+		if strings.Contains(pageText, "no results") ||
+			strings.Contains(pageText, "not found") ||
+			strings.Contains(pageText, "0 results") {
 			result.Found = false
 			return
 		}
 
+		// Several matches (reissues, box sets, PAL/NTSC variants) are left
+		// for the caller to choose from
+		if candidates := s.extractCandidates(e); len(candidates) > 1 {
+			log.Printf("Found %d candidate LaserDiscs", len(candidates))
+			result.Candidates = candidates
+			return
+		}
+
 		// Look for detailed page links in href attributes
 		// Pattern: /laserdisc/{ID}/{CATALOG_NUMBER}/{TITLE}
 		e.ForEach("a[href*='/laserdisc/']", func(_ int, link *colly.HTMLElement) {
 			href := link.Attr("href")
 			if strings.Contains(href, "/laserdisc/") && detailURL == "" {
 				// Convert relative URL to absolute
-				if strings.HasPrefix(href, "/") {
-					detailURL = "https://www.lddb.com" + href
-				} else if strings.HasPrefix(href, "http") {
-					detailURL = href
-				}
+				detailURL = s.absoluteURL(href)
 				log.Printf("Found detailed page link: %s", detailURL)
 			}
 		})
-		
+
 		// Fallback: Extract basic info from search results as before
 		s.extractLaserDiscInfo(e, result)
 	})
 
 	// Visit the search URL first
-	err := s.collector.Visit(searchURL)
+	err := c.Visit(searchURL)
 	if err != nil {
 		result.Error = fmt.Sprintf("Failed to fetch search results: %v", err)
-		return result, nil
+		return
 	}
-	
-	s.collector.Wait()
+
+	c.Wait()
 
 	// If we found a detailed URL, get detailed information (even if result.Found is false)
 	if detailURL != "" {
 		log.Printf("Attempting to get detailed info from: %s", detailURL)
-		err := s.getDetailedInfo(detailURL, result)
+		err := s.getDetailedInfo(detailURL, result, stats)
 		if err != nil {
 			log.Printf("Warning: Could not fetch detailed info from %s: %v", detailURL, err)
 		} else {
@@ -108,101 +367,218 @@ func (s *LDDBScraper) LookupByUPC(upc string) (*models.LookupResult, error) {
 	} else {
 		log.Printf("No detailed page link found")
 	}
+}
 
-	return result, nil
+// LookupByID fetches a LaserDisc's detail page directly by its numeric LDDB ID,
+// skipping the search step
+func (s *LDDBScraper) LookupByID(id int) (*models.LookupResult, error) {
+	if id <= 0 {
+		return &models.LookupResult{Error: "Invalid LDDB ID"}, nil
+	}
+
+	return s.lookupDetail(fmt.Sprintf("%s/laserdisc/%d/", s.baseURL, id), id), nil
 }
 
-// LookupByReference searches lddb.com for LaserDisc information using catalog reference
-func (s *LDDBScraper) LookupByReference(reference string) (*models.LookupResult, error) {
+// LookupByURL fetches the detail page a lddb.com URL points at, e.g. one
+// copied from a browser. The path is fetched from the configured base URL.
+func (s *LDDBScraper) LookupByURL(rawURL string) (*models.LookupResult, error) {
+	id, path, err := s.parseDetailURL(rawURL)
+	if err != nil {
+		return &models.LookupResult{Error: fmt.Sprintf("Invalid LDDB URL: %v", err)}, nil
+	}
+
+	return s.lookupDetail(s.baseURL+path, id), nil
+}
+
+// ParseLDDBID extracts the numeric LDDB ID from a lddb.com detail page URL
+func (s *LDDBScraper) ParseLDDBID(rawURL string) (int, error) {
+	id, _, err := s.parseDetailURL(rawURL)
+	return id, err
+}
+
+// parseDetailURL validates a detail page URL on lddb.com (or the configured
+// base URL's host) and returns its LDDB ID and path
+func (s *LDDBScraper) parseDetailURL(rawURL string) (int, string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "https://" + rawURL
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" {
+		return 0, "", fmt.Errorf("not a URL")
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	if base, err := url.Parse(s.baseURL); err == nil && strings.EqualFold(parsed.Host, base.Host) {
+		host = "lddb.com"
+	}
+	if host != "lddb.com" && host != "www.lddb.com" {
+		return 0, "", fmt.Errorf("not on lddb.com")
+	}
+
+	path := parsed.EscapedPath()
+	if !strings.HasSuffix(path, "/") && strings.Count(path, "/") == 2 {
+		path += "/" // bare /laserdisc/31738
+	}
+	matches := lddbIDPattern.FindStringSubmatch(path)
+	if len(matches) < 2 || !strings.HasPrefix(path, "/laserdisc/") {
+		return 0, "", fmt.Errorf("not a LaserDisc detail page")
+	}
+
+	id, err := strconv.Atoi(matches[1])
+	if err != nil || id <= 0 {
+		return 0, "", fmt.Errorf("invalid LDDB ID")
+	}
+	return id, path, nil
+}
+
+// lookupDetail scrapes a single detail page into a new result
+func (s *LDDBScraper) lookupDetail(detailURL string, id int) *models.LookupResult {
 	result := &models.LookupResult{
-		UPC:   reference, // Store reference in UPC field for consistency
-		Found: false,
+		LDDBID: id,
+		Found:  false,
 	}
 
-	// Clean reference (remove extra spaces)
-	cleanReference := strings.TrimSpace(reference)
-	if len(cleanReference) == 0 {
-		result.Error = "Invalid reference format"
-		return result, nil
+	stats := &lookupStats{}
+	if err := s.getDetailedInfo(detailURL, result, stats); err != nil {
+		result.Error = fmt.Sprintf("Failed to fetch detail page: %v", err)
 	}
+	result.Diagnostics = stats.diagnostics()
 
-	// Search by reference instead of UPC
-	searchURL := fmt.Sprintf("https://www.lddb.com/search.php?reference=%s", cleanReference)
-	var detailURL string
+	return result
+}
 
-	// Use the same scraping logic as UPC lookup
-	s.collector.OnHTML("html", func(e *colly.HTMLElement) {
-		pageText := strings.ToLower(e.Text)
-		
-		if strings.Contains(pageText, "no results") || 
-		   strings.Contains(pageText, "not found") ||
-		   strings.Contains(pageText, "0 results") {
-			result.Found = false
+// extractCandidates lists every distinct LaserDisc linked from a results
+// table row. Columns are identified by the table's header row when it has one.
+func (s *LDDBScraper) extractCandidates(e *colly.HTMLElement) []models.LookupCandidate {
+	var candidates []models.LookupCandidate
+	seen := make(map[int]bool)
+
+	e.ForEach("tr", func(_ int, row *colly.HTMLElement) {
+		link := row.DOM.ChildrenFiltered("td").Find("a[href*='/laserdisc/']").First()
+		href, ok := link.Attr("href")
+		if !ok {
 			return
 		}
 
-		// Look for detailed page links
-		e.ForEach("a[href*='/laserdisc/']", func(_ int, link *colly.HTMLElement) {
-			href := link.Attr("href")
-			if strings.Contains(href, "/laserdisc/") && detailURL == "" {
-				if strings.HasPrefix(href, "/") {
-					detailURL = "https://www.lddb.com" + href
-				} else if strings.HasPrefix(href, "http") {
-					detailURL = href
+		matches := lddbIDPattern.FindStringSubmatch(href)
+		if len(matches) < 2 {
+			return
+		}
+		id, err := strconv.Atoi(matches[1])
+		if err != nil || seen[id] {
+			return
+		}
+		seen[id] = true
+
+		candidate := models.LookupCandidate{
+			LDDBID:  id,
+			LDDBUrl: s.absoluteURL(href),
+		}
+
+		// Link text is usually "Title (Year)"
+		candidate.Title, candidate.Year = splitTitleYear(cleanText(link.Text()))
+
+		// Fill the rest from labelled columns
+		headers := row.DOM.Closest("table").Find("tr").First().ChildrenFiltered("th")
+		row.DOM.ChildrenFiltered("td").Each(func(i int, cell *goquery.Selection) {
+			if i >= headers.Length() {
+				return
+			}
+			value := cleanText(cell.Text())
+			switch strings.ToLower(cleanText(headers.Eq(i).Text())) {
+			case "reference", "catalog", "catalog #", "catalog number":
+				candidate.Reference = value
+			case "year":
+				if year, err := strconv.Atoi(value); err == nil && candidate.Year == 0 {
+					candidate.Year = year
 				}
-				log.Printf("Found detailed page link via reference: %s", detailURL)
+			case "country":
+				candidate.Country = value
 			}
 		})
-		
-		s.extractLaserDiscInfo(e, result)
-	})
 
-	// Visit the search URL
-	err := s.collector.Visit(searchURL)
-	if err != nil {
-		result.Error = fmt.Sprintf("Failed to fetch search results: %v", err)
-		return result, nil
-	}
-	
-	s.collector.Wait()
+		// Without a reference column, the catalog number is in the URL
+		if candidate.Reference == "" {
+			if parts := strings.Split(strings.Trim(strings.SplitN(href, "/laserdisc/", 2)[1], "/"), "/"); len(parts) > 1 {
+				candidate.Reference = parts[1]
+			}
+		}
 
-	// If we found a detailed URL, get detailed information
-	if detailURL != "" {
-		log.Printf("Attempting to get detailed info from: %s", detailURL)
-		err := s.getDetailedInfo(detailURL, result)
-		if err != nil {
-			log.Printf("Warning: Could not fetch detailed info from %s: %v", detailURL, err)
+		if src, ok := row.DOM.Find("img[src*='/cover/']").First().Attr("src"); ok {
+			candidate.ThumbnailURL = s.absoluteURL(src)
 		} else {
-			log.Printf("Successfully visited detailed page")
+			candidate.ThumbnailURL = s.coverThumbURL(id)
 		}
-	} else {
-		log.Printf("No detailed page link found for reference")
+
+		candidates = append(candidates, candidate)
+	})
+
+	return candidates
+}
+
+// cleanText collapses whitespace, including LDDB's non-breaking spaces
+func cleanText(text string) string {
+	return strings.Join(strings.Fields(strings.ReplaceAll(text, "\u00a0", " ")), " ")
+}
+
+// splitTitleYear splits "Title (1980)" into its title and year
+func splitTitleYear(text string) (string, int) {
+	matches := regexp.MustCompile(`^(.+?)\s*\((\d{4})\)$`).FindStringSubmatch(text)
+	if len(matches) < 3 {
+		return text, 0
 	}
+	year, _ := strconv.Atoi(matches[2])
+	return strings.TrimSpace(matches[1]), year
+}
 
-	return result, nil
+// coverThumbURL guesses the cover thumbnail URL for an LDDB ID. Covers are
+// grouped in ranges of 100 IDs, e.g. 31738 -> 31701-31800.
+func (s *LDDBScraper) coverThumbURL(id int) string {
+	// Calculate range start (round down to nearest 100, then add 1)
+	rangeStart := ((id-1)/100)*100 + 1
+	rangeEnd := rangeStart + 99
+
+	return fmt.Sprintf("%s/cover/ld/%d-%d/thumb/%d.jpg", s.baseURL, rangeStart, rangeEnd, id)
+}
+
+// absoluteURL resolves an href found on an LDDB page against the base URL.
+// It returns an empty string for hrefs that are neither root-relative nor absolute.
+func (s *LDDBScraper) absoluteURL(href string) string {
+	if strings.HasPrefix(href, "/") {
+		return s.baseURL + href
+	}
+	if strings.HasPrefix(href, "http") {
+		return href
+	}
+	return ""
 }
 
 // getDetailedInfo fetches detailed information from the LaserDisc's dedicated page
-func (s *LDDBScraper) getDetailedInfo(url string, result *models.LookupResult) error {
+func (s *LDDBScraper) getDetailedInfo(url string, result *models.LookupResult, stats *lookupStats) error {
 	// Extract LDDB ID from URL: /laserdisc/31738/SF098-1117/Star-Wars...
-	lddbIDPattern := regexp.MustCompile(`/laserdisc/(\d+)/`)
 	var lddbID string
 	if matches := lddbIDPattern.FindStringSubmatch(url); len(matches) > 1 {
 		lddbID = matches[1]
+		result.LDDBID, _ = strconv.Atoi(lddbID)
 		log.Printf("Extracted LDDB ID: %s", lddbID)
 	}
-	
-	// Create a new collector for the detailed page
-	detailCollector := colly.NewCollector()
-	detailCollector.UserAgent = s.collector.UserAgent
-	detailCollector.AllowURLRevisit = true
-	
+
+	// Use a fresh collector so this page's handler is the only one registered
+	detailCollector := s.newCollector(stats)
+
+	// Keep the raw page so callers can cache it alongside the parsed result
+	detailCollector.OnResponse(func(r *colly.Response) {
+		result.DetailHTML = string(r.Body)
+	})
+
 	detailCollector.OnHTML("html", func(e *colly.HTMLElement) {
 		pageText := e.Text
-		
+
 		// Debug logging
 		log.Printf("Visiting detailed page: %s", url)
-		
+
 		// Extract title and year from h2 class="lddb" element
 		// Format: "title (year) [discard]"
 		if result.Title == "" {
@@ -234,7 +610,7 @@ func (s *LDDBScraper) getDetailedInfo(url string, result *models.LookupResult) e
 				}
 			}
 		}
-		
+
 		// Extract year if not already set
 		if result.Year == 0 {
 			yearPattern := regexp.MustCompile(`(?i)(?:year|date)[:\s]*(\d{4})`)
@@ -244,14 +620,14 @@ func (s *LDDBScraper) getDetailedInfo(url string, result *models.LookupResult) e
 				}
 			}
 		}
-		
+
 		// Look for runtime information
 		runtimePatterns := []string{
 			`(?i)runtime[:\s]*(\d+)\s*min`,
 			`(\d+)\s*minutes?`,
 			`(?i)duration[:\s]*(\d+)\s*min`,
 		}
-		
+
 		for _, pattern := range runtimePatterns {
 			re := regexp.MustCompile(pattern)
 			if matches := re.FindStringSubmatch(pageText); len(matches) > 1 {
@@ -261,7 +637,7 @@ func (s *LDDBScraper) getDetailedInfo(url string, result *models.LookupResult) e
 				}
 			}
 		}
-		
+
 		// Look for sides information
 		sidesPattern := regexp.MustCompile(`(?i)sides[:\s]*(\d+)`)
 		if matches := sidesPattern.FindStringSubmatch(pageText); len(matches) > 1 {
@@ -269,17 +645,17 @@ func (s *LDDBScraper) getDetailedInfo(url string, result *models.LookupResult) e
 				result.Sides = sides
 			}
 		}
-		
+
 		// Extract structured fields from LDDB table format
 		s.extractTableFields(e, result)
-		
+
 		// Look for format information (CLV, CAV, etc.)
 		formatPatterns := []string{
 			`(?i)disc mode[:\s]*([A-Z]{3})`,
 			`(?i)format[:\s]*([A-Z/]+)`,
 			`\b(CLV|CAV)\b`,
 		}
-		
+
 		for _, pattern := range formatPatterns {
 			re := regexp.MustCompile(pattern)
 			if matches := re.FindStringSubmatch(pageText); len(matches) > 1 {
@@ -290,29 +666,21 @@ func (s *LDDBScraper) getDetailedInfo(url string, result *models.LookupResult) e
 				}
 			}
 		}
-		
+
 		// Construct cover image URL from LDDB ID if available
 		if lddbID != "" && result.CoverImageURL == "" {
-			// Convert LDDB ID to range grouping (e.g., 31738 -> 31701-31800)
 			if id, err := strconv.Atoi(lddbID); err == nil {
-				// Calculate range start (round down to nearest 100, then add 1)
-				rangeStart := ((id - 1) / 100) * 100 + 1
-				rangeEnd := rangeStart + 99
-				
-				// Construct cover URL
-				coverURL := fmt.Sprintf("https://www.lddb.com/cover/ld/%d-%d/thumb/%s.jpg", 
-					rangeStart, rangeEnd, lddbID)
-				result.CoverImageURL = coverURL
-				log.Printf("Constructed cover image URL: %s", coverURL)
-			}
-		}
-		
+				result.CoverImageURL = s.coverThumbURL(id)
+				log.Printf("Constructed cover image URL: %s", result.CoverImageURL)
+			}
+		}
+
 		// Look for format images (clv.png or cav.png) and cover images
 		e.ForEach("img", func(_ int, img *colly.HTMLElement) {
 			src := img.Attr("src")
-			
+
 			// Format detection and image processing
-			
+
 			// Check for format images (override any previous format detection)
 			if strings.Contains(src, "/mode/clv.png") {
 				result.Format = "CLV"
@@ -321,32 +689,30 @@ func (s *LDDBScraper) getDetailedInfo(url string, result *models.LookupResult) e
 				result.Format = "CAV"
 				log.Printf("Found format from image: CAV (%s)", src)
 			}
-			
+
 			// Skip loading gifs and generic images for cover extraction
 			if strings.Contains(src, "loading.gif") || strings.Contains(src, "spacer.gif") {
 				return
 			}
-			
+
 			// Look for actual cover images
 			if strings.Contains(src, "/cover/ld/") && strings.Contains(src, "/thumb/") {
-				if strings.HasPrefix(src, "/") {
-					result.CoverImageURL = "https://www.lddb.com" + src
-				} else if strings.HasPrefix(src, "http") {
-					result.CoverImageURL = src
+				if coverURL := s.absoluteURL(src); coverURL != "" {
+					result.CoverImageURL = coverURL
 				}
 				log.Printf("Found cover image in page: %s", result.CoverImageURL)
 			}
 		})
-		
+
 		// Set the LDDB URL for reference
 		result.LDDBUrl = url
-		
+
 		// Mark as found if we got essential information
 		if result.Title != "" {
 			result.Found = true
 		}
 	})
-	
+
 	return detailCollector.Visit(url)
 }
 
@@ -356,13 +722,13 @@ func (s *LDDBScraper) extractTableFields(e *colly.HTMLElement, result *models.Lo
 	e.ForEach("tr", func(_ int, row *colly.HTMLElement) {
 		fieldCell := row.ChildText("td.field")
 		fieldName := strings.ToLower(strings.TrimSpace(strings.ReplaceAll(fieldCell, "\u00a0", " ")))
-		
+
 		// Remove common suffixes and clean field name
 		fieldName = strings.TrimSuffix(fieldName, ":")
 		fieldName = strings.TrimSpace(fieldName)
-		
+
 		log.Printf("Processing table field: '%s'", fieldName)
-		
+
 		switch fieldName {
 		case "category":
 			if result.Genre == "" {
@@ -373,7 +739,7 @@ func (s *LDDBScraper) extractTableFields(e *colly.HTMLElement, result *models.Lo
 					log.Printf("Found category: %s", result.Genre)
 				}
 			}
-			
+
 		case "sides":
 			if result.Sides == 0 {
 				sidesText := row.ChildText("td.data")
@@ -384,7 +750,7 @@ func (s *LDDBScraper) extractTableFields(e *colly.HTMLElement, result *models.Lo
 					}
 				}
 			}
-			
+
 		case "length":
 			if result.Runtime == 0 {
 				lengthText := row.ChildText("td.data")
@@ -407,7 +773,7 @@ func (s *LDDBScraper) extractTableFields(e *colly.HTMLElement, result *models.Lo
 					}
 				}
 			}
-			
+
 		case "format", "disc mode":
 			if result.Format == "" {
 				formatText := row.ChildText("td.data")
@@ -416,10 +782,178 @@ func (s *LDDBScraper) extractTableFields(e *colly.HTMLElement, result *models.Lo
 					log.Printf("Found format from table: %s", result.Format)
 				}
 			}
+			if fieldName == "disc mode" && result.DiscModes == "" {
+				result.DiscModes = parseDiscModes(row)
+			}
+
+		case "upc", "barcode":
+			if result.UPC == "" {
+				result.UPC = dataText(row)
+			}
+
+		case "reference", "catalog", "catalog number", "catalog #":
+			if result.Reference == "" {
+				result.Reference = dataText(row)
+			}
+
+		case "label":
+			if result.Label == "" {
+				result.Label = dataText(row)
+			}
+
+		case "release date", "released":
+			if result.ReleaseDate == "" {
+				result.ReleaseDate = dataText(row)
+			}
+
+		case "country":
+			if result.Country == "" {
+				result.Country = dataText(row)
+			}
+
+		case "video":
+			if result.VideoStandard == "" {
+				result.VideoStandard = parseVideoStandard(dataText(row))
+			}
+
+		case "picture format", "picture", "aspect ratio":
+			if result.PictureFormat == "" && result.AspectRatio == "" {
+				result.PictureFormat, result.AspectRatio = parsePictureFormat(dataText(row))
+			}
+
+		case "sound", "audio":
+			if result.Sound == "" {
+				result.Sound = parseSound(dataText(row))
+			}
+
+		case "chapters":
+			if result.Chapters == 0 {
+				if matches := regexp.MustCompile(`(\d+)`).FindStringSubmatch(dataText(row)); len(matches) > 1 {
+					result.Chapters, _ = strconv.Atoi(matches[1])
+				}
+			}
+
+		case "price":
+			if result.Price == "" {
+				result.Price = dataText(row)
+			}
+
+		case "director", "directed by":
+			if result.Director == "" {
+				result.Director = linkList(row)
+			}
+
+		case "producer", "produced by":
+			if result.Producer == "" {
+				result.Producer = linkList(row)
+			}
+
+		case "cast", "starring":
+			if result.Cast == "" {
+				result.Cast = linkList(row)
+			}
 		}
 	})
 }
 
+// dataText returns the cleaned text of a row's td.data cell
+func dataText(row *colly.HTMLElement) string {
+	text := strings.ReplaceAll(row.ChildText("td.data"), "\u00a0", " ")
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// linkList joins the link texts in a row's td.data cell, falling back to the
+// plain cell text when the names aren't linked
+func linkList(row *colly.HTMLElement) string {
+	var names []string
+	for _, name := range row.ChildTexts("td.data a") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return dataText(row)
+	}
+	return strings.Join(names, ", ")
+}
+
+// parseDiscModes returns the mode of each side in order, e.g. "CAV/CLV".
+// LDDB shows one mode image per side, sometimes with plain text instead.
+func parseDiscModes(row *colly.HTMLElement) string {
+	var modes []string
+	row.ForEach("td.data img", func(_ int, img *colly.HTMLElement) {
+		src := strings.ToLower(img.Attr("src"))
+		switch {
+		case strings.Contains(src, "/mode/clv"):
+			modes = append(modes, "CLV")
+		case strings.Contains(src, "/mode/cav"):
+			modes = append(modes, "CAV")
+		case strings.Contains(src, "/mode/cld"):
+			modes = append(modes, "CLD")
+		}
+	})
+	if len(modes) == 0 {
+		modes = regexp.MustCompile(`\b(CLV|CAV|CLD)\b`).FindAllString(strings.ToUpper(dataText(row)), -1)
+	}
+	return strings.Join(modes, "/")
+}
+
+// parseVideoStandard normalizes the Video field to NTSC, PAL or SECAM
+func parseVideoStandard(text string) string {
+	upper := strings.ToUpper(text)
+	for _, standard := range []string{"NTSC", "PAL", "SECAM"} {
+		if strings.Contains(upper, standard) {
+			return standard
+		}
+	}
+	return text
+}
+
+// parsePictureFormat splits a picture format such as "Letterbox 2.35:1" into
+// LBX or P&S and the aspect ratio
+func parsePictureFormat(text string) (string, string) {
+	var aspectRatio string
+	if matches := regexp.MustCompile(`(\d+(?:\.\d+)?)\s*:\s*1\b`).FindStringSubmatch(text); len(matches) > 1 {
+		aspectRatio = matches[1] + ":1"
+	}
+
+	lower := strings.ToLower(text)
+	switch {
+	case strings.Contains(lower, "letterbox") || strings.Contains(lower, "lbx") ||
+		strings.Contains(lower, "widescreen"):
+		return "LBX", aspectRatio
+	case strings.Contains(lower, "pan") || strings.Contains(lower, "p&s") ||
+		strings.Contains(lower, "standard") || strings.Contains(lower, "full screen"):
+		return "P&S", aspectRatio
+	case aspectRatio != "":
+		return "", aspectRatio
+	}
+	return text, aspectRatio
+}
+
+// parseSound reduces the Sound field to its encodings, e.g. "AC3/Digital/Analog".
+// Unrecognized text is returned as is.
+func parseSound(text string) string {
+	lower := strings.ToLower(text)
+	var sound []string
+	if strings.Contains(lower, "ac3") || strings.Contains(lower, "ac-3") || strings.Contains(lower, "dolby digital") {
+		sound = append(sound, "AC3")
+	}
+	if strings.Contains(lower, "dts") {
+		sound = append(sound, "DTS")
+	}
+	if regexp.MustCompile(`\bdigital\b`).MatchString(strings.ReplaceAll(lower, "dolby digital", "")) {
+		sound = append(sound, "Digital")
+	}
+	if strings.Contains(lower, "analog") {
+		sound = append(sound, "Analog")
+	}
+	if len(sound) == 0 {
+		return text
+	}
+	return strings.Join(sound, "/")
+}
+
 // extractLaserDiscInfo extracts LaserDisc information from the HTML
 func (s *LDDBScraper) extractLaserDiscInfo(e *colly.HTMLElement, result *models.LookupResult) {
 	// Try different selectors to find the information
@@ -537,14 +1071,14 @@ func (s *LDDBScraper) extractFromDivStructure(e *colly.HTMLElement, result *mode
 
 		class := div.Attr("class")
 		id := div.Attr("id")
-		
-		if strings.Contains(strings.ToLower(class), "disc") || 
-		   strings.Contains(strings.ToLower(id), "disc") ||
-		   strings.Contains(strings.ToLower(class), "title") {
-			
+
+		if strings.Contains(strings.ToLower(class), "disc") ||
+			strings.Contains(strings.ToLower(id), "disc") ||
+			strings.Contains(strings.ToLower(class), "title") {
+
 			text := div.Text
 			s.extractFromText(text, result)
-			
+
 			if result.Title != "" {
 				result.Found = true
 			}
@@ -555,21 +1089,21 @@ func (s *LDDBScraper) extractFromDivStructure(e *colly.HTMLElement, result *mode
 // extractFromTextPatterns extracts info using regex patterns on the full page text
 func (s *LDDBScraper) extractFromTextPatterns(e *colly.HTMLElement, result *models.LookupResult) {
 	text := e.Text
-	
+
 	// Look for LDDB-specific format: LV323503WS Ghost and the Darkness, The (1996)LBX/AC3/THX1997-04-15NTSCUSA
 	// Note: LDDB uses non-breaking spaces (\u00a0) instead of regular spaces
 	lines := strings.Split(text, "\n")
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
-		
+
 		// Clean non-breaking spaces that LDDB uses
 		cleanLine := strings.ReplaceAll(line, "\u00a0", " ")
-		
+
 		// Look for lines that might contain LaserDisc information
-		if strings.Contains(strings.ToLower(line), strings.ToLower(result.UPC)) || 
-		   (len(result.UPC) > 6 && strings.Contains(line, result.UPC[len(result.UPC)-6:])) ||
-		   strings.Contains(line, "LV") {
-			
+		if strings.Contains(strings.ToLower(line), strings.ToLower(result.UPC)) ||
+			(len(result.UPC) > 6 && strings.Contains(line, result.UPC[len(result.UPC)-6:])) ||
+			strings.Contains(line, "LV") {
+
 			// Try to extract full information from the LDDB format
 			pattern := regexp.MustCompile(`(LV\w+)\s+(.+?)\s*\((\d{4})\)([A-Z/]+)`)
 			if matches := pattern.FindStringSubmatch(cleanLine); len(matches) >= 4 {
@@ -583,25 +1117,25 @@ func (s *LDDBScraper) extractFromTextPatterns(e *colly.HTMLElement, result *mode
 			}
 		}
 	}
-	
+
 	// Generic patterns as fallback
 	titlePatterns := []string{
 		`Title:\s*(.+?)(?:\n|$)`,
 		`TITLE:\s*(.+?)(?:\n|$)`,
 	}
-	
+
 	for _, pattern := range titlePatterns {
 		re := regexp.MustCompile(pattern)
 		if matches := re.FindStringSubmatch(text); len(matches) > 1 {
 			title := strings.TrimSpace(matches[1])
-			if !strings.Contains(strings.ToLower(title), "lddb") && 
-			   !strings.Contains(strings.ToLower(title), "search") {
+			if !strings.Contains(strings.ToLower(title), "lddb") &&
+				!strings.Contains(strings.ToLower(title), "search") {
 				result.Title = title
 				break
 			}
 		}
 	}
-	
+
 	// Extract year
 	yearPattern := regexp.MustCompile(`(?:Year|Date):\s*(\d{4})`)
 	if matches := yearPattern.FindStringSubmatch(text); len(matches) > 1 {
@@ -609,7 +1143,7 @@ func (s *LDDBScraper) extractFromTextPatterns(e *colly.HTMLElement, result *mode
 			result.Year = year
 		}
 	}
-	
+
 	if result.Title != "" {
 		result.Found = true
 	}
@@ -618,13 +1152,13 @@ func (s *LDDBScraper) extractFromTextPatterns(e *colly.HTMLElement, result *mode
 // extractFromText extracts information from raw text
 func (s *LDDBScraper) extractFromText(text string, result *models.LookupResult) {
 	lines := strings.Split(text, "\n")
-	
+
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
 		if line == "" {
 			continue
 		}
-		
+
 		parts := strings.Split(line, ":")
 		if len(parts) == 2 {
 			label := strings.ToLower(strings.TrimSpace(parts[0]))
@@ -675,13 +1209,13 @@ func (s *LDDBScraper) extractYear(text string) (int, error) {
 func (s *LDDBScraper) extractRuntime(text string) (int, error) {
 	// Look for patterns like "120 min", "2:00", "2h 30m"
 	text = strings.ToLower(text)
-	
+
 	// Pattern: "XXX min"
 	if re := regexp.MustCompile(`(\d+)\s*min`); re.MatchString(text) {
 		matches := re.FindStringSubmatch(text)
 		return strconv.Atoi(matches[1])
 	}
-	
+
 	// Pattern: "H:MM" or "HH:MM"
 	if re := regexp.MustCompile(`(\d+):(\d+)`); re.MatchString(text) {
 		matches := re.FindStringSubmatch(text)
@@ -689,7 +1223,7 @@ func (s *LDDBScraper) extractRuntime(text string) (int, error) {
 		minutes, _ := strconv.Atoi(matches[2])
 		return hours*60 + minutes, nil
 	}
-	
+
 	// Pattern: "Xh Ym"
 	if re := regexp.MustCompile(`(\d+)h\s*(\d+)m`); re.MatchString(text) {
 		matches := re.FindStringSubmatch(text)
@@ -697,6 +1231,6 @@ func (s *LDDBScraper) extractRuntime(text string) (int, error) {
 		minutes, _ := strconv.Atoi(matches[2])
 		return hours*60 + minutes, nil
 	}
-	
+
 	return 0, fmt.Errorf("no runtime found")
-}
\ No newline at end of file
+}
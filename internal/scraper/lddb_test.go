@@ -1,21 +1,31 @@
 package scraper
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/paran01d/lddb/internal/models"
 )
 
 func TestNewLDDBScraper(t *testing.T) {
 	scraper := NewLDDBScraper()
 	assert.NotNil(t, scraper)
 	assert.NotNil(t, scraper.collector)
+	assert.Equal(t, defaultUserAgent, scraper.collector.UserAgent)
+	assert.True(t, scraper.collector.IgnoreRobotsTxt)
 }
 
 func TestLDDBScraper_extractYear(t *testing.T) {
 	scraper := NewLDDBScraper()
-	
+
 	tests := []struct {
 		input    string
 		expected int
@@ -28,7 +38,7 @@ func TestLDDBScraper_extractYear(t *testing.T) {
 		{"no year here", 0, true},
 		{"", 0, true},
 	}
-	
+
 	for _, tt := range tests {
 		result, err := scraper.extractYear(tt.input)
 		if tt.hasError {
@@ -42,7 +52,7 @@ func TestLDDBScraper_extractYear(t *testing.T) {
 
 func TestLDDBScraper_extractRuntime(t *testing.T) {
 	scraper := NewLDDBScraper()
-	
+
 	tests := []struct {
 		input    string
 		expected int
@@ -58,7 +68,7 @@ func TestLDDBScraper_extractRuntime(t *testing.T) {
 		{"no runtime here", 0, true},
 		{"", 0, true},
 	}
-	
+
 	for _, tt := range tests {
 		result, err := scraper.extractRuntime(tt.input)
 		if tt.hasError {
@@ -73,7 +83,7 @@ func TestLDDBScraper_extractRuntime(t *testing.T) {
 func TestLDDBScraper_processLabelValue(t *testing.T) {
 	// Since processLabelValue is a private method that modifies a LookupResult,
 	// we'll test the pattern matching logic instead
-	
+
 	tests := []struct {
 		label       string
 		value       string
@@ -96,12 +106,12 @@ func TestLDDBScraper_processLabelValue(t *testing.T) {
 		{"duration", "2:00", true},
 		{"invalid", "value", false},
 	}
-	
+
 	for _, tt := range tests {
 		// Test that the label contains expected keywords
 		label := strings.ToLower(tt.label)
 		actualMatch := false
-		
+
 		switch {
 		case strings.Contains(label, "title"):
 			actualMatch = true
@@ -120,14 +130,14 @@ func TestLDDBScraper_processLabelValue(t *testing.T) {
 		default:
 			actualMatch = false
 		}
-		
+
 		assert.Equal(t, tt.expectMatch, actualMatch, "Match expectation failed for label: %s", tt.label)
 	}
 }
 
 func TestLDDBScraper_LookupByUPC_InvalidUPC(t *testing.T) {
 	scraper := NewLDDBScraper()
-	
+
 	// Test with invalid UPC (no digits)
 	result, err := scraper.LookupByUPC("invalid")
 	assert.NoError(t, err) // No network error, but result should indicate failure
@@ -140,11 +150,11 @@ func TestLDDBScraper_LookupByUPC_ValidFormat(t *testing.T) {
 	// Test with valid UPC format (this won't actually hit the network in unit tests)
 	// We're just testing the UPC cleaning logic
 	upc := "123-456-7890"
-	
+
 	// The UPC should be cleaned to remove non-digits
-	// We can't easily test the network call without mocking, 
+	// We can't easily test the network call without mocking,
 	// so we'll test the UPC cleaning logic indirectly
-	
+
 	cleanUPC := strings.ReplaceAll(upc, "-", "")
 	assert.Equal(t, "1234567890", cleanUPC)
 }
@@ -163,7 +173,7 @@ func TestCleanUPC(t *testing.T) {
 		{"", ""},
 		{"abcdef", ""},
 	}
-	
+
 	for _, tt := range tests {
 		// Simulate the UPC cleaning logic from the scraper
 		result := ""
@@ -187,7 +197,7 @@ func TestHTMLExtractionPatterns(t *testing.T) {
 		<tr><td>Runtime:</td><td>121 min</td></tr>
 	</table>
 	`
-	
+
 	// Test that we can find expected patterns
 	assert.Contains(t, testHTML, "Title:")
 	assert.Contains(t, testHTML, "Star Wars")
@@ -198,16 +208,457 @@ func TestHTMLExtractionPatterns(t *testing.T) {
 // Test error handling for network issues (mock)
 func TestLDDBScraper_ErrorHandling(t *testing.T) {
 	scraper := NewLDDBScraper()
-	
+
 	// Test with empty UPC
 	result, err := scraper.LookupByUPC("")
 	assert.NoError(t, err)
 	assert.False(t, result.Found)
 	assert.Contains(t, result.Error, "Invalid UPC format")
-	
+
 	// Test with only spaces
 	result, err = scraper.LookupByUPC("   ")
 	assert.NoError(t, err)
 	assert.False(t, result.Found)
 	assert.Contains(t, result.Error, "Invalid UPC format")
-}
\ No newline at end of file
+}
+
+// newSyntheticServer generates a minimal LDDB search and detail page for
+// any UPC, so parallel lookups each get distinct content.
+func newSyntheticServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search.php", func(w http.ResponseWriter, r *http.Request) {
+		upc := r.URL.Query().Get("UPC")
+		fmt.Fprintf(w, `<html><body><a href="/laserdisc/%s/REF-%s/Title-%s">Title %s</a></body></html>`,
+			upc, upc, upc, upc)
+	})
+	mux.HandleFunc("/laserdisc/", func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(r.URL.Path, "/")
+		id := parts[2]
+		fmt.Fprintf(w, `<html><body><h2 class="lddb">Title %s (1990) [REF-%s]</h2>
+<table><tr><td class="field">Sides:&nbsp;</td><td class="data">2</td></tr></table>
+</body></html>`, id, id)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestLDDBScraper_LookupByUPC_Synthetic(t *testing.T) {
+	server := newSyntheticServer(t)
+	scraper := NewLDDBScraper(WithBaseURL(server.URL), WithRateLimit(0))
+
+	result, err := scraper.LookupByUPC("123456")
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+	assert.Equal(t, "Title 123456", result.Title)
+	assert.Equal(t, 1990, result.Year)
+	assert.Equal(t, 2, result.Sides)
+	assert.Contains(t, result.LDDBUrl, "/laserdisc/123456/")
+}
+
+// Run with -race: each lookup must only ever see its own pages.
+func TestLDDBScraper_ConcurrentLookups(t *testing.T) {
+	server := newSyntheticServer(t)
+	scraper := NewLDDBScraper(WithBaseURL(server.URL), WithRateLimit(0))
+
+	const lookups = 20
+	results := make([]*models.LookupResult, lookups)
+
+	var wg sync.WaitGroup
+	for i := 0; i < lookups; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			result, err := scraper.LookupByUPC(strconv.Itoa(100000 + i))
+			assert.NoError(t, err)
+			results[i] = result
+		}(i)
+	}
+	wg.Wait()
+
+	for i, result := range results {
+		require.NotNil(t, result)
+		upc := strconv.Itoa(100000 + i)
+		assert.True(t, result.Found, "lookup %s not found", upc)
+		assert.Equal(t, upc, result.UPC)
+		assert.Equal(t, "Title "+upc, result.Title)
+		assert.Contains(t, result.LDDBUrl, "/laserdisc/"+upc+"/")
+	}
+
+	// Repeating a lookup must not pick up handlers from earlier calls
+	result, err := scraper.LookupByUPC("100003")
+	require.NoError(t, err)
+	assert.Equal(t, "Title 100003", result.Title)
+}
+
+func TestLDDBScraper_Fixture_LookupByUPC(t *testing.T) {
+	scraper, server := newFixtureScraper(t)
+
+	result, err := scraper.LookupByUPC("4988104006479")
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+	assert.Empty(t, result.Error)
+	assert.Equal(t, "Star Wars: The Empire Strikes Back", result.Title)
+	assert.Equal(t, 1980, result.Year)
+	assert.Equal(t, "Science Fiction", result.Genre)
+	assert.Equal(t, "CLV", result.Format)
+	assert.Equal(t, 2, result.Sides)
+	assert.Equal(t, 124, result.Runtime)
+	assert.Equal(t, server.URL+"/cover/ld/31701-31800/thumb/31738.jpg", result.CoverImageURL)
+	assert.Equal(t, server.URL+"/laserdisc/31738/SF098-1117/Star-Wars:-The-Empire-Strikes-Back", result.LDDBUrl)
+	assert.Contains(t, result.DetailHTML, `<h2 class="lddb">Star Wars: The Empire Strikes Back (1980) [SF098-1117]</h2>`)
+}
+
+func TestLDDBScraper_Fixture_LookupByReference(t *testing.T) {
+	scraper, _ := newFixtureScraper(t)
+
+	result, err := scraper.LookupByReference(" SF098-1117 ")
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+	assert.Equal(t, "Star Wars: The Empire Strikes Back", result.Title)
+	assert.Equal(t, 1980, result.Year)
+}
+
+func TestLDDBScraper_Fixture_NoResults(t *testing.T) {
+	scraper, _ := newFixtureScraper(t)
+
+	result, err := scraper.LookupByUPC("111111111117")
+	require.NoError(t, err)
+	assert.False(t, result.Found)
+	assert.Empty(t, result.Title)
+	assert.Empty(t, result.LDDBUrl)
+}
+
+func TestLDDBScraper_Fixture_SearchPageError(t *testing.T) {
+	scraper, _ := newFixtureScraper(t)
+
+	// Not in the fixture routes, so the server responds 404
+	result, err := scraper.LookupByUPC("222222222222")
+	require.NoError(t, err)
+	assert.False(t, result.Found)
+	assert.Contains(t, result.Error, "Failed to fetch search results")
+}
+
+func TestLDDBScraper_Fixture_TextPatternFallback(t *testing.T) {
+	scraper, _ := newFixtureScraper(t)
+
+	// The listing has no detail link, so only the text fallback can match
+	result, err := scraper.LookupByReference("LV323503WS")
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+	assert.Equal(t, "Ghost and the Darkness, The", result.Title)
+	assert.Equal(t, 1996, result.Year)
+	assert.Empty(t, result.LDDBUrl)
+}
+
+func TestLDDBScraper_Fixture_getDetailedInfo(t *testing.T) {
+	scraper, server := newFixtureScraper(t)
+
+	// Fields already set by the search page are not overwritten
+	result := &models.LookupResult{Title: "Empire"}
+	err := scraper.getDetailedInfo(server.URL+"/laserdisc/31738/SF098-1117/Star-Wars:-The-Empire-Strikes-Back", result, nil)
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+	assert.Equal(t, "Empire", result.Title)
+	assert.Equal(t, "Science Fiction", result.Genre)
+	assert.Equal(t, 124, result.Runtime)
+	assert.Equal(t, 2, result.Sides)
+}
+
+// recordingTransport records the User-Agent of every request it forwards
+type recordingTransport struct {
+	mu         sync.Mutex
+	userAgents []string
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	rt.userAgents = append(rt.userAgents, req.Header.Get("User-Agent"))
+	rt.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestLDDBScraper_Options(t *testing.T) {
+	server := newFixtureServer(t, lddbFixtureRoutes)
+	transport := &recordingTransport{}
+
+	scraper := NewLDDBScraper(
+		WithBaseURL(server.URL+"/"),
+		WithTransport(transport),
+		WithUserAgent("lddb-test/1.0"),
+		WithRateLimit(0),
+	)
+	assert.Equal(t, server.URL, scraper.baseURL)
+
+	result, err := scraper.LookupByUPC("4988104006479")
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+
+	// One search page plus one detail page, both through the transport
+	assert.Equal(t, []string{"lddb-test/1.0", "lddb-test/1.0"}, transport.userAgents)
+}
+
+func TestLDDBScraper_Fixture_PressingDetails(t *testing.T) {
+	scraper, _ := newFixtureScraper(t)
+
+	result, err := scraper.LookupByUPC("4988104006479")
+	require.NoError(t, err)
+	assert.Equal(t, "Irvin Kershner", result.Director)
+	assert.Equal(t, "SF098-1117", result.Reference)
+	assert.Equal(t, "Fox Video", result.Label)
+	assert.Equal(t, "1987-12-10", result.ReleaseDate)
+	assert.Equal(t, "Japan", result.Country)
+	assert.Equal(t, "NTSC", result.VideoStandard)
+	assert.Equal(t, "LBX", result.PictureFormat)
+	assert.Equal(t, "2.20:1", result.AspectRatio)
+	assert.Equal(t, "Digital/Analog", result.Sound)
+	assert.Equal(t, 27, result.Chapters)
+	assert.Equal(t, "¥9,800", result.Price)
+	assert.Equal(t, "CLV/CLV", result.DiscModes)
+	assert.Equal(t, "Mark Hamill, Harrison Ford, Carrie Fisher", result.Cast)
+	assert.Equal(t, "Gary Kurtz", result.Producer)
+}
+
+func TestLDDBScraper_Fixture_PressingDetails_TextVariants(t *testing.T) {
+	scraper, server := newFixtureScraper(t)
+
+	result := &models.LookupResult{}
+	err := scraper.getDetailedInfo(server.URL+"/laserdisc/12345/PLFEB-30581/Terminator-2:-Judgment-Day", result, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Terminator 2: Judgment Day", result.Title)
+	assert.Equal(t, "James Cameron", result.Director)
+	assert.Equal(t, "PLFEB 30581", result.Reference)
+	assert.Equal(t, "Pioneer", result.Label)
+	assert.Equal(t, "United Kingdom", result.Country)
+	assert.Equal(t, "PAL", result.VideoStandard)
+	assert.Equal(t, "P&S", result.PictureFormat)
+	assert.Equal(t, "1.33:1", result.AspectRatio)
+	assert.Equal(t, "AC3/DTS/Analog", result.Sound)
+	assert.Equal(t, 36, result.Chapters)
+	assert.Equal(t, "CAV/CAV/CLV", result.DiscModes)
+	assert.Equal(t, 3, result.Sides)
+	assert.Equal(t, "Arnold Schwarzenegger, Linda Hamilton", result.Cast)
+}
+
+func TestParsePictureFormat(t *testing.T) {
+	tests := []struct {
+		input       string
+		format      string
+		aspectRatio string
+	}{
+		{"Letterbox 2.35:1", "LBX", "2.35:1"},
+		{"LBX 1.85 : 1", "LBX", "1.85:1"},
+		{"Widescreen", "LBX", ""},
+		{"Pan & Scan", "P&S", ""},
+		{"Standard 1.33:1", "P&S", "1.33:1"},
+		{"2.40:1", "", "2.40:1"},
+		{"Matted", "Matted", ""},
+	}
+
+	for _, tt := range tests {
+		format, aspectRatio := parsePictureFormat(tt.input)
+		assert.Equal(t, tt.format, format, "Wrong format for input: %s", tt.input)
+		assert.Equal(t, tt.aspectRatio, aspectRatio, "Wrong aspect ratio for input: %s", tt.input)
+	}
+}
+
+func TestParseSound(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Digital Stereo / Analog Stereo", "Digital/Analog"},
+		{"Dolby Digital 5.1", "AC3"},
+		{"AC-3 / Digital / Analog", "AC3/Digital/Analog"},
+		{"DTS", "DTS"},
+		{"Analog Mono", "Analog"},
+		{"Mono", "Mono"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, parseSound(tt.input), "Wrong sound for input: %s", tt.input)
+	}
+}
+
+func TestParseVideoStandard(t *testing.T) {
+	assert.Equal(t, "NTSC", parseVideoStandard("NTSC"))
+	assert.Equal(t, "PAL", parseVideoStandard("pal (625 lines)"))
+	assert.Equal(t, "MUSE", parseVideoStandard("MUSE"))
+}
+
+func TestLDDBScraper_Fixture_MultipleCandidates(t *testing.T) {
+	scraper, server := newFixtureScraper(t)
+
+	result, err := scraper.LookupByUPC("085391163824")
+	require.NoError(t, err)
+	assert.False(t, result.Found)
+	assert.Empty(t, result.Title)
+	assert.Empty(t, result.LDDBUrl)
+
+	// Duplicate links to the same disc are listed once
+	require.Len(t, result.Candidates, 3)
+
+	first := result.Candidates[0]
+	assert.Equal(t, 42, first.LDDBID)
+	assert.Equal(t, "Blade Runner", first.Title)
+	assert.Equal(t, 1982, first.Year)
+	assert.Equal(t, "1478-80", first.Reference)
+	assert.Equal(t, "USA", first.Country)
+	assert.Equal(t, server.URL+"/cover/ld/1-100/thumb/42.jpg", first.ThumbnailURL)
+	assert.Equal(t, server.URL+"/laserdisc/42/1478-80/Blade-Runner", first.LDDBUrl)
+
+	assert.Equal(t, "Blade Runner: The Director's Cut", result.Candidates[1].Title)
+
+	// No cover in the row, so the thumbnail URL is built from the ID
+	third := result.Candidates[2]
+	assert.Equal(t, "Blade Runner", third.Title)
+	assert.Equal(t, 1982, third.Year)
+	assert.Equal(t, "Japan", third.Country)
+	assert.Equal(t, server.URL+"/cover/ld/7001-7100/thumb/7007.jpg", third.ThumbnailURL)
+}
+
+func TestLDDBScraper_Fixture_SingleCandidateFollowsLink(t *testing.T) {
+	scraper, _ := newFixtureScraper(t)
+
+	result, err := scraper.LookupByUPC("4988104006479")
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+	assert.Empty(t, result.Candidates)
+	assert.Equal(t, 31738, result.LDDBID)
+}
+
+func TestLDDBScraper_Fixture_LookupByID(t *testing.T) {
+	scraper, server := newFixtureScraper(t)
+
+	result, err := scraper.LookupByID(31738)
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+	assert.Equal(t, 31738, result.LDDBID)
+	assert.Equal(t, "Star Wars: The Empire Strikes Back", result.Title)
+	assert.Equal(t, "4988104006479", result.UPC)
+	assert.Equal(t, server.URL+"/laserdisc/31738/", result.LDDBUrl)
+	assert.Equal(t, 1, result.Diagnostics.Requests)
+
+	// Unknown IDs report the fetch failure
+	result, err = scraper.LookupByID(99999)
+	require.NoError(t, err)
+	assert.False(t, result.Found)
+	assert.Contains(t, result.Error, "Failed to fetch detail page")
+
+	result, err = scraper.LookupByID(0)
+	require.NoError(t, err)
+	assert.Equal(t, "Invalid LDDB ID", result.Error)
+}
+
+func TestSplitTitleYear(t *testing.T) {
+	title, year := splitTitleYear("Blade Runner (1982)")
+	assert.Equal(t, "Blade Runner", title)
+	assert.Equal(t, 1982, year)
+
+	title, year = splitTitleYear("Blade Runner")
+	assert.Equal(t, "Blade Runner", title)
+	assert.Equal(t, 0, year)
+}
+
+func TestLDDBScraper_ParseLDDBID(t *testing.T) {
+	scraper := NewLDDBScraper()
+
+	tests := []struct {
+		input    string
+		expected int
+		hasError bool
+	}{
+		{"https://www.lddb.com/laserdisc/31738/SF098-1117/Star-Wars:-The-Empire-Strikes-Back", 31738, false},
+		{"http://lddb.com/laserdisc/31738/SF098-1117/Star-Wars", 31738, false},
+		{"www.lddb.com/laserdisc/31738/SF098-1117/Star-Wars?tab=cover#top", 31738, false},
+		{"  https://www.lddb.com/laserdisc/42/  ", 42, false},
+		{"https://www.lddb.com/laserdisc/42", 42, false},
+		{"https://www.lddb.com/search.php?UPC=123", 0, true},
+		{"https://www.lddb.com/laserdisc/abc/", 0, true},
+		{"https://example.com/laserdisc/31738/", 0, true},
+		{"not a url", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		id, err := scraper.ParseLDDBID(tt.input)
+		if tt.hasError {
+			assert.Error(t, err, "Expected error for input: %s", tt.input)
+		} else {
+			assert.NoError(t, err, "Unexpected error for input: %s", tt.input)
+			assert.Equal(t, tt.expected, id, "Wrong ID for input: %s", tt.input)
+		}
+	}
+}
+
+func TestLDDBScraper_Fixture_LookupByURL(t *testing.T) {
+	scraper, server := newFixtureScraper(t)
+
+	// A pasted lddb.com URL is fetched from the configured base URL
+	result, err := scraper.LookupByURL("https://www.lddb.com/laserdisc/31738/SF098-1117/Star-Wars:-The-Empire-Strikes-Back")
+	require.NoError(t, err)
+	assert.True(t, result.Found)
+	assert.Equal(t, 31738, result.LDDBID)
+	assert.Equal(t, "Star Wars: The Empire Strikes Back", result.Title)
+	assert.Equal(t, server.URL+"/laserdisc/31738/SF098-1117/Star-Wars:-The-Empire-Strikes-Back", result.LDDBUrl)
+
+	result, err = scraper.LookupByURL("https://example.com/laserdisc/31738/")
+	require.NoError(t, err)
+	assert.False(t, result.Found)
+	assert.Contains(t, result.Error, "Invalid LDDB URL")
+}
+
+func TestLDDBScraper_Fixture_LookupByTitle(t *testing.T) {
+	scraper, server := newFixtureScraper(t)
+
+	result, err := scraper.LookupByTitle("blade runner", TitleSearch{})
+	require.NoError(t, err)
+	assert.Empty(t, result.Error)
+	assert.Equal(t, 1, result.Page)
+	assert.True(t, result.HasMore)
+	require.Len(t, result.Candidates, 3)
+	assert.Equal(t, 42, result.Candidates[0].LDDBID)
+	assert.Equal(t, "Blade Runner", result.Candidates[0].Title)
+	assert.Equal(t, 1982, result.Candidates[0].Year)
+	assert.Equal(t, server.URL+"/laserdisc/42/1478-80/Blade-Runner", result.Candidates[0].LDDBUrl)
+	require.NotNil(t, result.Diagnostics)
+	assert.Equal(t, 1, result.Diagnostics.Requests)
+
+	// The last page has no link onwards
+	result, err = scraper.LookupByTitle("blade runner", TitleSearch{Page: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Page)
+	assert.False(t, result.HasMore)
+	require.Len(t, result.Candidates, 1)
+	assert.Equal(t, 9901, result.Candidates[0].LDDBID)
+}
+
+func TestLDDBScraper_Fixture_LookupByTitleFilters(t *testing.T) {
+	scraper, _ := newFixtureScraper(t)
+
+	// Filters are sent to LDDB and also applied to the listing it returns
+	result, err := scraper.LookupByTitle("blade runner", TitleSearch{Country: "japan"})
+	require.NoError(t, err)
+	assert.Empty(t, result.Error)
+	require.Len(t, result.Candidates, 1)
+	assert.Equal(t, 7007, result.Candidates[0].LDDBID)
+	assert.Equal(t, "Japan", result.Candidates[0].Country)
+}
+
+func TestLDDBScraper_Fixture_LookupByTitleNoResults(t *testing.T) {
+	scraper, _ := newFixtureScraper(t)
+
+	result, err := scraper.LookupByTitle("zzzz", TitleSearch{})
+	require.NoError(t, err)
+	assert.Empty(t, result.Error)
+	assert.NotNil(t, result.Candidates)
+	assert.Empty(t, result.Candidates)
+	assert.False(t, result.HasMore)
+
+	result, err = scraper.LookupByTitle("   ", TitleSearch{})
+	require.NoError(t, err)
+	assert.Equal(t, "Invalid search query", result.Error)
+}
@@ -0,0 +1,198 @@
+package scraper
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/paran01d/lddb/internal/models"
+)
+
+// lookupStats counts the requests made on behalf of a single lookup
+type lookupStats struct {
+	requests     atomic.Int32
+	retries      atomic.Int32
+	throttled    atomic.Int32
+	throttleWait atomic.Int64 // nanoseconds
+}
+
+// diagnostics converts the counters into the form reported with a LookupResult
+func (st *lookupStats) diagnostics() *models.LookupDiagnostics {
+	return &models.LookupDiagnostics{
+		Requests:       int(st.requests.Load()),
+		Retries:        int(st.retries.Load()),
+		Throttled:      int(st.throttled.Load()),
+		ThrottleWaitMS: time.Duration(st.throttleWait.Load()).Milliseconds(),
+	}
+}
+
+// lookupStatsKey is the request context key holding a lookup's *lookupStats
+type lookupStatsKey struct{}
+
+// withLookupStats attaches stats to a context so the transport can count into them
+func withLookupStats(ctx context.Context, stats *lookupStats) context.Context {
+	return context.WithValue(ctx, lookupStatsKey{}, stats)
+}
+
+// politeTransport wraps another RoundTripper with a per-host request interval
+// and bounded retries using jittered exponential backoff. Requests are
+// retried on 5xx responses and timeouts only, and never after the caller's
+// context is done.
+type politeTransport struct {
+	base           http.RoundTripper
+	interval       time.Duration // minimum gap between requests to one host
+	maxRetries     int
+	backoff        time.Duration // delay before the first retry, doubled each time
+	attemptTimeout time.Duration // per attempt, 0 for none
+
+	mu   sync.Mutex
+	next map[string]time.Time // host -> earliest time the next request may start
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *politeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	stats, _ := req.Context().Value(lookupStatsKey{}).(*lookupStats)
+
+	for attempt := 0; ; attempt++ {
+		if err := t.throttle(req.Context(), req.URL.Host, stats); err != nil {
+			return nil, err
+		}
+		if stats != nil {
+			stats.requests.Add(1)
+		}
+
+		resp, err := t.attempt(req)
+		if attempt >= t.maxRetries || !t.shouldRetry(req.Context(), resp, err) {
+			return resp, err
+		}
+
+		if resp != nil {
+			log.Printf("Retrying %s after HTTP %d (attempt %d of %d)", req.URL, resp.StatusCode, attempt+1, t.maxRetries)
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		} else {
+			log.Printf("Retrying %s after error: %v (attempt %d of %d)", req.URL, err, attempt+1, t.maxRetries)
+		}
+		if stats != nil {
+			stats.retries.Add(1)
+		}
+
+		if err := sleepContext(req.Context(), t.backoffDelay(attempt)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// attempt sends the request once, bounded by the per-attempt timeout. The
+// timeout stays armed until the response body is closed.
+func (t *politeTransport) attempt(req *http.Request) (*http.Response, error) {
+	if t.attemptTimeout <= 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.attemptTimeout)
+	resp, err := t.base.RoundTrip(req.Clone(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// shouldRetry reports whether a failed attempt is worth repeating
+func (t *politeTransport) shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		var netErr net.Error
+		return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+	}
+	return resp.StatusCode >= 500
+}
+
+// backoffDelay returns the jittered delay before retry number attempt+1,
+// somewhere between half and all of backoff * 2^attempt
+func (t *politeTransport) backoffDelay(attempt int) time.Duration {
+	delay := t.backoff << attempt
+	if delay <= 0 {
+		return 0
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// throttle waits until the host's next request slot and reserves the one after it
+func (t *politeTransport) throttle(ctx context.Context, host string, stats *lookupStats) error {
+	if t.interval <= 0 {
+		return nil
+	}
+
+	t.mu.Lock()
+	now := time.Now()
+	start := t.next[host]
+	if start.Before(now) {
+		start = now
+	}
+	t.next[host] = start.Add(t.interval)
+	t.mu.Unlock()
+
+	wait := start.Sub(now)
+	if wait <= 0 {
+		return nil
+	}
+	if stats != nil {
+		stats.throttled.Add(1)
+		stats.throttleWait.Add(int64(wait))
+	}
+	return sleepContext(ctx, wait)
+}
+
+// sleepContext sleeps for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// cancelOnClose releases an attempt's timeout once its body has been read
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body and cancels the attempt context
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// userAgentTransport sets the User-Agent on requests made outside colly
+type userAgentTransport struct {
+	base      http.RoundTripper
+	userAgent string
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+	return t.base.RoundTrip(req)
+}
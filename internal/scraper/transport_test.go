@@ -0,0 +1,196 @@
+package scraper
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestTransport returns a politeTransport with short test-friendly delays
+func newTestTransport(interval time.Duration, maxRetries int) *politeTransport {
+	return &politeTransport{
+		base:           http.DefaultTransport,
+		interval:       interval,
+		maxRetries:     maxRetries,
+		backoff:        time.Millisecond,
+		attemptTimeout: time.Second,
+		next:           make(map[string]time.Time),
+	}
+}
+
+// doRequest sends a GET through the transport with stats attached
+func doRequest(t *testing.T, transport http.RoundTripper, url string, stats *lookupStats) (*http.Response, error) {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(withLookupStats(context.Background(), stats), http.MethodGet, url, nil)
+	require.NoError(t, err)
+	return transport.RoundTrip(req)
+}
+
+func TestPoliteTransport_RetriesServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	stats := &lookupStats{}
+	resp, err := doRequest(t, newTestTransport(0, 3), server.URL, stats)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+
+	diagnostics := stats.diagnostics()
+	assert.Equal(t, 3, diagnostics.Requests)
+	assert.Equal(t, 2, diagnostics.Retries)
+}
+
+func TestPoliteTransport_GivesUpAfterMaxRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	stats := &lookupStats{}
+	resp, err := doRequest(t, newTestTransport(0, 2), server.URL, stats)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// The last failure is handed back to the caller
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Equal(t, 2, stats.diagnostics().Retries)
+}
+
+func TestPoliteTransport_NoRetryOnClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	resp, err := doRequest(t, newTestTransport(0, 3), server.URL, &lookupStats{})
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestPoliteTransport_RetriesTimeouts(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	transport := newTestTransport(0, 1)
+	transport.attemptTimeout = 50 * time.Millisecond
+
+	stats := &lookupStats{}
+	resp, err := doRequest(t, transport, server.URL, stats)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1, stats.diagnostics().Retries)
+}
+
+func TestPoliteTransport_RateLimitsPerHost(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	transport := newTestTransport(50*time.Millisecond, 0)
+	stats := &lookupStats{}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		resp, err := doRequest(t, transport, server.URL, stats)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	// The first request goes straight through, the next two wait their turn
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	diagnostics := stats.diagnostics()
+	assert.Equal(t, 3, diagnostics.Requests)
+	assert.Equal(t, 2, diagnostics.Throttled)
+	assert.Greater(t, diagnostics.ThrottleWaitMS, int64(0))
+}
+
+func TestPoliteTransport_backoffDelay(t *testing.T) {
+	transport := &politeTransport{backoff: 100 * time.Millisecond}
+
+	for attempt := 0; attempt < 4; attempt++ {
+		full := 100 * time.Millisecond << attempt
+		delay := transport.backoffDelay(attempt)
+		assert.GreaterOrEqual(t, delay, full/2)
+		assert.LessOrEqual(t, delay, full)
+	}
+}
+
+func TestLDDBScraper_RobotsTxt(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: *\nDisallow: /search.php\n"))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	scraper := NewLDDBScraper(WithBaseURL(server.URL), WithRateLimit(0), WithRobotsTxt(true))
+	result, err := scraper.LookupByUPC("4988104006479")
+	require.NoError(t, err)
+	assert.False(t, result.Found)
+	assert.Contains(t, result.Error, "robots.txt")
+}
+
+func TestLDDBScraper_LookupDiagnostics(t *testing.T) {
+	scraper, _ := newFixtureScraper(t)
+
+	result, err := scraper.LookupByUPC("4988104006479")
+	require.NoError(t, err)
+	require.NotNil(t, result.Diagnostics)
+
+	// One search page and one detail page
+	assert.Equal(t, 2, result.Diagnostics.Requests)
+	assert.Equal(t, 0, result.Diagnostics.Retries)
+}
+
+func TestLDDBScraper_HTTPClient(t *testing.T) {
+	var userAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.UserAgent()
+		w.Write([]byte("image"))
+	}))
+	defer server.Close()
+
+	scraper := NewLDDBScraper(WithUserAgent("test-agent/1.0"), WithRateLimit(0))
+	resp, err := scraper.HTTPClient().Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "test-agent/1.0", userAgent)
+}
@@ -68,6 +68,13 @@ echo "📥 Restoring database..."
 # Copy backup file to container volume
 docker cp "$BACKUP_FILE" "${CONTAINER_NAME}:/app/data/collection.db"
 
+# Restore the photos backed up alongside the database, if any
+IMAGES_BACKUP="${BACKUP_FILE%.db}_images"
+if [ -d "$IMAGES_BACKUP" ]; then
+    echo "📥 Restoring photos..."
+    docker cp "$IMAGES_BACKUP/." "${CONTAINER_NAME}:/app/data/images"
+fi
+
 echo "🚀 Starting container..."
 docker compose start lddb
 
@@ -8,6 +8,9 @@ class CollectionManager {
         this.sortBy = 'title';
         this.sortOrder = 'asc';
         this.filterWatched = 'all'; // all, watched, unwatched
+        this.filterTag = ''; // only LaserDiscs with this tag, when set
+        this.didYouMean = []; // titles suggested for a search that found nothing
+        this.fuzzy = false; // whether the last search matched titles loosely
     }
 
     // Load collection with advanced filtering and sorting
@@ -18,7 +21,8 @@ class CollectionManager {
             limit = this.itemsPerPage,
             sortBy = this.sortBy,
             sortOrder = this.sortOrder,
-            filterWatched = this.filterWatched
+            filterWatched = this.filterWatched,
+            fuzzy = false
         } = options;
 
         try {
@@ -31,37 +35,29 @@ class CollectionManager {
 
             if (search) {
                 params.append('search', search);
+                if (fuzzy) {
+                    params.append('fuzzy', true);
+                }
             }
 
-            const data = await apiCall(`/collection?${params}`);
-            let laserdiscs = data.laserdiscs || [];
-            
-            // Client-side filtering for watched status
-            if (filterWatched === 'watched') {
-                laserdiscs = laserdiscs.filter(ld => ld.watched);
-            } else if (filterWatched === 'unwatched') {
-                laserdiscs = laserdiscs.filter(ld => !ld.watched);
+            // The server filters and sorts, so every page is in order.
+            // Searches are ranked by relevance unless sorted otherwise.
+            if (sortBy !== 'relevance') {
+                params.append('sort', sortBy);
+            }
+            params.append('order', sortOrder);
+            if (filterWatched !== 'all') {
+                params.append('watched', filterWatched === 'watched');
+            }
+            if (this.filterTag) {
+                params.append('tag', this.filterTag);
             }
 
-            // Client-side sorting
-            laserdiscs.sort((a, b) => {
-                let aVal = a[sortBy] || '';
-                let bVal = b[sortBy] || '';
-                
-                if (typeof aVal === 'string') {
-                    aVal = aVal.toLowerCase();
-                    bVal = bVal.toLowerCase();
-                }
-
-                if (sortOrder === 'desc') {
-                    return bVal < aVal ? -1 : bVal > aVal ? 1 : 0;
-                } else {
-                    return aVal < bVal ? -1 : aVal > bVal ? 1 : 0;
-                }
-            });
-
-            collection = laserdiscs;
+            const data = await apiCall(`/collection?${params}`);
+            collection = data.laserdiscs || [];
             currentOffset = offset;
+            this.didYouMean = data.did_you_mean || [];
+            this.fuzzy = fuzzy;
 
             updateStats(data.stats);
             this.renderCollection();
@@ -79,9 +75,23 @@ class CollectionManager {
                 <div class="empty-state">
                     <h3>📀 No LaserDiscs found</h3>
                     <p>${currentSearch ? 'Try a different search term' : 'Start by scanning a barcode or adding a LaserDisc manually'}</p>
+                    ${this.didYouMean.length > 0 ? `<p class="did-you-mean">Did you mean ${this.didYouMean.map((title, i) =>
+                        `<button type="button" class="link-btn" data-index="${i}">${escapeHtml(title)}</button>`).join(', ')}?</p>` : ''}
+                    ${currentSearch && !this.fuzzy ? `<button type="button" class="secondary-btn" id="fuzzy-search-btn">Search titles loosely</button>` : ''}
                     <button class="primary-btn" onclick="openModal('scan')">Scan Your First LaserDisc</button>
                 </div>
             `;
+            elements.collection.querySelectorAll('.did-you-mean button').forEach(button => {
+                const title = this.didYouMean[button.dataset.index];
+                button.addEventListener('click', () => {
+                    elements.inputs.search.value = title;
+                    loadCollection(title, 0);
+                });
+            });
+            const fuzzyButton = document.getElementById('fuzzy-search-btn');
+            if (fuzzyButton) {
+                fuzzyButton.addEventListener('click', () => this.loadCollection({ fuzzy: true }));
+            }
             return;
         }
 
@@ -115,15 +125,20 @@ class CollectionManager {
                         <option value="unwatched">Unwatched</option>
                         <option value="watched">Watched</option>
                     </select>
+                    ${this.filterTag ? `<button type="button" class="tag-chip active-tag" onclick="collectionManager.updateTag('')" title="Show every tag">${escapeHtml(this.filterTag)} ✕</button>` : ''}
                 </div>
                 
                 <div class="sort-group">
                     <label>Sort by:</label>
                     <select id="sort-by" onchange="collectionManager.updateSort(this.value)">
+                        <option value="relevance">Relevance</option>
                         <option value="title">Title</option>
                         <option value="year">Year</option>
                         <option value="director">Director</option>
+                        <option value="runtime">Runtime</option>
                         <option value="added_date">Date Added</option>
+                        <option value="updated_date">Last Updated</option>
+                        <option value="tags">Tags</option>
                     </select>
                     <button class="sort-order-btn" onclick="collectionManager.toggleSortOrder()" title="Toggle sort order">
                         ${this.sortOrder === 'asc' ? '▲' : '▼'}
@@ -133,6 +148,7 @@ class CollectionManager {
                 <div class="view-group">
                     <button class="view-btn active" onclick="collectionManager.setView('grid')" title="Grid view">⊞</button>
                     <button class="view-btn" onclick="collectionManager.setView('list')" title="List view">☰</button>
+                    <a class="view-btn" href="${this.exportUrl('csv')}" title="Export these LaserDiscs as CSV">⤓</a>
                 </div>
             </div>
         `;
@@ -166,11 +182,11 @@ class CollectionManager {
             <div class="card-header">
                 ${laserdisc.cover_image_url && laserdisc.cover_image_url !== 'https://www.lddb.com/images/visual/loading.gif' ? 
                     `<div class="card-cover">
-                        <img src="${laserdisc.cover_image_url}" alt="${escapeHtml(laserdisc.title)} cover" loading="lazy" 
+                        <img src="${coverSrc(laserdisc.id, 'medium')}" alt="${escapeHtml(laserdisc.title)} cover" loading="lazy" 
                              onerror="this.style.display='none'; this.parentNode.classList.add('no-image')">
                     </div>` : ''}
                 <div class="card-header-content">
-                    <h3>${escapeHtml(laserdisc.title)}</h3>
+                    <h3>${laserdisc.match ? laserdisc.match.title : escapeHtml(laserdisc.title)}</h3>
                     <span class="watched-status ${watchedClass}" title="${watchedText}">
                         ${watchedIcon}
                     </span>
@@ -180,14 +196,19 @@ class CollectionManager {
             <div class="card-body">
                 <div class="card-info">
                     <p><strong>Year:</strong> ${laserdisc.year || 'Unknown'}</p>
-                    <p><strong>UPC:</strong> <code>${laserdisc.upc}</code></p>
+                    ${laserdisc.upc ? `<p><strong>UPC:</strong> <code>${laserdisc.upc}</code></p>` : ''}
                     ${laserdisc.director ? `<p><strong>Director:</strong> ${escapeHtml(laserdisc.director)}</p>` : ''}
                     ${laserdisc.genre ? `<p><strong>Category:</strong> ${escapeHtml(laserdisc.genre)}</p>` : ''}
                     ${laserdisc.format ? `<p><strong>Format:</strong> <span class="format-badge">${laserdisc.format}</span></p>` : ''}
                     ${laserdisc.runtime ? `<p><strong>Runtime:</strong> ${laserdisc.runtime} min</p>` : ''}
                     ${laserdisc.sides ? `<p><strong>Sides:</strong> ${laserdisc.sides}</p>` : ''}
+                    ${this.copiesSummary(laserdisc.copies)}
+                    ${this.customFieldsSummary(laserdisc.custom_fields)}
                 </div>
+                ${this.tagChips(laserdisc.tags)}
                 
+                ${laserdisc.match && laserdisc.match.snippet && laserdisc.match.snippet !== laserdisc.match.title ? `<div class="card-match">${laserdisc.match.snippet}</div>` : ''}
+
                 ${laserdisc.notes ? `<div class="card-notes"><strong>Notes:</strong> ${escapeHtml(laserdisc.notes)}</div>` : ''}
                 
                 <div class="card-meta">
@@ -210,6 +231,64 @@ class CollectionManager {
         return card;
     }
 
+    // Summarise the copies owned, e.g. "2 (sealed, NM · VG+/VG · rot suspected)"
+    copiesSummary(copies) {
+        if (!copies || copies.length === 0) {
+            return '';
+        }
+        const details = copies.map(copy => {
+            const parts = [];
+            if (copy.sealed) parts.push('sealed');
+            if (copy.sleeve_grade) parts.push(escapeHtml(copy.sleeve_grade));
+            if (copy.side_grades) parts.push(escapeHtml(copy.side_grades));
+            if (copy.rot_status && copy.rot_status !== 'unknown' && copy.rot_status !== 'none') {
+                parts.push(`rot ${escapeHtml(copy.rot_status)}`);
+            }
+            return parts.join(' · ');
+        }).filter(detail => detail);
+        return `<p><strong>Copies:</strong> ${copies.length}${details.length ? ` (${details.join(', ')})` : ''}</p>`;
+    }
+
+    // Show the custom fields set, one per line
+    customFieldsSummary(fields) {
+        return Object.entries(fields || {}).map(([name, value]) =>
+            `<p><strong>${escapeHtml(name)}:</strong> ${escapeHtml(String(value))}</p>`).join('');
+    }
+
+    // Show tags in their colours, each filtering the collection by it
+    tagChips(tags) {
+        if (!tags || tags.length === 0) {
+            return '';
+        }
+        return `<div class="card-tags">${tags.map(tag =>
+            `<button type="button" class="tag-chip" style="background: ${escapeHtml(tag.color)}" onclick="collectionManager.updateTag(this.textContent)">${escapeHtml(tag.name)}</button>`).join('')}</div>`;
+    }
+
+    // Link to an export of the LaserDiscs shown, with every page
+    exportUrl(format) {
+        const params = new URLSearchParams({ token: localStorage.getItem('lddb_token') || '' });
+        if (currentSearch) {
+            params.append('search', currentSearch);
+        }
+        if (this.sortBy !== 'relevance') {
+            params.append('sort', this.sortBy);
+        }
+        params.append('order', this.sortOrder);
+        if (this.filterWatched !== 'all') {
+            params.append('watched', this.filterWatched === 'watched');
+        }
+        if (this.filterTag) {
+            params.append('tag', this.filterTag);
+        }
+        return `/api/export/${format}?${params}`;
+    }
+
+    // Update tag filter
+    updateTag(tag) {
+        this.filterTag = tag;
+        this.loadCollection();
+    }
+
     // Update filter
     updateFilter(filter) {
         this.filterWatched = filter;
@@ -296,7 +375,7 @@ class BulkOperations {
     // Bulk mark as watched
     async markAllWatched() {
         const promises = Array.from(this.selectedItems).map(id => 
-            apiCall(`/collection/${id}/watched`, { method: 'POST' })
+            apiCall(`/collection/${id}/watched?watched=true`, { method: 'POST' })
         );
         
         try {
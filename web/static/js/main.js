@@ -5,8 +5,16 @@ const API_BASE = window.location.origin + '/api';
 let collection = [];
 let currentSearch = '';
 let currentOffset = 0;
+let pendingLookup = null; // last lookup result, supplies fields the add form doesn't show
 const LIMIT = 20;
 
+// Lookup fields saved with a new LaserDisc without appearing in the add form
+const PRESSING_FIELDS = [
+    'lddb_url', 'reference', 'label', 'release_date', 'country', 'video_standard',
+    'picture_format', 'aspect_ratio', 'sound', 'chapters', 'price', 'disc_modes',
+    'cast', 'producer'
+];
+
 // DOM elements
 const elements = {
     stats: {
@@ -24,8 +32,12 @@ const elements = {
         search: document.getElementById('search-input'),
         manualUpc: document.getElementById('manual-upc'),
         manualReference: document.getElementById('manual-reference'),
+        manualLddb: document.getElementById('manual-lddb'),
+        manualTitle: document.getElementById('manual-title'),
         lookupUpcBtn: document.getElementById('lookup-upc-btn'),
-        lookupRefBtn: document.getElementById('lookup-ref-btn')
+        lookupRefBtn: document.getElementById('lookup-ref-btn'),
+        lookupLddbBtn: document.getElementById('lookup-lddb-btn'),
+        lookupTitleBtn: document.getElementById('lookup-title-btn')
     },
     modals: {
         scan: document.getElementById('scan-modal'),
@@ -140,6 +152,8 @@ function initializeEventListeners() {
     // UPC and Reference lookup
     elements.inputs.lookupUpcBtn.addEventListener('click', lookupUPC);
     elements.inputs.lookupRefBtn.addEventListener('click', lookupReference);
+    elements.inputs.lookupLddbBtn.addEventListener('click', lookupLDDB);
+    elements.inputs.lookupTitleBtn.addEventListener('click', () => searchTitle(1));
     
     elements.inputs.manualUpc.addEventListener('keypress', function(e) {
         if (e.key === 'Enter') {
@@ -153,12 +167,31 @@ function initializeEventListeners() {
         }
     });
 
+    elements.inputs.manualLddb.addEventListener('keypress', function(e) {
+        if (e.key === 'Enter') {
+            lookupLDDB();
+        }
+    });
+
+    elements.inputs.manualTitle.addEventListener('keypress', function(e) {
+        if (e.key === 'Enter') {
+            searchTitle(1);
+        }
+    });
+
     // Add form submission
     elements.addForm.addEventListener('submit', handleAddLaserDisc);
     
     // Edit form submission
     elements.editForm.addEventListener('submit', handleEditLaserDisc);
 
+    // Complete directors and genres from the collection
+    ['form', 'edit'].forEach(form => {
+        setupValueSuggestions(document.getElementById(`${form}-director`), 'director');
+        setupValueSuggestions(document.getElementById(`${form}-genre`), 'genre');
+    });
+    document.getElementById('photo-upload').addEventListener('change', uploadPhotos);
+
     // Close modals when clicking outside or on close button
     document.querySelectorAll('.close').forEach(closeBtn => {
         closeBtn.addEventListener('click', closeModals);
@@ -180,6 +213,10 @@ function initializeEventListeners() {
 
 // API functions
 async function apiCall(endpoint, options = {}) {
+    // Statuses the caller handles itself, without an error notification
+    const { quiet = [], ...fetchOptions } = options;
+    options = fetchOptions;
+
     try {
         // Get token from localStorage
         const token = localStorage.getItem('lddb_token');
@@ -201,18 +238,59 @@ async function apiCall(endpoint, options = {}) {
         }
 
         if (!response.ok) {
-            const error = await response.json();
-            throw new Error(error.error || `HTTP ${response.status}`);
+            const data = await response.json();
+            const error = new Error(data.error || `HTTP ${response.status}`);
+            error.status = response.status;
+            error.data = data;
+            throw error;
         }
 
         return await response.json();
     } catch (error) {
+        if (quiet.includes(error.status)) {
+            throw error;
+        }
         console.error('API call failed:', error);
         showNotification(`Error: ${error.message}`, 'error');
         throw error;
     }
 }
 
+// Offer values already in the collection as a field is typed, so the same
+// director or genre isn't entered several different ways
+function setupValueSuggestions(input, field) {
+    const list = document.createElement('datalist');
+    list.id = `${input.id}-suggestions`;
+    input.after(list);
+    input.setAttribute('list', list.id);
+
+    let timer;
+    input.addEventListener('input', () => {
+        clearTimeout(timer);
+        timer = setTimeout(async () => {
+            const params = new URLSearchParams({ field, prefix: input.value.trim() });
+            const token = localStorage.getItem('lddb_token');
+            try {
+                // Fetched directly, as a failed suggestion isn't worth an error
+                const response = await fetch(`${API_BASE}/suggest?${params}`, {
+                    headers: { 'Authorization': token ? `Bearer ${token}` : '' }
+                });
+                if (!response.ok) {
+                    return;
+                }
+                const data = await response.json();
+                list.replaceChildren(...data.suggestions.map(suggestion => {
+                    const option = document.createElement('option');
+                    option.value = suggestion.value;
+                    return option;
+                }));
+            } catch (error) {
+                console.error('Suggestions failed:', error);
+            }
+        }, 200);
+    });
+}
+
 // Load collection from API
 async function loadCollection(search = '', offset = 0) {
     try {
@@ -277,7 +355,7 @@ function createLaserDiscCard(laserdisc) {
     card.innerHTML = `
         <h3>${escapeHtml(laserdisc.title)}</h3>
         <p><strong>Year:</strong> ${laserdisc.year || 'Unknown'}</p>
-        <p><strong>UPC:</strong> ${laserdisc.upc}</p>
+        ${laserdisc.upc ? `<p><strong>UPC:</strong> ${laserdisc.upc}</p>` : ''}
         ${laserdisc.director ? `<p><strong>Director:</strong> ${escapeHtml(laserdisc.director)}</p>` : ''}
         ${laserdisc.genre ? `<p><strong>Genre:</strong> ${escapeHtml(laserdisc.genre)}</p>` : ''}
         ${laserdisc.format ? `<p><strong>Format:</strong> ${laserdisc.format}</p>` : ''}
@@ -320,7 +398,9 @@ async function lookupUPC() {
         showNotification('Looking up LaserDisc by UPC...', 'info');
         const data = await apiCall(`/lookup/${encodeURIComponent(upc)}`);
 
-        if (data.result && data.result.found) {
+        if (data.candidates) {
+            showCandidates(data.candidates);
+        } else if (data.result && data.result.found) {
             // Pre-fill the add form with the lookup data
             populateAddForm(data.result);
             closeModals();
@@ -346,7 +426,9 @@ async function lookupReference() {
         showNotification('Looking up LaserDisc by reference...', 'info');
         const data = await apiCall(`/lookup/reference/${encodeURIComponent(reference)}`);
 
-        if (data.result && data.result.found) {
+        if (data.candidates) {
+            showCandidates(data.candidates);
+        } else if (data.result && data.result.found) {
             // Pre-fill the add form with the lookup data
             populateAddForm(data.result);
             closeModals();
@@ -360,8 +442,102 @@ async function lookupReference() {
     }
 }
 
+// List the LaserDiscs matching an ambiguous lookup so the user can pick one.
+// When more is given, a button loading the next page of results is appended.
+function showCandidates(candidates, more) {
+    const container = document.getElementById('lookup-candidates');
+    container.innerHTML = candidates.map(candidate => `
+        <button type="button" class="candidate" onclick="lookupLDDBID(${candidate.lddb_id})">
+            <img src="${escapeHtml(candidate.thumbnail_url)}" alt="" onerror="this.style.visibility='hidden'" />
+            <span class="candidate-info">
+                <strong>${escapeHtml(candidate.title)}${candidate.year ? ` (${candidate.year})` : ''}</strong>
+                <small>${escapeHtml([candidate.reference, candidate.country].filter(Boolean).join(' · '))}</small>
+            </span>
+        </button>
+    `).join('');
+    if (more) {
+        container.innerHTML += `<button type="button" class="secondary-btn candidates-more" onclick="searchTitle(${more})">More results</button>`;
+    }
+    container.style.display = 'block';
+    showNotification(`${candidates.length} LaserDiscs match, choose one`, 'info');
+}
+
+// Search LDDB by title for discs without a usable barcode. A trailing year,
+// e.g. "Blade Runner 1982", narrows the search to that year.
+async function searchTitle(page) {
+    const value = elements.inputs.manualTitle.value.trim();
+    if (!value) {
+        showNotification('Please enter a title to search for', 'error');
+        return;
+    }
+
+    const params = new URLSearchParams({ page: page });
+    const match = value.match(/^(.+?)\s+((?:19|20)\d{2})$/);
+    if (match) {
+        params.set('q', match[1]);
+        params.set('year', match[2]);
+    } else {
+        params.set('q', value);
+    }
+
+    try {
+        showNotification('Searching LDDB...', 'info');
+        const data = await apiCall(`/lookup/search?${params}`);
+
+        if (data.candidates.length > 0) {
+            showCandidates(data.candidates, data.has_more ? page + 1 : 0);
+        } else {
+            document.getElementById('lookup-candidates').style.display = 'none';
+            showNotification('No LaserDiscs found with that title', 'warning');
+        }
+    } catch (error) {
+        // Error already handled in apiCall
+    }
+}
+
+// Look up a pasted lddb.com URL or bare LDDB ID
+function lookupLDDB() {
+    const value = elements.inputs.manualLddb.value.trim();
+    if (!value) {
+        showNotification('Please enter an lddb.com URL or LDDB ID', 'error');
+        return;
+    }
+
+    if (/^\d+$/.test(value)) {
+        lookupLDDBID(value);
+    } else {
+        lookupLDDBDetail(`/lookup/url?url=${encodeURIComponent(value)}`);
+    }
+}
+
+// Look up one specific LDDB entry, e.g. a candidate picked from a list
+function lookupLDDBID(id) {
+    lookupLDDBDetail(`/lookup/lddb/${encodeURIComponent(id)}`);
+}
+
+// Fetch a single LDDB detail page and fill the add form from it
+async function lookupLDDBDetail(endpoint) {
+    try {
+        showNotification('Fetching LaserDisc details...', 'info');
+        const data = await apiCall(endpoint);
+
+        if (data.result && data.result.found) {
+            document.getElementById('lookup-candidates').style.display = 'none';
+            populateAddForm(data.result);
+            closeModals();
+            openModal('add');
+            showNotification('LaserDisc found! Review and add to collection.', 'success');
+        } else {
+            showNotification('LaserDisc not found in database', 'warning');
+        }
+    } catch (error) {
+        // Error already handled in apiCall
+    }
+}
+
 // Populate add form with lookup data
 function populateAddForm(data) {
+    pendingLookup = data;
     document.getElementById('form-upc').value = data.upc || '';
     document.getElementById('form-title').value = data.title || '';
     document.getElementById('form-year').value = data.year || '';
@@ -371,6 +547,7 @@ function populateAddForm(data) {
     document.getElementById('form-sides').value = data.sides || '';
     document.getElementById('form-runtime').value = data.runtime || '';
     document.getElementById('form-cover-url').value = data.cover_image_url || '';
+    showFieldSources(data.sources || {});
     
     // Show cover image preview if available
     const coverUrl = data.cover_image_url;
@@ -379,6 +556,43 @@ function populateAddForm(data) {
     }
 }
 
+// Form inputs for lookup fields whose name doesn't match the input ID
+const FORM_FIELD_IDS = {
+    cover_image_url: 'form-cover-url'
+};
+
+// Mark each add form input with the metadata provider that filled it
+function showFieldSources(sources) {
+    document.querySelectorAll('#add-form [data-source]').forEach(input => {
+        input.removeAttribute('data-source');
+        input.removeAttribute('title');
+    });
+
+    Object.entries(sources).forEach(([field, provider]) => {
+        const input = document.getElementById(FORM_FIELD_IDS[field] || `form-${field}`);
+        if (input) {
+            input.dataset.source = provider;
+            input.title = `From ${provider}`;
+        }
+    });
+}
+
+// Providers of the looked up fields still holding the looked up value.
+// Fields changed in the form are recorded as entered by hand.
+function lookupSources(laserdisc) {
+    const sources = {};
+    if (!pendingLookup || !pendingLookup.sources) {
+        return sources;
+    }
+
+    Object.entries(pendingLookup.sources).forEach(([field, provider]) => {
+        if (String(laserdisc[field] || '') === String(pendingLookup[field] || '')) {
+            sources[field] = provider;
+        }
+    });
+    return sources;
+}
+
 // Show cover image preview in form
 function showCoverPreview(url) {
     const preview = document.getElementById('form-cover-preview');
@@ -412,30 +626,94 @@ async function handleAddLaserDisc(e) {
         notes: formData.get('notes') || document.getElementById('form-notes').value || ''
     };
 
-    if (!laserdisc.upc || !laserdisc.title) {
-        showNotification('UPC and Title are required', 'error');
+    // Keep the pressing details from the lookup this form was filled from
+    if (pendingLookup) {
+        PRESSING_FIELDS.forEach(field => {
+            if (pendingLookup[field]) {
+                laserdisc[field] = pendingLookup[field];
+            }
+        });
+    }
+
+    laserdisc.sources = lookupSources(laserdisc);
+    laserdisc.copy = copyDetails();
+
+    if (!laserdisc.title) {
+        showNotification('Title is required', 'error');
         return;
     }
 
     try {
-        await apiCall('/collection', {
+        const data = await apiCall('/collection', {
             method: 'POST',
-            body: JSON.stringify(laserdisc)
+            body: JSON.stringify(laserdisc),
+            quiet: [409]
         });
 
-        showNotification('LaserDisc added successfully!', 'success');
+        if (data.similar && data.similar.length > 0) {
+            const titles = data.similar.map(match => match.year ? `${match.title} (${match.year})` : match.title);
+            showNotification(`LaserDisc added, but you may already have it: ${titles.join(', ')}`, 'warning');
+        } else {
+            showNotification('LaserDisc added successfully!', 'success');
+        }
         closeModals();
         resetAddForm();
         loadCollection(currentSearch, 0); // Refresh collection
+    } catch (error) {
+        // Owning the same release twice adds another copy of it
+        if (error.status === 409 && error.data.laserdisc_id) {
+            addAnotherCopy(error.data.laserdisc_id, laserdisc.copy);
+        }
+        // Other errors already handled in apiCall
+    }
+}
+
+// Read the add form's condition and purchase details of the copy in hand
+function copyDetails() {
+    const copy = {
+        sealed: document.getElementById('form-copy-sealed').checked,
+        sleeve_grade: document.getElementById('form-copy-sleeve-grade').value,
+        side_grades: document.getElementById('form-copy-side-grades').value.trim(),
+        purchase_date: document.getElementById('form-copy-purchase-date').value,
+        currency: document.getElementById('form-copy-currency').value.trim(),
+        seller: document.getElementById('form-copy-seller').value.trim()
+    };
+    const price = parseFloat(document.getElementById('form-copy-price').value);
+    if (!isNaN(price)) {
+        copy.purchase_price = price;
+    }
+    return copy;
+}
+
+// Add a copy of a LaserDisc already in the collection, once confirmed
+async function addAnotherCopy(id, copy) {
+    if (!confirm('This LaserDisc is already in your collection. Add it as another copy?')) {
+        return;
+    }
+
+    try {
+        await apiCall(`/collection/${id}/copies`, {
+            method: 'POST',
+            body: JSON.stringify(copy)
+        });
+
+        showNotification('Copy added successfully!', 'success');
+        closeModals();
+        resetAddForm();
+        loadCollection(currentSearch, currentOffset);
     } catch (error) {
         // Error already handled in apiCall
     }
 }
 
-// Toggle watched status
-async function toggleWatched(id) {
+// Toggle watched status. Marking a LaserDisc watched records a viewing now,
+// and marking it unwatched undoes that. A viewing history is never deleted
+// this way; the server refuses with a 409 instead. Passing watched sets the
+// status rather than toggling it.
+async function toggleWatched(id, watched) {
+    const query = watched === undefined ? '' : `?watched=${watched}`;
     try {
-        await apiCall(`/collection/${id}/watched`, {
+        await apiCall(`/collection/${id}/watched${query}`, {
             method: 'POST'
         });
 
@@ -464,17 +742,17 @@ async function deleteLaserDisc(id) {
     }
 }
 
-// Get random unwatched movie
+// Get random unwatched movie, other than those suggested this week
 async function getRandomMovie() {
     try {
-        const data = await apiCall('/random-unwatched');
+        const data = await apiCall('/random', { quiet: [404] });
         
-        if (data.laserdisc) {
-            showRandomMovieModal(data.laserdisc);
+        if (data.laserdiscs && data.laserdiscs.length > 0) {
+            showRandomMovieModal(data.laserdiscs[0]);
         }
     } catch (error) {
-        if (error.message.includes('No unwatched')) {
-            showNotification('No unwatched LaserDiscs found!', 'info');
+        if (error.status === 404) {
+            showNotification('No unwatched LaserDiscs left to suggest this week!', 'info');
         }
     }
 }
@@ -512,7 +790,7 @@ function showRandomMovieModal(laserdisc) {
 
 // Mark as watched from random modal
 async function markAsWatched(id) {
-    await toggleWatched(id);
+    await toggleWatched(id, true);
     closeModals();
 }
 
@@ -538,6 +816,8 @@ function closeModals() {
 // Reset add form
 function resetAddForm() {
     elements.addForm.reset();
+    pendingLookup = null;
+    showFieldSources({});
 }
 
 // Utility functions
@@ -572,6 +852,12 @@ function escapeHtml(text) {
     return div.innerHTML;
 }
 
+// Locally mirrored cover, passing the token since <img> can't send headers
+function coverSrc(id, size) {
+    const token = encodeURIComponent(localStorage.getItem('lddb_token') || '');
+    return `/api/collection/${id}/cover?size=${size}&token=${token}`;
+}
+
 // Edit LaserDisc functionality
 async function editLaserDisc(id) {
     try {
@@ -595,12 +881,16 @@ async function editLaserDisc(id) {
         document.getElementById('edit-cover-url').value = laserdisc.cover_image_url || '';
         document.getElementById('edit-lddb-url').value = laserdisc.lddb_url || '';
         document.getElementById('edit-notes').value = laserdisc.notes || '';
+        document.getElementById('edit-tags').value = (laserdisc.tags || []).map(tag => tag.name).join(', ');
         
         // Show cover image preview if available
         if (laserdisc.cover_image_url && !laserdisc.cover_image_url.includes('loading.gif')) {
             showEditCoverPreview(laserdisc.cover_image_url);
         }
         
+        showFieldProvenance(laserdisc.provenance || {});
+        loadPhotos(laserdisc.id);
+        
         // Open edit modal
         openModal('edit');
         
@@ -634,6 +924,10 @@ async function handleEditLaserDisc(e) {
         }
     });
 
+    // Tags are always sent, so clearing the input removes them
+    updateData.tags = document.getElementById('edit-tags').value
+        .split(',').map(tag => tag.trim()).filter(tag => tag);
+
     try {
         await apiCall(`/collection/${id}`, {
             method: 'PUT',
@@ -648,6 +942,23 @@ async function handleEditLaserDisc(e) {
     }
 }
 
+// Mark each edit form input with where its value came from
+function showFieldProvenance(provenance) {
+    document.querySelectorAll('#edit-form [data-provenance]').forEach(input => {
+        input.removeAttribute('data-provenance');
+        input.removeAttribute('title');
+    });
+
+    Object.entries(provenance).forEach(([field, entry]) => {
+        const input = document.getElementById(field === 'cover_image_url' ? 'edit-cover-url' : `edit-${field}`);
+        if (input) {
+            const date = new Date(entry.updated_at).toLocaleDateString();
+            input.dataset.provenance = entry.source;
+            input.title = entry.source === 'manual' ? `Edited by hand on ${date}` : `From ${entry.source} on ${date}`;
+        }
+    });
+}
+
 // Show cover image preview in edit form
 function showEditCoverPreview(url) {
     const preview = document.getElementById('edit-cover-preview');
@@ -663,9 +974,110 @@ function showEditCoverPreview(url) {
     }
 }
 
+// Photos of the user's own copy, shown in the edit form
+async function loadPhotos(id) {
+    const list = document.getElementById('edit-photos-list');
+    list.innerHTML = '';
+    try {
+        const data = await apiCall(`/collection/${id}/images`);
+        renderPhotos(id, data.images || []);
+    } catch (error) {
+        // Error already handled in apiCall
+    }
+}
+
+function renderPhotos(id, images) {
+    const list = document.getElementById('edit-photos-list');
+    const token = encodeURIComponent(localStorage.getItem('lddb_token') || '');
+    list.dataset.order = JSON.stringify(images.map(image => image.id));
+    list.innerHTML = images.length === 0 ? '<p class="photo-empty">No photos yet</p>' : images.map((image, i) => `
+        <div class="photo-item ${image.primary ? 'primary' : ''}">
+            <img src="/api/collection/${id}/images/${image.id}/file?token=${token}" alt="${escapeHtml(image.kind)}" loading="lazy">
+            <span class="photo-kind">${escapeHtml(image.kind)}${image.primary ? ' · cover' : ''}</span>
+            <div class="photo-actions">
+                <button type="button" title="Move left" ${i === 0 ? 'disabled' : ''} onclick="movePhoto(${id}, ${image.id}, -1)">&larr;</button>
+                <button type="button" title="Move right" ${i === images.length - 1 ? 'disabled' : ''} onclick="movePhoto(${id}, ${image.id}, 1)">&rarr;</button>
+                <button type="button" title="Use as cover" ${image.primary ? 'disabled' : ''} onclick="setPrimaryPhoto(${id}, ${image.id})">&#9733;</button>
+                <button type="button" title="Delete" onclick="deletePhoto(${id}, ${image.id})">&times;</button>
+            </div>
+        </div>
+    `).join('');
+}
+
+async function uploadPhotos(e) {
+    const files = e.target.files;
+    const id = document.getElementById('edit-id').value;
+    if (!files.length || !id) return;
+
+    const form = new FormData();
+    for (const file of files) {
+        form.append('image', file);
+    }
+    form.append('kind', document.getElementById('photo-kind').value);
+
+    try {
+        // Replace the JSON headers so the browser sets the multipart boundary
+        const token = localStorage.getItem('lddb_token');
+        await apiCall(`/collection/${id}/images`, {
+            method: 'POST',
+            headers: { 'Authorization': token ? `Bearer ${token}` : '' },
+            body: form
+        });
+        showNotification('Photos uploaded', 'success');
+        loadPhotos(id);
+    } catch (error) {
+        // Error already handled in apiCall
+    }
+    e.target.value = '';
+}
+
+async function movePhoto(id, imageId, direction) {
+    const order = JSON.parse(document.getElementById('edit-photos-list').dataset.order || '[]');
+    const from = order.indexOf(imageId);
+    const to = from + direction;
+    if (from < 0 || to < 0 || to >= order.length) return;
+    [order[from], order[to]] = [order[to], order[from]];
+
+    try {
+        const data = await apiCall(`/collection/${id}/images`, {
+            method: 'PUT',
+            body: JSON.stringify({ image_ids: order })
+        });
+        renderPhotos(id, data.images || []);
+    } catch (error) {
+        // Error already handled in apiCall
+    }
+}
+
+async function setPrimaryPhoto(id, imageId) {
+    try {
+        await apiCall(`/collection/${id}/images/${imageId}/primary`, { method: 'PUT' });
+        showNotification('Cover updated', 'success');
+        loadPhotos(id);
+        loadCollection(currentSearch, currentOffset);
+    } catch (error) {
+        // Error already handled in apiCall
+    }
+}
+
+async function deletePhoto(id, imageId) {
+    if (!confirm('Delete this photo?')) return;
+    try {
+        await apiCall(`/collection/${id}/images/${imageId}`, { method: 'DELETE' });
+        loadPhotos(id);
+    } catch (error) {
+        // Error already handled in apiCall
+    }
+}
+
 // Global functions for inline event handlers
 window.toggleWatched = toggleWatched;
 window.deleteLaserDisc = deleteLaserDisc;
 window.editLaserDisc = editLaserDisc;
 window.markAsWatched = markAsWatched;
-window.closeModals = closeModals;
\ No newline at end of file
+window.lookupLDDBID = lookupLDDBID;
+window.searchTitle = searchTitle;
+window.closeModals = closeModals;
+window.movePhoto = movePhoto;
+window.setPrimaryPhoto = setPrimaryPhoto;
+window.deletePhoto = deletePhoto;
\ No newline at end of file